@@ -1,10 +1,11 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
@@ -17,21 +18,48 @@ import (
 	"github.com/peithosecure/peitho-backend/internal/api/handlers"
 	passwordreset "github.com/peithosecure/peitho-backend/internal/api/handlers"
 	"github.com/peithosecure/peitho-backend/internal/api/routes"
+	"github.com/peithosecure/peitho-backend/internal/audit"
+	"github.com/peithosecure/peitho-backend/internal/auth/peitho"
+	"github.com/peithosecure/peitho-backend/internal/buildinfo"
 	"github.com/peithosecure/peitho-backend/internal/config"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/export"
+	"github.com/peithosecure/peitho-backend/internal/health"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
+	"github.com/peithosecure/peitho-backend/internal/listener"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
+	"github.com/peithosecure/peitho-backend/internal/logging"
 	"github.com/peithosecure/peitho-backend/internal/metrics"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
+	"github.com/peithosecure/peitho-backend/internal/reload"
+	"github.com/peithosecure/peitho-backend/internal/retention"
+	"github.com/peithosecure/peitho-backend/internal/scheduler"
+	"github.com/peithosecure/peitho-backend/internal/trace"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"github.com/peithosecure/peitho-backend/internal/webhooks"
+	"github.com/peithosecure/peitho-backend/pkg/moodreactor"
 )
 
-const unlockPath = "/app/peitho-core/unlock.lic"
-
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("⚠️  No .env file found. We're flying environmental freestyle.")
 	}
 
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("🧱 Config load failed. Even IKEA gives better instructions: %v", err)
+	}
+	if err := logging.Init(cfg); err != nil {
+		log.Fatalf("🪵 Logger misconfigured: %v", err)
+	}
+	slog.Info("📦 Loaded config", "file", config.ConfigFileFromEnv(), "config", cfg)
+	build := buildinfo.Get()
+	slog.Info("🏷️ Build", "version", build.Version, "commit", build.Commit, "date", build.Date, "go", build.GoVersion)
+
 	// 🥩 Roast-Only Mode
-	if os.Getenv("PEITHO_ROAST_ONLY") == "true" {
+	if cfg.RoastOnly {
 		printAsciiBanner()
 		log.Println("💥 Roast Engine™ stub initialized.")
 		log.Println("🧠 Memory is empty. No unlock present.")
@@ -41,7 +69,8 @@ func main() {
 	}
 
 	// ⏳ Ensure license presence
-	waitForLicense(unlockPath)
+	waitForLicense(cfg.UnlockPath)
+	handlers.ValidateLicense(cfg)
 
 	// 🔐 Validate license if not already trusted
 	unlocked, _ := licenseguard.UnlockStatus()
@@ -53,7 +82,7 @@ func main() {
 			log.Printf("🔥 %s", payload.Message)
 			log.Printf("🧬 Runtime Engine Hash: %s", licenseguard.CurrentEngineHash())
 
-			if raw, rerr := os.ReadFile(unlockPath); rerr == nil {
+			if raw, rerr := os.ReadFile(cfg.UnlockPath); rerr == nil {
 				log.Printf("📄 unlock.lic contents: %s", truncateMiddle(string(raw), 160))
 				parts := splitParts(string(raw))
 				if len(parts) == 2 {
@@ -82,45 +111,214 @@ func main() {
 		log.Println("🧬 Skipping license validation — you already unlocked the boss room.")
 	}
 
-	sqlite.InitDB()
+	// registered first so its hook runs last, flushing the spans of every other shutdown step
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("🔭 Tracing misconfigured: %v", err)
+	}
+	lifecycle.OnShutdown("tracing", shutdownTracing)
+	if cfg.TracingExporter != tracing.ExporterNone {
+		slog.Info("🔭 Tracing enabled", "exporter", cfg.TracingExporter, "sample_percent", cfg.TracingSamplePercent)
+	}
 
-	cfg, err := config.LoadConfig()
+	sqlite.InitDB(cfg.SQLitePath)
+	lifecycle.OnShutdown("database", func(context.Context) error { return sqlite.Close() })
+	// registered before the event bus, so it runs once events have drained and before the
+	// database closes
+	audit.Init(cfg)
+	lifecycle.OnShutdown("audit-checkpoint", audit.FinalCheckpoint)
+
+	if err := trace.Init(cfg.TraceRingSize); err != nil {
+		log.Fatalf("📼 Trace engine failed to warm up: %v", err)
+	}
+	if err := lockdown.Init(cfg); err != nil {
+		log.Fatalf("🚨 Lockdown engine failed to arm: %v", err)
+	}
+	if err := passwordpolicy.Init(cfg); err != nil {
+		log.Fatalf("🔑 Password policy failed to load: %v", err)
+	}
+	if err := middleware.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("🕵️ TRUSTED_PROXIES is malformed: %v", err)
+	}
+	middleware.SetCORSPolicy(corsPolicy(cfg))
+	middleware.SetSecurityPolicy(securityPolicy(cfg))
+	middleware.SetLoginPolicy(loginPolicy(cfg))
+	if err := middleware.SetAdminPolicy(adminPolicy(cfg)); err != nil {
+		log.Fatalf("🛡️ Admin auth misconfigured: %v", err)
+	}
+	if !middleware.AdminEnabled() {
+		slog.Warn("🛡️ Admin endpoints are disabled (PEITHO_ADMIN_AUTH=none)")
+	}
+	events.RegisterBuiltins()
+	events.Subscribe("moodreactor", func(ev events.Event) {
+		moodreactor.UpdateMoodState(string(ev.Type))
+	}, events.TamperDetected, events.RateLimited, events.LicenseInvalid)
+	lifecycle.OnShutdown("events", func(context.Context) error { events.Close(); return nil })
+	webhooks.Start(cfg)
+	lifecycle.OnShutdown("webhooks", func(context.Context) error { webhooks.Stop(); return nil })
+	audit.ScheduleCheckpoints()
+	if err := retention.Schedule(cfg); err != nil {
+		log.Fatalf("🧹 Retention misconfigured: %v", err)
+	}
+	tlsConf, err := listener.TLSConfig(cfg)
 	if err != nil {
-		log.Fatalf("🧱 Config load failed. Even IKEA gives better instructions: %v", err)
+		log.Fatalf("🔐 Listener misconfigured: %v", err)
 	}
-	log.Printf("📦 Loaded Config: %+v", cfg)
+	registerReloadHooks()
+	reload.Start(cfg, config.ConfigFileFromEnv())
+	scheduler.Start()
+	lifecycle.OnShutdown("scheduler", func(context.Context) error { scheduler.Stop(); return nil })
+	if err := export.StartSyslog(cfg); err != nil {
+		log.Fatalf("📡 Syslog forwarder misconfigured: %v", err)
+	}
+	lifecycle.OnShutdown("syslog", func(context.Context) error { export.StopSyslog(); return nil })
 
 	handlers.InitWithConfig(cfg)
-	handlers.InitMetricsHandler(cfg)
-	handlers.InitEmailService()
+	handlers.InitEmailService(cfg)
 	handlers.InitIntegrationHandler(cfg)
 	passwordreset.InjectConfig(cfg)
+	registerHealthChecks(cfg)
 
 	metrics.RegisterTokenMetrics()
+	metrics.RegisterEventMetrics()
+	metrics.RegisterRetentionMetrics()
+	metrics.RegisterConfigMetrics()
+	metrics.RegisterHTTPMetrics()
+	metrics.RegisterAuthMetrics()
+	metrics.RegisterEmailMetrics()
+	metrics.RegisterDBMetrics()
+	metrics.RegisterLicenseMetrics()
+	_ = checkLicense(cfg.UnlockPath)
 
 	log.Println("🔧 Initializing routes...")
 	router := routes.SetupRoutes()
-	handler := middleware.CorsMiddleware(middleware.LoggerMiddleware(router))
+	handler := middleware.SecurityHeaders(middleware.CorsMiddleware(middleware.RequestID(middleware.ClientCert(middleware.LoggerMiddleware(router)))))
 	log.Println("✅ Routes wired and ready for judgment day.")
 
+	if cfg.InternalAddr != "" {
+		internal := middleware.RequestID(middleware.LoggerMiddleware(routes.SetupInternalRoutes()))
+		if err := listener.StartInternal(cfg.InternalAddr, internal); err != nil {
+			log.Fatalf("🔧 Internal listener failed to bind: %v", err)
+		}
+	}
+
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
 		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
-		TLSConfig: &tls.Config{
-			MinVersion: tls.VersionTLS13,
-		},
+		TLSConfig:    tlsConf,
 	}
 
-	log.Printf("🚀 PeithoSecure Lite server armed and dangerous on port %s...", cfg.Port)
+	ln, err := listener.Listen(server)
+	if err != nil {
+		log.Fatalf("💣 Server failed to bind port %s: %v", cfg.Port, err)
+	}
+	log.Printf("🚀 PeithoSecure Lite server armed and dangerous on port %s (%s)...", cfg.Port, cfg.ListenMode)
+
+	err = lifecycle.Serve(server, ln, listener.Serve(server, cfg),
+		lifecycle.Options{DrainDelay: cfg.ShutdownDrain, Timeout: cfg.ShutdownTimeout})
+	if err != nil {
+		log.Fatalf("💣 Server Error — pulled the wrong wire: %v", err)
+	}
+}
+
+// registerReloadHooks lists the components that pick up reloadable settings on SIGHUP or a
+// config file change. Each checks the new settings first; none is applied unless all accept.
+func registerReloadHooks() {
+	reload.Register("logger", func(cfg *config.Config) (func(), error) {
+		if _, err := logging.ParseLevel(cfg.LogLevel); err != nil {
+			return nil, err
+		}
+		return func() { _ = logging.SetLevel(cfg.LogLevel) }, nil
+	})
+	reload.Register("cors", func(cfg *config.Config) (func(), error) {
+		return func() { middleware.SetCORSPolicy(corsPolicy(cfg)) }, nil
+	})
+	reload.Register("security-headers", func(cfg *config.Config) (func(), error) {
+		return func() { middleware.SetSecurityPolicy(securityPolicy(cfg)) }, nil
+	})
+	reload.Register("rate-limiter", func(cfg *config.Config) (func(), error) {
+		return func() { middleware.SetLoginPolicy(loginPolicy(cfg)) }, nil
+	})
+	reload.Register("admin-auth", func(cfg *config.Config) (func(), error) {
+		return middleware.PrepareAdminPolicy(adminPolicy(cfg))
+	})
+	reload.Register("mailer", func(cfg *config.Config) (func(), error) {
+		return func() { handlers.InitEmailService(cfg) }, nil
+	})
+}
+
+// registerHealthChecks lists the dependencies /readyz probes. Only the database and the
+// license take the server out of rotation; the others report it degraded, since another
+// replica would see the same Keycloak or mail server outage.
+func registerHealthChecks(cfg *config.Config) {
+	health.Configure(cfg.HealthTimeout, cfg.HealthCacheTTL)
+	health.Register("sqlite", sqlite.Ping, health.Options{Critical: true})
+	health.Register("license", func(context.Context) error {
+		return checkLicense(cfg.UnlockPath)
+	}, health.Options{Critical: true})
+	if cfg.KeycloakInternalURL != "" {
+		health.Register("keycloak", health.HTTP(cfg.KeycloakInternalURL+"/realms/master/protocol/openid-connect/token"), health.Options{})
+	}
+	health.Register("jwks", health.JWKS(middleware.JWKSURL()), health.Options{})
+	health.Register("smtp", health.SMTP(handlers.SMTPAddr), health.Options{})
+}
+
+func loginPolicy(cfg *config.Config) middleware.LoginPolicy {
+	return middleware.LoginPolicy{
+		MaxAttempts: cfg.LoginMaxAttempts,
+		Window:      cfg.LoginWindow,
+		Lockout:     cfg.LoginLockout,
+	}
+}
+
+// checkLicense verifies unlock.lic and publishes the result as the peitho_license_* gauges
+func checkLicense(path string) error {
+	lic, err := peitho.CheckLicenseFile(path)
+	metrics.LicenseLastCheck.SetToCurrentTime()
+	if err != nil {
+		metrics.LicenseValid.Set(0)
+		return err
+	}
+	metrics.LicenseValid.Set(1)
+	if issued, perr := time.Parse(time.RFC3339, lic.IssuedAt); perr == nil {
+		metrics.LicenseIssued.Set(float64(issued.Unix()))
+	} else {
+		metrics.LicenseIssued.Set(0)
+	}
+	return nil
+}
+
+func corsPolicy(cfg *config.Config) middleware.CORSPolicy {
+	return middleware.CORSPolicy{
+		Origins:          cfg.CORSOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
+
+func securityPolicy(cfg *config.Config) middleware.SecurityPolicy {
+	return middleware.SecurityPolicy{
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		HSTSPreload:           cfg.HSTSPreload,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+		FrameAncestors:        cfg.FrameAncestors,
+	}
+}
 
-	if err := server.ListenAndServeTLS(
-		os.Getenv("TLS_CERT_FILE"),
-		os.Getenv("TLS_KEY_FILE"),
-	); err != nil {
-		log.Fatalf("💣 TLS Server Error — pulled the wrong wire: %v", err)
+func adminPolicy(cfg *config.Config) middleware.AdminPolicy {
+	return middleware.AdminPolicy{
+		Methods:      cfg.AdminAuth,
+		Role:         cfg.AdminRole,
+		Username:     cfg.AdminUsername,
+		PasswordHash: cfg.AdminPasswordHash,
+		AllowedIPs:   cfg.AdminAllowedIPs,
 	}
 }
 
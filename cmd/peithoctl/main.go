@@ -0,0 +1,400 @@
+// Command peithoctl is the operator CLI for a PeithoSecure Lite database.
+//
+//	peithoctl audit verify    walk the audit hash chain and signed checkpoints
+//	peithoctl audit export    stream audit or trace events as JSONL, CSV or CEF
+//	peithoctl syslog listen   print syslog messages received on a local port
+//	peithoctl password check  evaluate a password against the configured policy
+//	peithoctl admin hash-password  hash the admin basic-auth password for PEITHO_ADMIN_PASSWORD_HASH
+//	peithoctl breach index    build the offline breached-password index from a HIBP dump
+//	peithoctl config check    load and validate the configuration, showing where each value came from
+//	peithoctl security scan   run the Prowler security checks and print the report as JSON or SARIF
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/peithosecure/peitho-backend/internal/audit"
+	"github.com/peithosecure/peitho-backend/internal/breach"
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/export"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
+	"github.com/peithosecure/peitho-backend/pkg/prowler"
+)
+
+const usage = `usage: peithoctl <command> [args]
+
+commands:
+  audit verify    verify the audit hash chain; exits 1 when it is broken
+  audit export    stream events to stdout (or -out); the resume cursor is printed to stderr
+  syslog listen   print RFC 5424 messages received over udp, tcp or tls, for testing a forwarder
+  password check  evaluate passwords read from stdin (one per line) against the PEITHO_PASSWORD_*
+                  policy; exits 1 when any fails. Needs no database or identity provider.
+  admin hash-password
+                  read the admin basic-auth password from stdin and print its argon2id hash
+                  for PEITHO_ADMIN_PASSWORD_HASH
+  breach index    build the PEITHO_BREACH_INDEX file from a HIBP SHA-1 dump ordered by hash
+  config check    print the effective configuration (secrets redacted) and every validation
+                  error; exits 1 when the server would refuse to start
+  security scan   run the security checks against this configuration and print the report as
+                  JSON or SARIF (-format sarif); exits 1 when a check at or above -fail-on fails
+
+Configuration is loaded as for the server: PEITHO_CONFIG_FILE, then the environment.
+`
+
+func main() {
+	_ = godotenv.Load()
+	log.SetOutput(os.Stderr)
+
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] + " " + os.Args[2] {
+	case "audit verify":
+		os.Exit(auditVerify())
+	case "audit export":
+		os.Exit(auditExport(os.Args[3:]))
+	case "syslog listen":
+		os.Exit(syslogListen(os.Args[3:]))
+	case "password check":
+		os.Exit(passwordCheck(os.Args[3:]))
+	case "admin hash-password":
+		os.Exit(adminHashPassword())
+	case "breach index":
+		os.Exit(breachIndex(os.Args[3:]))
+	case "config check":
+		os.Exit(configCheck(os.Args[3:]))
+	case "security scan":
+		os.Exit(securityScan(os.Args[3:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func auditVerify() int {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	sqlite.InitDB(cfg.SQLitePath)
+	audit.Init(cfg)
+
+	report, err := audit.Verify(context.Background())
+	if err != nil {
+		log.Fatalf("❌ Verification could not run: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+func auditExport(args []string) int {
+	fs := flag.NewFlagSet("audit export", flag.ExitOnError)
+	source := fs.String("source", export.SourceAudit, "audit or trace")
+	format := fs.String("format", export.FormatJSONL, "jsonl, csv or cef")
+	since := fs.String("since", "", "only events at or after this RFC3339 time")
+	until := fs.String("until", "", "only events at or before this RFC3339 time")
+	cursor := fs.String("cursor", "", "resume after this id")
+	limit := fs.Int("limit", 0, "maximum records (0 = everything)")
+	outPath := fs.String("out", "", "write to this file instead of stdout")
+	_ = fs.Parse(args)
+
+	f := export.Filter{Source: *source, Limit: *limit}
+	var err error
+	if f.After, err = export.ParseCursor(*cursor); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if *since != "" {
+		if f.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+			log.Fatalf("❌ -since: %v", err)
+		}
+	}
+	if *until != "" {
+		if f.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			log.Fatalf("❌ -until: %v", err)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	w, err := export.NewWriter(*format, out)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	sqlite.InitDB(cfg.SQLitePath)
+	next, n, err := export.Stream(context.Background(), f, w)
+	fmt.Fprintf(os.Stderr, "exported %d record(s); resume with -cursor %d\n", n, next)
+	if err != nil {
+		log.Printf("❌ Export stopped early: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func syslogListen(args []string) int {
+	fs := flag.NewFlagSet("syslog listen", flag.ExitOnError)
+	network := fs.String("network", "udp", "udp, tcp or tls")
+	addr := fs.String("addr", "127.0.0.1:5514", "listen address")
+	cert := fs.String("cert", "", "TLS certificate (tls only)")
+	key := fs.String("key", "", "TLS key (tls only)")
+	_ = fs.Parse(args)
+
+	if *network == "udp" {
+		conn, err := net.ListenPacket("udp", *addr)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("👂 Listening for syslog on udp://%s", conn.LocalAddr())
+		buf := make([]byte, 64<<10)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+			fmt.Println(string(buf[:n]))
+		}
+	}
+
+	var ln net.Listener
+	var err error
+	switch *network {
+	case "tcp":
+		ln, err = net.Listen("tcp", *addr)
+	case "tls":
+		var pair tls.Certificate
+		if pair, err = tls.LoadX509KeyPair(*cert, *key); err == nil {
+			ln, err = tls.Listen("tcp", *addr, &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12})
+		}
+	default:
+		err = fmt.Errorf("unknown network %q", *network)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("👂 Listening for syslog on %s://%s", *network, ln.Addr())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		go printFramed(conn)
+	}
+}
+
+// printFramed prints octet-counted (RFC 6587) messages until the peer disconnects
+func printFramed(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil || n <= 0 {
+			log.Printf("⚠️ Bad frame length %q from %s", size, conn.RemoteAddr())
+			return
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return
+		}
+		fmt.Println(string(msg))
+	}
+}
+
+// passwordCheck reads candidates from stdin so they stay out of shell history and ps output
+func passwordCheck(args []string) int {
+	fs := flag.NewFlagSet("password check", flag.ExitOnError)
+	username := fs.String("username", "", "username the password must not contain")
+	email := fs.String("email", "", "email address the password must not contain")
+	_ = fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	policy, err := passwordpolicy.Load(cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	status := 0
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		res := policy.Evaluate(scanner.Text(), *username, *email)
+		_ = enc.Encode(res)
+		if !res.OK {
+			status = 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return status
+}
+
+// adminHashPassword reads a single line so the password stays out of argv and shell history
+func adminHashPassword() int {
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+	password := strings.TrimRight(scanner.Text(), "\r")
+	if password == "" {
+		log.Println("❌ Empty password; pipe the admin password in on stdin")
+		return 1
+	}
+	hash, err := passwordpolicy.Hash(password)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println(hash)
+	return 0
+}
+
+func breachIndex(args []string) int {
+	fs := flag.NewFlagSet("breach index", flag.ExitOnError)
+	in := fs.String("in", "-", "HIBP SHA-1 dump (HASH:COUNT lines ordered by hash); - for stdin")
+	out := fs.String("out", os.Getenv("PEITHO_BREACH_INDEX"), "index file to write (default $PEITHO_BREACH_INDEX)")
+	prefix := fs.Int("prefix-bytes", breach.DefaultPrefixBytes, "bytes of each hash to keep (4-20); fewer is smaller but less exact")
+	minCount := fs.Int("min-count", 0, "skip hashes seen in fewer breaches than this")
+	_ = fs.Parse(args)
+
+	if *out == "" {
+		log.Fatalf("❌ -out is required when PEITHO_BREACH_INDEX is unset")
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	started := time.Now()
+	stats, err := breach.Build(r, *out, breach.BuildOptions{PrefixBytes: *prefix, MinCount: *minCount})
+	if err != nil {
+		log.Printf("❌ Index build failed after %d line(s): %v", stats.Lines, err)
+		return 1
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(stats)
+	log.Printf("✅ Wrote %d hash(es) to %s in %s", stats.Written, *out, time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+func configCheck(args []string) int {
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	file := fs.String("file", config.ConfigFileFromEnv(), "YAML or TOML config file (default $PEITHO_CONFIG_FILE)")
+	asJSON := fs.Bool("json", false, "print settings as JSON")
+	_ = fs.Parse(args)
+
+	cfg, err := config.Load(*file)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(cfg.Settings())
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENV\tKEY\tVALUE\tSOURCE")
+		for _, s := range cfg.Settings() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Env, s.Key, s.Value, s.Source)
+		}
+		_ = tw.Flush()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "\n❌ Configuration is invalid:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "\n✅ Configuration is valid")
+	return 0
+}
+
+func securityScan(args []string) int {
+	fs := flag.NewFlagSet("security scan", flag.ExitOnError)
+	format := fs.String("format", prowler.FormatJSON, "json or sarif")
+	outPath := fs.String("out", "", "write to this file instead of stdout")
+	failOn := fs.String("fail-on", string(prowler.SeverityHigh), "lowest severity whose failure exits 1: info, low, medium, high or critical")
+	_ = fs.Parse(args)
+
+	if *format != prowler.FormatJSON && *format != prowler.FormatSARIF {
+		log.Fatalf("❌ -format: %v", prowler.ErrInvalidFormat)
+	}
+	switch prowler.Severity(*failOn) {
+	case prowler.SeverityInfo, prowler.SeverityLow, prowler.SeverityMedium, prowler.SeverityHigh, prowler.SeverityCritical:
+	default:
+		log.Fatalf("❌ -fail-on must be info, low, medium, high or critical")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	report := prowler.Scan(context.Background(), cfg)
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := prowler.Write(out, *format, report); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	for _, f := range report.Findings {
+		if (f.Status == prowler.StatusFail || f.Status == prowler.StatusError) && f.Severity.AtLeast(prowler.Severity(*failOn)) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+)
+
+// MaxBodyBytes caps every JSON request body read through DecodeJSON.
+const MaxBodyBytes int64 = 1 << 20
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON name so problem details match the payload the client sent
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// DecodeJSON reads r's body into dst and validates it against dst's `validate`
+// tags. On failure it writes a problem response and returns false; parseCode is
+// the handler's catalog code for bodies that are not valid JSON for dst.
+//
+// The body must be declared as application/json, may not exceed MaxBodyBytes,
+// may not contain fields dst does not declare and must hold exactly one value.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any, parseCode string) bool {
+	if !isJSON(r.Header.Get("Content-Type")) {
+		problem.RespondWithDetail(w, r, "unsupported_media_type", http.StatusUnsupportedMediaType,
+			"Content-Type must be application/json")
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		respondDecodeError(w, r, err, parseCode)
+		return false
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondDecodeError(w, r, err, parseCode)
+			return false
+		}
+		problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, "request body must contain a single JSON value")
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, err.Error())
+			return false
+		}
+		fields := make([]problem.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, problem.FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: message(fe),
+			})
+		}
+		problem.RespondWithFields(w, r, "validation_failed", http.StatusUnprocessableEntity, fields)
+		return false
+	}
+	return true
+}
+
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+func respondDecodeError(w http.ResponseWriter, r *http.Request, err error, parseCode string) {
+	var (
+		maxErr    *http.MaxBytesError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &maxErr):
+		problem.RespondWithDetail(w, r, "request_too_large", http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body must not exceed %d bytes", maxErr.Limit))
+	case errors.Is(err, io.EOF):
+		problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, "request body must not be empty")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, "request body is not valid JSON")
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, "request body must be a JSON object")
+			return
+		}
+		problem.RespondWithFields(w, r, parseCode, http.StatusBadRequest, []problem.FieldError{{
+			Field:   field,
+			Code:    "type",
+			Message: fmt.Sprintf("must be of type %s", jsonType(typeErr.Type)),
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for DisallowUnknownFields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		problem.RespondWithFields(w, r, parseCode, http.StatusBadRequest, []problem.FieldError{{
+			Field:   field,
+			Code:    "unknown",
+			Message: "is not a recognised field",
+		}})
+	default:
+		problem.RespondWithDetail(w, r, parseCode, http.StatusBadRequest, err.Error())
+	}
+}
+
+// fieldPath drops the top-level struct name from the validator namespace,
+// leaving a JSON path such as "events[0]".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "ip":
+		return "must be a valid IP address"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
+)
+
+// ForcePasswordResetHandler godoc
+// @Summary Force a password reset
+// @Description Flags the account so the next login reports password_expired. The flag clears once the user sets a new password via setup-password or reset-password.
+// @Tags Admin
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} GenericMessageResponse
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 500 {object} problem.Problem "Flag could not be stored"
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{username}/force-password-reset [post]
+func ForcePasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	admin, _ := middleware.ExtractUsernameFromContext(r.Context())
+
+	if err := sqlite.SetForcePasswordReset(r.Context(), username, true); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			problem.Respond(w, r, "user_not_found", http.StatusNotFound)
+			return
+		}
+		audit.Record(r, audit.Failure(username, "password_reset_forced", "store_failed"))
+		problem.Respond(w, r, "force_reset_failed", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r, audit.Entry{
+		Username: username,
+		Event:    "password_reset_forced",
+		Outcome:  models.OutcomeSuccess,
+		Reason:   "forced by " + admin,
+	})
+	events.Publish(events.Event{
+		Type:      events.PasswordResetForced,
+		Username:  username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Reason:    "forced by " + admin,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(GenericMessageResponse{
+		Message: "Password reset required at next login",
+	})
+}
@@ -2,40 +2,234 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
+const (
+	maxAuditPageSize     = 500
+	defaultSummaryWindow = 30 * 24 * time.Hour
+)
+
 // AuditAnalyticsHandler godoc
 // @Summary View audit activity
-// @Description Returns a timeline of recent audit events for this user
+// @Description Returns the caller's audit events, newest first by default. When more results exist the X-Next-Cursor header carries the cursor for the next page.
 // @Tags Analytics
 // @Produce json
 // @Security BearerAuth
+// @Param type query string false "Comma-separated event types (e.g. login_failed,password_reset)"
+// @Param ip query string false "Filter by client IP"
+// @Param outcome query string false "Filter by outcome: success or failure"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param until query string false "Only events at or before this RFC3339 time"
+// @Param order query string false "Sort order by id: desc (default) or asc"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor from a previous X-Next-Cursor header"
 // @Success 200 {array} models.AuditEvent
-// @Failure 401 {object} handlers.GenericErrorResponse
-// @Failure 500 {object} handlers.GenericErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
 // @Router /api/v1/analytics/audit [get]
 func AuditAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
 	username, err := middleware.ExtractUsernameFromContext(r.Context())
 	if err != nil || username == "" {
-		corestub.RespondWithTraceError(w, "unauthorized", http.StatusUnauthorized)
+		problem.Respond(w, r, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		problem.RespondWithDetail(w, r, "invalid_audit_filter", http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Username = username
+
+	writeAuditPage(w, r, filter)
+}
+
+// AdminAuditHandler godoc
+// @Summary Query the audit log across all users
+// @Description Same filters as /api/v1/analytics/audit, plus an optional username. When more results exist the X-Next-Cursor header carries the cursor for the next page.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param username query string false "Filter by username"
+// @Param type query string false "Comma-separated event types"
+// @Param ip query string false "Filter by client IP"
+// @Param outcome query string false "Filter by outcome: success or failure"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param until query string false "Only events at or before this RFC3339 time"
+// @Param order query string false "Sort order by id: desc (default) or asc"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor from a previous X-Next-Cursor header"
+// @Success 200 {array} models.AuditEvent
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/audit [get]
+func AdminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		problem.RespondWithDetail(w, r, "invalid_audit_filter", http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Username = r.URL.Query().Get("username")
+
+	writeAuditPage(w, r, filter)
+}
+
+// AdminAuditSummaryHandler godoc
+// @Summary Audit event counts per day
+// @Description Aggregates audit events by UTC day and event type, newest day first. Defaults to the last 30 days when since is omitted.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param username query string false "Filter by username"
+// @Param type query string false "Comma-separated event types"
+// @Param ip query string false "Filter by client IP"
+// @Param outcome query string false "Filter by outcome: success or failure"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param until query string false "Only events at or before this RFC3339 time"
+// @Success 200 {array} models.AuditDailyCount
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/audit/summary [get]
+func AdminAuditSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		problem.RespondWithDetail(w, r, "invalid_audit_filter", http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Username = r.URL.Query().Get("username")
+	if filter.Since.IsZero() {
+		filter.Since = time.Now().UTC().Add(-defaultSummaryWindow)
+	}
+
+	counts, err := sqlite.CountAuditEventsByDay(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "❌ Failed to aggregate audit events", "error", err)
+		problem.Respond(w, r, "audit_query_failed", http.StatusInternalServerError)
+		return
+	}
+	if counts == nil {
+		counts = []models.AuditDailyCount{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(counts)
+}
+
+// AdminAuditVerifyHandler godoc
+// @Summary Verify the audit hash chain
+// @Description Walks every audit row recomputing its hash and link to the previous row, then checks each signed checkpoint. Reports the first broken link.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} audit.Report "Verification ran; see ok and broken_at_id"
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /api/v1/admin/audit/verify [get]
+func AdminAuditVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := audit.Verify(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "❌ Audit chain verification failed to run", "error", err)
+		problem.Respond(w, r, "audit_verify_failed", http.StatusInternalServerError)
 		return
 	}
+	if !report.OK {
+		slog.ErrorContext(r.Context(), "🚨 Audit chain broken", "event_id", report.BrokenAtID, "problem", report.Problem)
+	}
 
-	log.Printf("🔍 AuditAnalyticsHandler called for: %s", username)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
 
-	events, err := sqlite.GetAuditEventsByUsername(username, 50)
+func writeAuditPage(w http.ResponseWriter, r *http.Request, filter sqlite.AuditFilter) {
+	events, err := sqlite.QueryAuditEvents(r.Context(), filter)
 	if err != nil {
-		log.Printf("❌ Failed to fetch audit events for %s: %v", username, err)
-		corestub.RespondWithTraceError(w, "audit_query_failed", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "❌ Failed to query audit events", "error", err)
+		problem.Respond(w, r, "audit_query_failed", http.StatusInternalServerError)
 		return
 	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
 
+	if len(events) == filter.Limit {
+		w.Header().Set("X-Next-Cursor", strconv.Itoa(events[len(events)-1].ID))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(events)
 }
+
+func parseAuditFilter(r *http.Request) (sqlite.AuditFilter, error) {
+	q := r.URL.Query()
+	f := sqlite.AuditFilter{
+		IP:    q.Get("ip"),
+		Limit: 50,
+	}
+
+	for _, t := range strings.Split(q.Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.EventTypes = append(f.EventTypes, t)
+		}
+	}
+
+	switch f.Outcome = strings.ToLower(q.Get("outcome")); f.Outcome {
+	case "", models.OutcomeSuccess, models.OutcomeFailure:
+	default:
+		return f, errors.New("outcome must be success or failure")
+	}
+
+	switch strings.ToLower(q.Get("order")) {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return f, errors.New("order must be asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, err
+		}
+		if n <= 0 {
+			return f, errors.New("limit must be positive")
+		}
+		if n > maxAuditPageSize {
+			n = maxAuditPageSize
+		}
+		f.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return f, errors.New("invalid cursor")
+		}
+		f.AfterID = id
+	}
+
+	var err error
+	if f.Since, err = parseTimeParam(q.Get("since")); err != nil {
+		return f, err
+	}
+	if f.Until, err = parseTimeParam(q.Get("until")); err != nil {
+		return f, err
+	}
+	return f, nil
+}
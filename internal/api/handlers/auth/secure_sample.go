@@ -3,7 +3,7 @@ package handlers
 import (
 	"net/http"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
@@ -11,7 +11,7 @@ import (
 func SecureSampleHandler(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey)
 	if user == nil {
-		corestub.RespondWithTraceError(w, "unauthorized_access", http.StatusUnauthorized)
+		problem.Respond(w, r, "unauthorized_access", http.StatusUnauthorized)
 		return
 	}
 
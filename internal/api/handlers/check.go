@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
@@ -16,19 +16,19 @@ import (
 // @Produce json
 // @Param email query string true "Email address to check"
 // @Success 200 {object} CheckEmailResponse
-// @Failure 400 {object} map[string]string "Invalid email"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} problem.Problem "Invalid email"
+// @Failure 500 {object} problem.Problem "Internal server error"
 // @Router /api/v1/auth/check [get]
 func CheckEmailHandler(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
 	if email == "" || !isValidEmail(email) {
-		corestub.RespondWithTraceError(w, "email_invalid", http.StatusBadRequest)
+		problem.Respond(w, r, "email_invalid", http.StatusBadRequest)
 		return
 	}
 
-	user, err := sqlite.GetUserByEmail(email)
+	user, err := sqlite.GetUserByEmail(r.Context(), email)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "check_email_failed", http.StatusInternalServerError)
+		problem.Respond(w, r, "check_email_failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -47,7 +47,7 @@ func CheckEmailHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		corestub.RespondWithTraceError(w, "encode_fail", http.StatusInternalServerError)
+		problem.Respond(w, r, "encode_fail", http.StatusInternalServerError)
 	}
 }
 
@@ -64,13 +64,13 @@ type CheckEmailResponse struct {
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 401 {object} problem.Problem "Unauthorized"
 // @Security BearerAuth
 // @Router /api/v1/auth/secure-sample [get]
 func SecureSampleHandler(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey)
 	if user == nil {
-		corestub.RespondWithTraceError(w, "unauthorized", http.StatusUnauthorized)
+		problem.Respond(w, r, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
@@ -12,17 +12,6 @@ type GenericMessageResponse struct {
 	Message string `json:"message" example:"Operation completed successfully"`
 }
 
-// GenericErrorResponse represents a detailed structured error payload
-// @Description Trace error structure with audit details
-type GenericErrorResponse struct {
-	Message   string `json:"message" example:"Invalid request"`
-	Code      string `json:"code" example:"BadRequest"`
-	Event     string `json:"event" example:"email_invalid"`
-	Actor     string `json:"actor" example:"USER"`
-	Timestamp string `json:"timestamp" example:"2025-05-16T10:00:00Z"`
-	Debug     string `json:"debug,omitempty" example:"stack trace for devs (optional)"`
-}
-
 // TokenResponse represents a token exchange response
 // @Description Returned on successful login or refresh
 type TokenResponse struct {
@@ -3,7 +3,7 @@ package handlers
 import (
 	"net/http"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 )
 
 // DeepLinkRedirectHandler godoc
@@ -14,14 +14,14 @@ import (
 // @Param type query string true "Link type (verify/reset)"
 // @Param token query string true "Verification or reset token"
 // @Success 302 {string} string "Redirect to app URI"
-// @Failure 400 {object} map[string]string "Missing or invalid query parameters"
+// @Failure 400 {object} problem.Problem "Missing or invalid query parameters"
 // @Router /api/v1/deeplink/legacy [get]
 func DeepLinkRedirectHandler(w http.ResponseWriter, r *http.Request) {
 	linkType := r.URL.Query().Get("type")
 	token := r.URL.Query().Get("token")
 
 	if linkType == "" || token == "" {
-		corestub.RespondWithTraceError(w, "missing_deeplink_params", http.StatusBadRequest)
+		problem.Respond(w, r, "missing_deeplink_params", http.StatusBadRequest)
 		return
 	}
 
@@ -32,7 +32,7 @@ func DeepLinkRedirectHandler(w http.ResponseWriter, r *http.Request) {
 	case "reset":
 		frontendURL = "peitho://setup-password?token=" + token + "&reset=true"
 	default:
-		corestub.RespondWithTraceError(w, "invalid_deeplink_type", http.StatusBadRequest)
+		problem.Respond(w, r, "invalid_deeplink_type", http.StatusBadRequest)
 		return
 	}
 
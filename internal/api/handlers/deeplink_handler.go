@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // UniversalDeeplinkHandler godoc
@@ -17,7 +19,7 @@ import (
 // @Param type query string true "Link type (verify or reset)"
 // @Param token query string true "Verification or reset token"
 // @Success 302 {string} string "Redirect to app or browser path"
-// @Failure 400 {object} map[string]string "Missing or invalid query parameters"
+// @Failure 400 {object} problem.Problem "Missing or invalid query parameters"
 // @Router /api/v1/deeplink [get]
 func UniversalDeeplinkHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -25,20 +27,23 @@ func UniversalDeeplinkHandler(w http.ResponseWriter, r *http.Request) {
 	token := query.Get("token")
 
 	if linkType == "" || token == "" {
-		corestub.RespondWithTraceError(w, "missing_deeplink_params", http.StatusBadRequest)
+		problem.Respond(w, r, "missing_deeplink_params", http.StatusBadRequest)
 		return
 	}
 
+	// Tokens are hex, but anything else must not break out of the page's URLs and script
+	token = url.QueryEscape(token)
+
 	var appPath, webPath string
 	switch linkType {
 	case "verify":
 		appPath = fmt.Sprintf("peitho://verify?token=%s", token)
-		webPath = fmt.Sprintf("%s/verify?token=%s", emailConfig.FrontendURL, token)
+		webPath = fmt.Sprintf("%s/verify?token=%s", emailConfig.Load().FrontendURL, token)
 	case "reset":
 		appPath = fmt.Sprintf("peitho://reset?token=%s", token)
-		webPath = fmt.Sprintf("%s/reset-password?token=%s&reset=true", emailConfig.FrontendURL, token)
+		webPath = fmt.Sprintf("%s/reset-password?token=%s&reset=true", emailConfig.Load().FrontendURL, token)
 	default:
-		corestub.RespondWithTraceError(w, "invalid_deeplink_type", http.StatusBadRequest)
+		problem.Respond(w, r, "invalid_deeplink_type", http.StatusBadRequest)
 		return
 	}
 
@@ -52,11 +57,12 @@ func UniversalDeeplinkHandler(w http.ResponseWriter, r *http.Request) {
 
 	switch {
 	case isMobile:
-		log.Printf("📡 [DEEPLINK] Mobile UA: %s → %s", userAgent, appPath)
+		slog.InfoContext(r.Context(), "📡 Deeplink opening app", "type", linkType, "client", "mobile", "user_agent", userAgent)
 		http.Redirect(w, r, appPath, http.StatusFound)
 	case isDesktop:
-		log.Printf("📡 [DEEPLINK] Desktop UA: %s → Launching %s", userAgent, appPath)
-		w.Header().Set("Content-Type", "text/html")
+		slog.InfoContext(r.Context(), "📡 Deeplink opening app", "type", linkType, "client", "desktop", "user_agent", userAgent)
+		nonce := middleware.HTMLPageCSP(w)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		html := fmt.Sprintf(`
 <!DOCTYPE html>
 <html>
@@ -67,17 +73,17 @@ func UniversalDeeplinkHandler(w http.ResponseWriter, r *http.Request) {
 <body style="font-family:sans-serif;text-align:center;padding:2rem;">
   <p>Trying to open the PeithoSecure app...</p>
   <p><a href="%s">Click here if not redirected</a></p>
-  <script>
+  <script nonce="%s">
     setTimeout(function() {
       window.location = "%s";
     }, 500);
   </script>
 </body>
 </html>
-`, appPath, appPath, appPath)
+`, appPath, appPath, nonce, appPath)
 		fmt.Fprint(w, html)
 	default:
-		log.Printf("📡 [DEEPLINK] Unknown UA: %s → %s", userAgent, webPath)
+		slog.InfoContext(r.Context(), "📡 Deeplink opening web", "type", linkType, "client", "unknown", "user_agent", userAgent)
 		http.Redirect(w, r, webPath, http.StatusFound)
 	}
 }
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
-	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // DeleteAccountHandler godoc
@@ -18,22 +20,30 @@ import (
 // @Produce json
 // @Param username query string true "Username to delete"
 // @Success 200 {object} DeleteResponse "Account deleted successfully"
-// @Failure 400 {object} map[string]string "Username missing or invalid"
-// @Failure 500 {object} map[string]string "Failed to delete from Keycloak or log audit"
+// @Failure 400 {object} problem.Problem "Username missing or invalid"
+// @Failure 500 {object} problem.Problem "Failed to delete from Keycloak or log audit"
 // @Router /api/v1/auth/delete [delete]
 func DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 	username := r.URL.Query().Get("username")
 	if username == "" {
-		corestub.RespondWithTraceError(w, "username_required", http.StatusBadRequest)
+		audit.Record(r, audit.Failure("", "account_deleted", "missing_username"))
+		problem.Respond(w, r, "username_required", http.StatusBadRequest)
 		return
 	}
 
-	if err := keycloak.DeleteUser(GlobalConfig, username); err != nil {
-		corestub.RespondWithTraceError(w, "account_delete_failed", http.StatusInternalServerError)
+	if err := keycloak.DeleteUser(r.Context(), GlobalConfig, username); err != nil {
+		audit.Record(r, audit.Failure(username, "account_deleted", "keycloak_delete_failed"))
+		problem.Respond(w, r, "account_delete_failed", http.StatusInternalServerError)
 		return
 	}
 
-	_ = sqlite.LogAuditEvent(username, "account_deleted", r.RemoteAddr, r.UserAgent())
+	audit.Record(r, audit.Success(username, "", "account_deleted"))
+	events.Publish(events.Event{
+		Type:      events.AccountDeleted,
+		Username:  username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(DeleteResponse{
@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/smtp"
-	"os"
 	"strings"
+	"sync/atomic"
 
+	"github.com/peithosecure/peitho-backend/internal/config"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
 )
 
 type EmailServiceConfig struct {
@@ -22,35 +26,47 @@ type EmailServiceConfig struct {
 	AppLinkScheme string
 }
 
-var emailConfig EmailServiceConfig
-
-func InitEmailService() {
-	emailConfig = EmailServiceConfig{
-		SMTPHost:      os.Getenv("SMTP_HOST"),
-		SMTPPort:      os.Getenv("SMTP_PORT"),
-		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
-		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
-		FromEmail:     os.Getenv("FROM_EMAIL"),
-		FrontendURL:   strings.TrimSuffix(os.Getenv("FRONTEND_URL"), "/"),
-		AppLinkScheme: strings.TrimSuffix(os.Getenv("APP_LINK_SCHEME"), "/"),
-	}
+// emailConfig is replaced whole on config reload, so a send never mixes old and new settings
+var emailConfig atomic.Pointer[EmailServiceConfig]
+
+func init() {
+	emailConfig.Store(&EmailServiceConfig{})
+}
 
-	if emailConfig.SMTPHost == "" || emailConfig.SMTPPort == "" {
-		log.Println("⚠️ Email service is misconfigured: missing SMTP_HOST or SMTP_PORT")
+// InitEmailService (re)configures the mailer from cfg; it is also the reload hook for the
+// SMTP_*, FROM_EMAIL, FRONTEND_URL and APP_LINK_SCHEME settings
+func InitEmailService(cfg *config.Config) {
+	mc := &EmailServiceConfig{
+		SMTPHost:      cfg.SMTPHost,
+		SMTPPort:      cfg.SMTPPort,
+		SMTPUsername:  cfg.SMTPUsername,
+		SMTPPassword:  cfg.SMTPPassword,
+		FromEmail:     cfg.FromEmail,
+		FrontendURL:   strings.TrimSuffix(cfg.FrontendURL, "/"),
+		AppLinkScheme: strings.TrimSuffix(cfg.AppLinkScheme, "/"),
 	}
-	if emailConfig.FromEmail == "" {
-		emailConfig.FromEmail = "no-reply@peithosecure.io"
+
+	if mc.SMTPHost == "" || mc.SMTPPort == "" {
+		slog.Warn("⚠️ Email service is misconfigured: missing SMTP_HOST or SMTP_PORT", "smtp_host", mc.SMTPHost, "smtp_port", mc.SMTPPort)
 	}
-	if emailConfig.FrontendURL == "" {
-		emailConfig.FrontendURL = "http://localhost:3000"
+	emailConfig.Store(mc)
+}
+
+// SMTPAddr returns the configured mail server as host:port, or "" when unset. The readiness
+// probe uses it so its SMTP check follows config reloads.
+func SMTPAddr() string {
+	mc := emailConfig.Load()
+	if mc.SMTPHost == "" || mc.SMTPPort == "" {
+		return ""
 	}
+	return net.JoinHostPort(mc.SMTPHost, mc.SMTPPort)
 }
 
-func SendVerificationEmail(username, email string) error {
+func SendVerificationEmail(ctx context.Context, username, email string) error {
 	token := generateToken(16)
 	link := generateDeepLink("verify", token)
 
-	fmt.Printf("📩 [EMAIL TOKEN] Generated for %s → %s\n", email, token)
+	slog.DebugContext(ctx, "📩 Verification token generated", "email", email, "token", token)
 
 	subject := "Verify your PeithoSecure Email"
 	body := fmt.Sprintf(`
@@ -69,14 +85,14 @@ func SendVerificationEmail(username, email string) error {
   </body>
 </html>`, username, link, link, link)
 
-	if err := sqlite.InsertEmailToken(email, token, "verify"); err != nil {
-		log.Printf("❌ Failed to insert email token: %v", err)
+	if err := sqlite.InsertEmailToken(ctx, email, token, "verify"); err != nil {
+		slog.ErrorContext(ctx, "❌ Failed to insert email token", "email", email, "error", err)
 		return fmt.Errorf("token_insert_fail: %w", err)
 	}
-	return sendEmail(email, subject, body)
+	return sendEmail(ctx, "verify", email, subject, body)
 }
 
-func SendPasswordResetEmail(email, token string) error {
+func SendPasswordResetEmail(ctx context.Context, email, token string) error {
 	link := generateDeepLink("reset", token)
 
 	subject := "Reset Your PeithoSecure Password"
@@ -95,24 +111,27 @@ func SendPasswordResetEmail(email, token string) error {
   </body>
 </html>`, link, link, link)
 
-	return sendEmail(email, subject, body)
+	return sendEmail(ctx, "reset", email, subject, body)
 }
 
 func generateDeepLink(linkType, token string) string {
-	if emailConfig.AppLinkScheme != "" {
-		if strings.HasPrefix(emailConfig.AppLinkScheme, "http") {
-			return fmt.Sprintf("%s?type=%s&token=%s", emailConfig.AppLinkScheme, linkType, token)
+	mc := emailConfig.Load()
+	if mc.AppLinkScheme != "" {
+		if strings.HasPrefix(mc.AppLinkScheme, "http") {
+			return fmt.Sprintf("%s?type=%s&token=%s", mc.AppLinkScheme, linkType, token)
 		}
-		return fmt.Sprintf("%s/%s?token=%s", emailConfig.AppLinkScheme, linkType, token)
+		return fmt.Sprintf("%s/%s?token=%s", mc.AppLinkScheme, linkType, token)
 	}
-	return fmt.Sprintf("%s/%s?token=%s", emailConfig.FrontendURL, linkType, token)
+	return fmt.Sprintf("%s/%s?token=%s", mc.FrontendURL, linkType, token)
 }
 
-func sendEmail(to, subject, htmlBody string) error {
-	auth := smtp.PlainAuth("", emailConfig.SMTPUsername, emailConfig.SMTPPassword, emailConfig.SMTPHost)
+// sendEmail delivers an HTML message; kind labels it in peitho_emails_sent_total
+func sendEmail(ctx context.Context, kind, to, subject, htmlBody string) error {
+	mc := emailConfig.Load()
+	auth := smtp.PlainAuth("", mc.SMTPUsername, mc.SMTPPassword, mc.SMTPHost)
 
 	headers := map[string]string{
-		"From":         emailConfig.FromEmail,
+		"From":         mc.FromEmail,
 		"To":           to,
 		"Subject":      subject,
 		"MIME-Version": "1.0",
@@ -126,17 +145,19 @@ func sendEmail(to, subject, htmlBody string) error {
 	msg.WriteString("\r\n" + htmlBody)
 
 	err := smtp.SendMail(
-		emailConfig.SMTPHost+":"+emailConfig.SMTPPort,
+		mc.SMTPHost+":"+mc.SMTPPort,
 		auth,
-		emailConfig.FromEmail,
+		mc.FromEmail,
 		[]string{to},
 		[]byte(msg.String()),
 	)
 
 	if err != nil {
-		log.Printf("❌ Failed to send email to %s: %v", to, err)
+		metrics.EmailsSent.WithLabelValues(kind, "failed").Inc()
+		slog.ErrorContext(ctx, "❌ Failed to send email", "kind", kind, "to", to, "error", err)
 		return fmt.Errorf("email_send_fail: %w", err)
 	}
+	metrics.EmailsSent.WithLabelValues(kind, "sent").Inc()
 	return nil
 }
 
@@ -4,48 +4,81 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
-	"time"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
+	"github.com/peithosecure/peitho-backend/internal/trace"
 )
 
 // EngineEventPayload defines the structure for custom trace logs
 type EngineEventPayload struct {
-	Event   string `json:"event" example:"custom_event"`           // Custom event identifier
-	Actor   string `json:"actor" example:"USER"`                   // One of: DEV, USER, HACKER
-	Message string `json:"message" example:"Manual log injection"` // Human-readable description
-	Lock    bool   `json:"lock" example:"false"`                   // Whether to trigger lockdown
+	Event    string `json:"event" validate:"required,max=128" example:"custom_event"`   // Custom event identifier
+	Actor    string `json:"actor" validate:"required" example:"USER"`                   // One of: DEV, USER, HACKER
+	Message  string `json:"message" validate:"max=4096" example:"Manual log injection"` // Human-readable description
+	Severity string `json:"severity" example:"low"`                                     // One of: low, medium, high, critical (default low)
+	Lock     bool   `json:"lock" example:"false"`                                       // Whether to trigger lockdown
+	Scope    string `json:"scope,omitempty" example:"ip"`                               // Lockdown scope: server (default), user or ip
+	Target   string `json:"target,omitempty" example:"203.0.113.7"`                     // Username or IP for user/ip scope
 }
 
 // EngineEventHandler godoc
 // @Summary Submit system trace event
-// @Description Log a custom trace event (devtools, external agent, honeypot, etc.)
+// @Description Log a custom trace event (devtools, external agent, honeypot, etc.) into the persistent trace store.
+// @Description Admin-only, since flagged events can engage lockdowns.
 // @Tags System
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security BasicAuth
 // @Param payload body EngineEventPayload true "Custom trace event to log"
 // @Success 200 {object} GenericMessageResponse "Event logged successfully"
-// @Failure 400 {object} map[string]string "Invalid request body"
-// @Failure 422 {object} map[string]string "Unknown actor type"
+// @Failure 400 {object} problem.Problem "Invalid request body"
+// @Failure 401 {object} problem.Problem "Missing or invalid admin credentials"
+// @Failure 403 {object} problem.Problem "Caller is not an admin"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Missing fields, unknown actor type, severity or lockdown scope"
+// @Failure 500 {object} problem.Problem "Trace persistence failed"
 // @Router /api/v1/events/log [post]
 func EngineEventHandler(w http.ResponseWriter, r *http.Request) {
 	var payload EngineEventPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		corestub.RespondWithTraceError(w, "event_payload_parse_fail", http.StatusBadRequest)
+	if !binding.DecodeJSON(w, r, &payload, "event_payload_parse_fail") {
 		return
 	}
 
 	actor := strings.ToUpper(payload.Actor)
 	if actor != "DEV" && actor != "USER" && actor != "HACKER" {
-		corestub.RespondWithTraceError(w, "invalid_actor", http.StatusUnprocessableEntity)
+		problem.Respond(w, r, "invalid_actor", http.StatusUnprocessableEntity)
 		return
 	}
 
-	logEntry := "[TRACE] " + time.Now().Format(time.RFC3339) + " | [" + actor + "] " + payload.Event + " → " + payload.Message
-	if payload.Lock {
-		logEntry += " 🔒 LOCK TRIGGERED"
+	severity, err := trace.NormalizeSeverity(payload.Severity)
+	if err != nil {
+		problem.RespondWithDetail(w, r, "invalid_severity", http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	scope, target := payload.Scope, payload.Target
+	if scope != "" {
+		if scope, target, err = lockdown.NormalizeScope(scope, target); err != nil {
+			problem.RespondWithDetail(w, r, "invalid_lockdown_scope", http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+
+	if _, err := trace.Record(r.Context(), trace.Event{
+		Actor:    actor,
+		Event:    payload.Event,
+		Severity: severity,
+		Lock:     payload.Lock,
+		Scope:    scope,
+		Target:   target,
+		Message:  payload.Message,
+	}); err != nil {
+		problem.Respond(w, r, "trace_persist_failed", http.StatusInternalServerError)
+		return
 	}
-	corestub.TrackEvent(logEntry)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(GenericMessageResponse{
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+)
+
+// ErrorCatalogHandler godoc
+// @Summary List API error codes
+// @Description Returns every machine-readable error code the API can emit, with its usual HTTP status and title.
+// @Description All error responses are application/problem+json (RFC 7807) bodies carrying one of these codes.
+// @Tags meta
+// @Produce json
+// @Success 200 {array} problem.Entry
+// @Router /api/v1/errors [get]
+func ErrorCatalogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(problem.Catalog())
+}
+
+// RouteNotFoundHandler answers unmatched paths with a problem body
+func RouteNotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	problem.Respond(w, r, "route_not_found", http.StatusNotFound)
+}
+
+// MethodNotAllowedHandler answers known paths called with the wrong method
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	problem.Respond(w, r, "method_not_allowed", http.StatusMethodNotAllowed)
+}
@@ -3,18 +3,23 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/buildinfo"
+	"github.com/peithosecure/peitho-backend/internal/health"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
 )
 
 // HealthzResponse defines the structure for infra probe responses
 type HealthzResponse struct {
 	Status  string `json:"status" example:"ok"`
 	Service string `json:"service" example:"PeithoSecure Lite"`
-	Version string `json:"version" example:"1.0.0"`
+	Version string `json:"version" example:"1.2.0"`
 }
 
 // HealthzHandler godoc
 // @Summary Infra health probe
-// @Description Lightweight endpoint for readiness/liveness probes (Kubernetes, CI/CD, etc.)
+// @Description Lightweight endpoint kept for existing probes; prefer /livez and /readyz
 // @Tags Health
 // @Produce json
 // @Success 200 {object} HealthzResponse
@@ -23,9 +28,76 @@ func HealthzHandler(w http.ResponseWriter, r *http.Request) {
 	response := HealthzResponse{
 		Status:  "ok",
 		Service: "PeithoSecure Lite",
-		Version: "1.0.0",
+		Version: buildinfo.Get().Version,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(response)
 }
+
+// LivezResponse reports that the process is running and which build it is
+type LivezResponse struct {
+	Status string         `json:"status" example:"ok"`
+	Build  buildinfo.Info `json:"build"`
+}
+
+// LivezHandler godoc
+// @Summary Liveness probe
+// @Description Returns 200 whenever the process can serve HTTP. It checks no dependencies, so an outage elsewhere never gets the server restarted.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} LivezResponse
+// @Router /livez [get]
+func LivezHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LivezResponse{Status: "ok", Build: buildinfo.Get()})
+}
+
+// ReadyzHandler godoc
+// @Summary Readiness probe
+// @Description Runs the registered dependency checks (SQLite, Keycloak, JWKS, SMTP, license) with per-check timeouts and cached results. Returns 200 when all critical checks pass, even if others are degraded, and 503 when a critical check fails or during startup and shutdown. Only the aggregate status is returned here; per-check detail is on the internal listener and /api/v1/admin/readyz.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report "A critical check failed"
+// @Failure 503 {object} problem.Problem "Starting up or shutting down"
+// @Router /readyz [get]
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	serveReadyz(w, r, false)
+}
+
+// ReadyzDetailHandler godoc
+// @Summary Readiness probe with per-check detail
+// @Description Same checks as /readyz. Add ?verbose for per-check results, whose errors name internal hosts and paths; this handler is only mounted on the internal listener and behind the admin guard.
+// @Tags Admin
+// @Produce json
+// @Param verbose query bool false "Include per-check results"
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report "A critical check failed"
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 503 {object} problem.Problem "Starting up or shutting down"
+// @Security BearerAuth
+// @Router /api/v1/admin/readyz [get]
+func ReadyzDetailHandler(w http.ResponseWriter, r *http.Request) {
+	serveReadyz(w, r, r.URL.Query().Has("verbose"))
+}
+
+func serveReadyz(w http.ResponseWriter, r *http.Request, detail bool) {
+	if !lifecycle.Ready() {
+		problem.Respond(w, r, "not_ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	report := health.Run(r.Context())
+	if !detail {
+		report.Checks = nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == health.StatusFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
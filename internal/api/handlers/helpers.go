@@ -1,9 +1,37 @@
 package handlers
 
-import "regexp"
+import (
+	"os"
+	"regexp"
+
+	"github.com/golang-jwt/jwt/v4"
+)
 
 // isValidEmail validates a basic email format.
 func isValidEmail(email string) bool {
 	reg := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
 	return reg.MatchString(email)
 }
+
+// tokenIdentity reads preferred_username and sub from a token Keycloak has just issued to us.
+// The signature is not checked: the token came straight from Keycloak over our own
+// connection, and the result is only used to attribute audit events.
+func tokenIdentity(token string) (username, subject string) {
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return "", ""
+	}
+	username, _ = claims["preferred_username"].(string)
+	subject, _ = claims["sub"].(string)
+	return username, subject
+}
+
+// writeUnlockFile installs a license block at the configured UNLOCK_PATH, keeping the
+// previous file as .bak, and returns the path written
+func writeUnlockFile(block string) (string, error) {
+	unlockPath := GlobalConfig.UnlockPath
+	if _, err := os.Stat(unlockPath); err == nil {
+		_ = os.Rename(unlockPath, unlockPath+".bak")
+	}
+	return unlockPath, os.WriteFile(unlockPath, []byte(block), 0600)
+}
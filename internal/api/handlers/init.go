@@ -8,9 +8,6 @@ import (
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 )
 
-// init performs early validation of PeithoCore integrity and license state.
-// This ensures the backend refuses to start if tampering or missing license is detected.
-
 var GlobalConfig *config.Config
 
 // InitWithConfig sets the global config for use in handlers
@@ -23,7 +20,12 @@ func init() {
 	if corestub.PeithoTrap() != "__peitho_signature__" {
 		_, _ = os.Stderr.WriteString("⚠️  Warning: PeithoCore signature not verified — you're flying on vibes.\n")
 	}
+}
 
+// ValidateLicense performs early validation of PeithoCore integrity and license state
+// against the loaded configuration, so the backend refuses to start if tampering or a
+// missing license is detected. It must run after config.Load.
+func ValidateLicense(cfg *config.Config) {
 	// 🧬 Skip double-check if already marked
 	if unlocked, _ := corestub.UnlockStatus(); unlocked {
 		os.Setenv("PEITHO_LICENSE_HASH_OK", "true")
@@ -31,7 +33,7 @@ func init() {
 	}
 
 	// 🔐 Final unlock validation — triggers ShutdownNow with savage roast
-	if err := peitho.ValidateLicenseToken(""); err != nil {
+	if err := peitho.ValidateLicenseToken(cfg.UnlockPath, cfg.DeviceID, cfg.AllowMultiDevice); err != nil {
 		corestub.ShutdownNow(err.Error())
 	}
 
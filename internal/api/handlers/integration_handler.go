@@ -3,9 +3,9 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
 	"github.com/peithosecure/peitho-backend/internal/config"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 	"github.com/peithosecure/peitho-backend/internal/utils"
 )
 
@@ -31,12 +31,12 @@ type IntegrationClient struct {
 // @Tags Integrations
 // @Produce json
 // @Success 200 {array} IntegrationClient
-// @Failure 401 {object} map[string]string "Unauthorized or token expired"
+// @Failure 401 {object} problem.Problem "Unauthorized or token expired"
 // @Router /api/v1/integrations [get]
 func GetAppIntegrations(w http.ResponseWriter, r *http.Request) {
-	clients, err := keycloak.GetRealmClients(integrationCfg)
+	clients, err := keycloak.GetRealmClients(r.Context(), integrationCfg)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "integration_fetch_fail", http.StatusUnauthorized)
+		problem.Respond(w, r, "integration_fetch_fail", http.StatusUnauthorized)
 		return
 	}
 
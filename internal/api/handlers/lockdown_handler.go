@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
+)
+
+// LockdownRequest defines the payload for manually engaging a lockdown
+type LockdownRequest struct {
+	Scope  string `json:"scope" validate:"required" example:"user"`     // One of: server, user, ip
+	Target string `json:"target" example:"johndoe"`                     // Username or IP; ignored for server scope
+	Reason string `json:"reason" validate:"max=512" example:"incident"` // Free-text reason recorded with the lockdown
+}
+
+// ListLockdownsHandler godoc
+// @Summary List lockdowns
+// @Description Returns active lockdowns, or the full lockdown history when all=true
+// @Tags Admin
+// @Produce json
+// @Param all query bool false "Include lifted and expired lockdowns"
+// @Success 200 {array} models.Lockdown
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 500 {object} problem.Problem "Lockdown query failed"
+// @Security BearerAuth
+// @Router /api/v1/admin/lockdowns [get]
+func ListLockdownsHandler(w http.ResponseWriter, r *http.Request) {
+	var lockdowns []models.Lockdown
+	if r.URL.Query().Get("all") == "true" {
+		var err error
+		lockdowns, err = sqlite.ListLockdowns(r.Context(), true, 500)
+		if err != nil {
+			problem.Respond(w, r, "lockdown_query_failed", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		lockdowns = lockdown.Active(r.Context())
+	}
+
+	if lockdowns == nil {
+		lockdowns = []models.Lockdown{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lockdowns)
+}
+
+// EngageLockdownHandler godoc
+// @Summary Engage a lockdown
+// @Description Puts the server, a user or an IP into lockdown. Engaging an already active lockdown returns it unchanged.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body LockdownRequest true "Lockdown scope, target and reason"
+// @Success 201 {object} models.Lockdown
+// @Failure 400 {object} problem.Problem "Malformed request, invalid scope or missing target"
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 500 {object} problem.Problem "Lockdown could not be persisted"
+// @Security BearerAuth
+// @Router /api/v1/admin/lockdowns [post]
+func EngageLockdownHandler(w http.ResponseWriter, r *http.Request) {
+	var req LockdownRequest
+	if !binding.DecodeJSON(w, r, &req, "lockdown_parse_fail") {
+		return
+	}
+
+	l, err := lockdown.Engage(r.Context(), req.Scope, req.Target, req.Reason, lockdownActor(r))
+	if err != nil {
+		if errors.Is(err, lockdown.ErrInvalidScope) || errors.Is(err, lockdown.ErrMissingTarget) {
+			problem.RespondWithDetail(w, r, "lockdown_invalid", http.StatusBadRequest, err.Error())
+			return
+		}
+		problem.Respond(w, r, "lockdown_engage_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(l)
+}
+
+// LiftLockdownHandler godoc
+// @Summary Lift a lockdown
+// @Description Ends an active lockdown by id
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Lockdown ID"
+// @Success 200 {object} GenericMessageResponse
+// @Failure 400 {object} problem.Problem "Invalid lockdown id"
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 404 {object} problem.Problem "Lockdown not found or already lifted"
+// @Failure 500 {object} problem.Problem "Lockdown could not be lifted"
+// @Security BearerAuth
+// @Router /api/v1/admin/lockdowns/{id} [delete]
+func LiftLockdownHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Respond(w, r, "lockdown_id_invalid", http.StatusBadRequest)
+		return
+	}
+
+	if err := lockdown.Lift(r.Context(), id, lockdownActor(r)); err != nil {
+		if errors.Is(err, lockdown.ErrNotActive) {
+			problem.Respond(w, r, "lockdown_not_active", http.StatusNotFound)
+			return
+		}
+		problem.Respond(w, r, "lockdown_lift_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(GenericMessageResponse{
+		Message: "Lockdown lifted",
+	})
+}
+
+func lockdownActor(r *http.Request) lockdown.Actor {
+	username, _ := middleware.ExtractUsernameFromContext(r.Context())
+	return lockdown.Actor{
+		Name:      username,
+		Subject:   middleware.ExtractSubjectFromContext(r.Context()),
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+	}
+}
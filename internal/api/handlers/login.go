@@ -3,58 +3,92 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 	"github.com/peithosecure/peitho-backend/internal/db/models"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
 	"github.com/peithosecure/peitho-backend/internal/metrics"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
 )
 
 // LoginHandler godoc
 // @Summary Authenticate user credentials
-// @Description Logs in the user and returns a Keycloak-issued JWT token pair along with email verification status
+// @Description Logs in the user and returns a Keycloak-issued JWT token pair along with email verification status. password_expired is set when the password exceeded its maximum age or an admin forced a reset; clients should send the user through the password reset flow.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param loginRequest body models.LoginRequest true "Username and password payload"
 // @Success 200 {object} models.LoginResponse "Authentication successful"
-// @Failure 400 {object} map[string]string "Malformed request or JSON parsing failed"
-// @Failure 401 {object} map[string]string "Invalid credentials"
-// @Failure 429 {object} map[string]string "Too many failed login attempts"
+// @Failure 400 {object} problem.Problem "Malformed request or JSON parsing failed"
+// @Failure 401 {object} problem.Problem "Invalid credentials"
+// @Failure 403 {object} problem.Problem "User is under lockdown"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 429 {object} problem.Problem "Too many failed login attempts"
 // @Router /api/v1/auth/login [post]
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var loginReq models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		corestub.RespondWithTraceError(w, "login_parse_fail", http.StatusBadRequest)
+	if !binding.DecodeJSON(w, r, &loginReq, "login_parse_fail") {
+		audit.Record(r, audit.Failure("", "login", "invalid_request"))
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure, "invalid_request").Inc()
 		return
 	}
 
 	if middleware.IsUserLocked(loginReq.Username) {
+		audit.Record(r, audit.Failure(loginReq.Username, "login", "rate_limited"))
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure, "rate_limited").Inc()
+		metrics.RateLimitRejections.WithLabelValues("login").Inc()
 		retryAfter := middleware.GetRetryAfterSeconds(loginReq.Username)
 		w.Header().Set("Retry-After", retryAfter)
-		corestub.RespondWithTraceError(w, "user_locked", http.StatusTooManyRequests)
+		problem.Respond(w, r, "user_locked", http.StatusTooManyRequests)
 		return
 	}
 
-	tokenResp, err := keycloak.LoginWithPassword(GlobalConfig, loginReq.Username, loginReq.Password)
+	if _, locked := lockdown.Check(r.Context(), lockdown.ScopeUser, loginReq.Username); locked {
+		audit.Record(r, audit.Failure(loginReq.Username, "login", "user_lockdown"))
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure, "user_lockdown").Inc()
+		problem.Respond(w, r, "user_lockdown", http.StatusForbidden)
+		return
+	}
+
+	tokenResp, err := keycloak.LoginWithPassword(r.Context(), GlobalConfig, loginReq.Username, loginReq.Password)
 	if err != nil {
-		middleware.IncrementLoginFailure(loginReq.Username)
-		corestub.RespondWithTraceError(w, "auth_failed", http.StatusUnauthorized)
+		events.Publish(events.Event{
+			Type:      events.LoginFailed,
+			Username:  loginReq.Username,
+			IP:        middleware.ClientIP(r),
+			UserAgent: r.UserAgent(),
+			Reason:    "invalid_credentials",
+		})
+		audit.Record(r, audit.Failure(loginReq.Username, "login", "invalid_credentials"))
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure, "invalid_credentials").Inc()
+		middleware.IncrementLoginFailure(r, loginReq.Username)
+		problem.Respond(w, r, "auth_failed", http.StatusUnauthorized)
 		return
 	}
 
-	user, err := sqlite.GetUserByUsername(loginReq.Username)
+	user, err := sqlite.GetUserByUsername(r.Context(), loginReq.Username)
 	if err != nil || user == nil {
-		corestub.RespondWithTraceError(w, "user_lookup_failed", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(loginReq.Username, "login", "user_lookup_failed"))
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure, "user_lookup_failed").Inc()
+		problem.Respond(w, r, "user_lookup_failed", http.StatusInternalServerError)
 		return
 	}
 
 	middleware.ClearLoginAttempts(loginReq.Username)
 
 	metrics.IncIssued()
-	_ = sqlite.LogAuditEvent(user.Username, "login", r.RemoteAddr, r.UserAgent())
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSuccess, "").Inc()
+	_, subject := tokenIdentity(tokenResp.AccessToken)
+	audit.Record(r, audit.Success(user.Username, subject, "login"))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(models.LoginResponse{
@@ -63,5 +97,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		TokenType:     tokenResp.TokenType,
 		ExpiresIn:     tokenResp.ExpiresIn,
 		EmailVerified: user.EmailVerified != 0,
+		PasswordExpired: user.ForcePasswordReset != 0 ||
+			passwordpolicy.Current().Expired(user.PasswordChangedAt, time.Now()),
 	})
 }
@@ -4,16 +4,16 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
-	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
 	"github.com/peithosecure/peitho-backend/internal/metrics"
-	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // LogoutRequest represents the incoming payload to logout
 type LogoutRequest struct {
-	RefreshToken string `json:"refresh_token" example:"eyJhbGc..."`
+	RefreshToken string `json:"refresh_token" validate:"required" example:"eyJhbGc..."`
 }
 
 // LogoutResponse represents the standard logout success response
@@ -29,29 +29,31 @@ type LogoutResponse struct {
 // @Produce json
 // @Param logoutRequest body LogoutRequest true "Refresh token payload"
 // @Success 200 {object} LogoutResponse
-// @Failure 400 {object} map[string]string "Malformed request or missing token"
-// @Failure 401 {object} map[string]string "Invalid or expired token"
+// @Failure 400 {object} problem.Problem "Malformed request or missing token"
+// @Failure 401 {object} problem.Problem "Invalid or expired token"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
 // @Router /api/v1/auth/logout [post]
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	var body LogoutRequest
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		corestub.RespondWithTraceError(w, "logout_parse_fail", http.StatusBadRequest)
+	if !binding.DecodeJSON(w, r, &body, "logout_parse_fail") {
+		audit.Record(r, audit.Failure("", "logout", "invalid_request"))
 		return
 	}
 
-	if err := keycloak.RevokeRefreshToken(GlobalConfig, body.RefreshToken); err != nil {
-		corestub.RespondWithTraceError(w, "logout_failed", http.StatusUnauthorized)
+	if err := keycloak.RevokeRefreshToken(r.Context(), GlobalConfig, body.RefreshToken); err != nil {
+		// The token was not accepted, so its claims are unverified and not recorded
+		audit.Record(r, audit.Failure("", "logout", "revoke_failed"))
+		problem.Respond(w, r, "logout_failed", http.StatusUnauthorized)
 		return
 	}
 
 	metrics.IncRevoked()
-
-	ctxUser := r.Context().Value(middleware.UserContextKey)
-	if claims, ok := ctxUser.(map[string]interface{}); ok {
-		if uname, ok := claims["preferred_username"].(string); ok {
-			_ = sqlite.LogAuditEvent(uname, "logout", r.RemoteAddr, r.UserAgent())
-		}
-	}
+	// Claims are read unverified; they are trusted only now that Keycloak has accepted
+	// the token for revocation
+	username, subject := tokenIdentity(body.RefreshToken)
+	audit.Record(r, audit.Success(username, subject, "logout"))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(LogoutResponse{
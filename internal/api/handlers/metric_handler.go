@@ -4,45 +4,25 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/peithosecure/peitho-backend/internal/config"
 	"github.com/peithosecure/peitho-backend/internal/metrics"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var cfg *config.Config
-
-// InitMetricsHandler initializes the config for metrics
-func InitMetricsHandler(configLoaded *config.Config) {
-	cfg = configLoaded
-}
-
 // MetricsHandler godoc
 // @Summary Prometheus metrics endpoint
-// @Description Returns raw Prometheus metrics for external monitoring
+// @Description Returns raw Prometheus metrics for external monitoring. Admin-only: a bearer token
+// @Description with the admin role or the admin basic-auth credentials, per PEITHO_ADMIN_AUTH.
 // @Tags metrics
 // @Produce plain
+// @Security BearerAuth
+// @Security BasicAuth
 // @Success 200 {string} string "Prometheus-formatted metrics"
+// @Failure 401 {object} problem.Problem "Missing or invalid credentials"
+// @Failure 403 {object} problem.Problem "Caller lacks the admin role or address is not allowed"
+// @Failure 404 {object} problem.Problem "Admin endpoints are disabled"
 // @Router /api/v1/metrics [get]
-func MetricsHandler(w http.ResponseWriter, r *http.Request) {
-	promhttp.Handler().ServeHTTP(w, r)
-}
-
-// AdminMetricsHandler godoc
-// @Summary Admin-only Prometheus metrics
-// @Description Returns full Prometheus metrics (Basic Auth protected)
-// @Tags metrics
-// @Produce plain
-// @Success 200 {string} string "Prometheus-formatted metrics"
-// @Failure 401 {string} string "Unauthorized"
 // @Router /api/v1/admin-metrics [get]
-func AdminMetricsHandler(w http.ResponseWriter, r *http.Request) {
-	username, password, ok := r.BasicAuth()
-	if !ok || username != cfg.AdminMetricsUsername || password != cfg.AdminMetricsPassword {
-		w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-	promhttp.Handler().ServeHTTP(w, r)
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	metrics.Handler().ServeHTTP(w, r)
 }
 
 // TokenMetricsHandler godoc
@@ -2,20 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 	"github.com/peithosecure/peitho-backend/internal/db/models"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // SetupPasswordRequest is used to bind token and password
 type SetupPasswordRequest struct {
-	Token    string `json:"token" example:"verify-token-abc123"`
-	Password string `json:"password" example:"StrongPassword123!"`
+	Token    string `json:"token" validate:"required" example:"verify-token-abc123"`
+	Password string `json:"password" validate:"required" example:"StrongPassword123!"`
 }
 
 // SetupPasswordHandler godoc
@@ -26,24 +30,21 @@ type SetupPasswordRequest struct {
 // @Produce json
 // @Param request body SetupPasswordRequest true "Token and new password"
 // @Success 200 {object} GenericMessageResponse
-// @Failure 400 {object} map[string]string "Missing or invalid input"
-// @Failure 404 {object} map[string]string "User not found"
-// @Failure 409 {object} map[string]string "Already verified or setup attempted twice"
-// @Failure 500 {object} map[string]string "Server, Keycloak, or license error"
+// @Failure 400 {object} problem.Problem "Malformed request, invalid token or unverified user"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 409 {object} problem.Problem "Already verified or setup attempted twice"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed or password violates the policy"
+// @Failure 500 {object} problem.Problem "Server, Keycloak, or license error"
 // @Router /api/v1/auth/setup-password [post]
 func SetupPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	var body map[string]string
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		corestub.RespondWithTraceError(w, "parse_error", http.StatusBadRequest)
-		return
-	}
-
-	token := body["token"]
-	newPass := body["password"]
-	if token == "" || newPass == "" {
-		corestub.RespondWithTraceError(w, "missing_token_or_password", http.StatusBadRequest)
+	var req SetupPasswordRequest
+	if !binding.DecodeJSON(w, r, &req, "parse_error") {
+		audit.Record(r, audit.Failure("", "password_set", "invalid_request"))
 		return
 	}
+	token, newPass := req.Token, req.Password
 
 	var user *models.User
 	var err error
@@ -51,82 +52,103 @@ func SetupPasswordHandler(w http.ResponseWriter, r *http.Request) {
 	if token == "pending" {
 		email := r.Header.Get("Email")
 		if email == "" {
-			corestub.RespondWithTraceError(w, "missing_email_header", http.StatusBadRequest)
+			audit.Record(r, audit.Failure("", "password_set", "missing_email_header"))
+			problem.Respond(w, r, "missing_email_header", http.StatusBadRequest)
 			return
 		}
-		user, err = sqlite.GetUserByEmail(email)
+		user, err = sqlite.GetUserByEmail(r.Context(), email)
 		if err != nil || user == nil || user.EmailVerified == 0 {
-			corestub.RespondWithTraceError(w, "user_not_verified", http.StatusBadRequest)
+			audit.Record(r, audit.Failure("", "password_set", "user_not_verified"))
+			problem.Respond(w, r, "user_not_verified", http.StatusBadRequest)
 			return
 		}
 	} else {
-		email, err := sqlite.GetUsernameByTokenAndType(token, "verify")
+		email, err := sqlite.GetUsernameByTokenAndType(r.Context(), token, "verify")
 		if err != nil || email == "" {
-			corestub.RespondWithTraceError(w, "invalid_token", http.StatusBadRequest)
+			audit.Record(r, audit.Failure("", "password_set", "invalid_token"))
+			problem.Respond(w, r, "invalid_token", http.StatusBadRequest)
 			return
 		}
-		user, err = sqlite.GetUserByEmail(email)
+		user, err = sqlite.GetUserByEmail(r.Context(), email)
 		if err != nil || user == nil {
-			corestub.RespondWithTraceError(w, "user_not_found", http.StatusNotFound)
+			audit.Record(r, audit.Failure("", "password_set", "user_not_found"))
+			problem.Respond(w, r, "user_not_found", http.StatusNotFound)
 			return
 		}
 	}
 
-	exists, err := keycloak.UserExists(GlobalConfig, user.Username)
+	res, err := checkNewPassword(r, user, newPass)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "keycloak_check_failed", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(user.Username, "password_set", "password_history_failed"))
+		problem.Respond(w, r, "password_history_failed", http.StatusInternalServerError)
+		return
+	}
+	if !res.OK {
+		audit.Record(r, audit.Failure(user.Username, "password_set", policyFailureReason(res)))
+		respondPolicyViolation(w, r, res)
+		return
+	}
+
+	exists, err := keycloak.UserExists(r.Context(), GlobalConfig, user.Username)
+	if err != nil {
+		audit.Record(r, audit.Failure(user.Username, "password_set", "keycloak_check_failed"))
+		problem.Respond(w, r, "keycloak_check_failed", http.StatusInternalServerError)
 		return
 	}
 
 	if exists {
-		err = keycloak.ResetPassword(GlobalConfig, user.Username, newPass)
+		err = keycloak.ResetPassword(r.Context(), GlobalConfig, user.Username, newPass)
 		if err != nil {
-			corestub.RespondWithTraceError(w, "password_reset_failed", http.StatusInternalServerError)
+			audit.Record(r, audit.Failure(user.Username, "password_set", "keycloak_reset_failed"))
+			problem.Respond(w, r, "password_reset_failed", http.StatusInternalServerError)
 			return
 		}
 	} else {
-		err = keycloak.RegisterNewUserWithEmail(GlobalConfig, user.Username, newPass, user.Email)
+		err = keycloak.RegisterNewUserWithEmail(r.Context(), GlobalConfig, user.Username, newPass, user.Email)
 		if err != nil {
-			corestub.RespondWithTraceError(w, "user_create_failed", http.StatusInternalServerError)
+			audit.Record(r, audit.Failure(user.Username, "password_set", "keycloak_create_failed"))
+			problem.Respond(w, r, "user_create_failed", http.StatusInternalServerError)
 			return
 		}
 	}
 
+	rememberPassword(r, user, newPass)
+
 	deviceID := r.Header.Get("Device-ID")
 	if deviceID == "" {
-		deviceID = "web-default"
+		deviceID = GlobalConfig.DeviceID
 	}
 
 	block, err := corestub.GenerateSignedLicense(user.Username, deviceID, true)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "license_gen_failed", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(user.Username, "password_set", "license_generation_failed"))
+		problem.Respond(w, r, "license_gen_failed", http.StatusInternalServerError)
 		return
 	}
 
-	unlockPath := os.Getenv("UNLOCK_PATH")
-	if unlockPath == "" {
-		unlockPath = "/app/peitho-core/unlock.lic"
-	}
-	if _, err := os.Stat(unlockPath); err == nil {
-		_ = os.Rename(unlockPath, unlockPath+".bak")
-	}
-
-	if err := os.WriteFile(unlockPath, []byte(block), 0600); err != nil {
-		corestub.RespondWithTraceError(w, "license_write_failed", http.StatusInternalServerError)
+	if _, err := writeUnlockFile(block); err != nil {
+		audit.Record(r, audit.Failure(user.Username, "password_set", "license_write_failed"))
+		problem.Respond(w, r, "license_write_failed", http.StatusInternalServerError)
 		return
 	}
 
 	if token != "pending" {
-		_ = sqlite.DeleteVerificationToken(token)
+		_ = sqlite.DeleteVerificationToken(r.Context(), token)
 	}
-	_ = sqlite.LogAuditEvent(user.Username, "password_set", r.RemoteAddr, r.UserAgent())
+	audit.Record(r, audit.Success(user.Username, "", "password_set"))
+	events.Publish(events.Event{
+		Type:      events.PasswordSet,
+		Username:  user.Username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
 
-	fmt.Printf("✅ Account finalized and password SET for: %s\n", user.Username)
+	slog.InfoContext(r.Context(), "✅ Account finalized and password set", "username", user.Username)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(GenericMessageResponse{
 		Message: "Password set and account activated.",
 	}); err != nil {
-		corestub.RespondWithTraceError(w, "response_encode_failed", http.StatusInternalServerError)
+		problem.Respond(w, r, "response_encode_failed", http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
+)
+
+// PasswordCheckRequest carries a candidate password and the identity it must not contain
+type PasswordCheckRequest struct {
+	Password string `json:"password" validate:"required" example:"correct-Horse-battery-9"`
+	Username string `json:"username,omitempty" example:"johndoe"`
+	Email    string `json:"email,omitempty" example:"john@example.com"`
+}
+
+// PasswordPolicyHandler godoc
+// @Summary Password policy
+// @Description Returns the rules new passwords must satisfy, so clients can describe them up front
+// @Tags auth
+// @Produce json
+// @Success 200 {object} passwordpolicy.Policy
+// @Router /api/v1/auth/password-policy [get]
+func PasswordPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(passwordpolicy.Current())
+}
+
+// PasswordCheckHandler godoc
+// @Summary Pre-check a password
+// @Description Evaluates a candidate password against the policy without storing it. The same rules are enforced on setup-password and reset-password.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordCheckRequest true "Candidate password and optional identity"
+// @Success 200 {object} passwordpolicy.Result "Evaluation result; ok is false when any rule failed"
+// @Failure 400 {object} problem.Problem "Malformed request body"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Router /api/v1/auth/password-policy/check [post]
+func PasswordCheckHandler(w http.ResponseWriter, r *http.Request) {
+	var req PasswordCheckRequest
+	if !binding.DecodeJSON(w, r, &req, "invalid_request") {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(passwordpolicy.Check(req.Password, req.Username, req.Email))
+}
+
+// policyFailureReason is the audit reason for a rejected password. Breach hits get their own
+// reason so they can be counted; the password itself is never recorded.
+func policyFailureReason(res passwordpolicy.Result) string {
+	switch {
+	case res.Breached():
+		return "password_breached"
+	case res.Failed(passwordpolicy.RuleReused):
+		return "password_reused"
+	default:
+		return "password_policy"
+	}
+}
+
+// checkNewPassword evaluates a new password for user against the policy and their history
+func checkNewPassword(r *http.Request, user *models.User, password string) (passwordpolicy.Result, error) {
+	policy := passwordpolicy.Current()
+	res := policy.Evaluate(password, user.Username, user.Email)
+	if policy.History == 0 {
+		return res, nil
+	}
+	hashes, err := sqlite.ListPasswordHistory(r.Context(), user.ID, policy.History)
+	if err != nil {
+		return res, err
+	}
+	return res, policy.CheckHistory(&res, password, hashes)
+}
+
+// rememberPassword records a completed change so the history and age rules apply to it.
+// Keycloak already holds the new password, so failures are logged rather than returned.
+func rememberPassword(r *http.Request, user *models.User, password string) {
+	policy := passwordpolicy.Current()
+	var hash string
+	if policy.History > 0 {
+		var err error
+		if hash, err = passwordpolicy.Hash(password); err != nil {
+			slog.ErrorContext(r.Context(), "❌ Password history hash failed", "username", user.Username, "error", err)
+			return
+		}
+	}
+	if err := sqlite.RecordPasswordChange(r.Context(), user.ID, hash, policy.History, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "❌ Password change not recorded", "username", user.Username, "error", err)
+	}
+}
+
+// respondPolicyViolation reports each failed password rule as a field error on "password"
+func respondPolicyViolation(w http.ResponseWriter, r *http.Request, res passwordpolicy.Result) {
+	fields := make([]problem.FieldError, 0, len(res.Violations))
+	for _, v := range res.Violations {
+		fields = append(fields, problem.FieldError{Field: "password", Code: v.Rule, Message: v.Message})
+	}
+	problem.RespondWithFields(w, r, "password_policy_violation", http.StatusUnprocessableEntity, fields)
+}
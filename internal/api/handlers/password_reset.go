@@ -2,15 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
 	"github.com/peithosecure/peitho-backend/internal/config"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 	"github.com/peithosecure/peitho-backend/internal/utils"
 )
 
@@ -22,13 +25,13 @@ func InjectConfig(cfg *config.Config) {
 
 // PasswordResetRequest defines the input for requesting a reset link
 type PasswordResetRequest struct {
-	Email string `json:"email" example:"user@example.com"`
+	Email string `json:"email" validate:"required,email,max=254" example:"user@example.com"`
 }
 
 // PasswordResetConfirm defines the input for setting a new password
 type PasswordResetConfirm struct {
-	Token    string `json:"token" example:"abc123token"`
-	Password string `json:"password" example:"SuperSecurePassword123!"`
+	Token    string `json:"token" validate:"required" example:"abc123token"`
+	Password string `json:"password" validate:"required" example:"SuperSecurePassword123!"`
 }
 
 // RequestPasswordResetHandler godoc
@@ -39,30 +42,51 @@ type PasswordResetConfirm struct {
 // @Produce json
 // @Param request body PasswordResetRequest true "Email to receive reset link"
 // @Success 200 {object} GenericMessageResponse
-// @Failure 400 {object} map[string]string "Missing or invalid email"
-// @Failure 500 {object} map[string]string "Internal error or email send failed"
+// @Failure 400 {object} problem.Problem "Malformed request body"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 500 {object} problem.Problem "Internal error or email send failed"
 // @Router /api/v1/auth/request-password-reset [post]
 func RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
-	var body map[string]string
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["email"] == "" {
-		corestub.RespondWithTraceError(w, "missing_email", http.StatusBadRequest)
+	var req PasswordResetRequest
+	if !binding.DecodeJSON(w, r, &req, "invalid_request") {
+		audit.Record(r, audit.Failure("", "password_reset_requested", "invalid_request"))
 		return
 	}
 
-	email := strings.ToLower(strings.TrimSpace(body["email"]))
+	email := strings.ToLower(strings.TrimSpace(req.Email))
 	token := utils.GenerateSecureToken(32)
 
-	if err := sqlite.InsertEmailToken(email, token, "password_reset"); err != nil {
-		corestub.RespondWithTraceError(w, "reset_token_insert_failed", http.StatusInternalServerError)
+	// Attribution only; the response never reveals whether the address is registered
+	username := ""
+	if user, _ := sqlite.GetUserByEmail(r.Context(), email); user != nil {
+		username = user.Username
+	}
+
+	if err := sqlite.InsertEmailToken(r.Context(), email, token, "password_reset"); err != nil {
+		audit.Record(r, audit.Failure(username, "password_reset_requested", "token_insert_failed"))
+		problem.Respond(w, r, "reset_token_insert_failed", http.StatusInternalServerError)
 		return
 	}
 
-	if err := SendPasswordResetEmail(email, token); err != nil {
-		corestub.RespondWithTraceError(w, "reset_email_failed", http.StatusInternalServerError)
+	if err := SendPasswordResetEmail(r.Context(), email, token); err != nil {
+		audit.Record(r, audit.Failure(username, "password_reset_requested", "email_send_failed"))
+		problem.Respond(w, r, "reset_email_failed", http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Printf("🔑 Password reset token sent to %s\n", email)
+	audit.Record(r, audit.Success(username, "", "password_reset_requested"))
+
+	events.Publish(events.Event{
+		Type:      events.PasswordResetRequested,
+		Username:  username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Reason:    "reset link sent",
+	})
+
+	slog.InfoContext(r.Context(), "🔑 Password reset link sent", "email", email)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(GenericMessageResponse{
 		Message: "Password reset email sent",
@@ -77,47 +101,71 @@ func RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body PasswordResetConfirm true "Token and new password"
 // @Success 200 {object} GenericMessageResponse
-// @Failure 400 {object} map[string]string "Invalid or missing token/password"
-// @Failure 500 {object} map[string]string "Internal error or reset failure"
+// @Failure 400 {object} problem.Problem "Malformed request body or invalid token"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed or password violates the policy"
+// @Failure 500 {object} problem.Problem "Internal error or reset failure"
 // @Router /api/v1/auth/reset-password [post]
 func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	var body map[string]string
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		corestub.RespondWithTraceError(w, "reset_body_invalid", http.StatusBadRequest)
-		return
-	}
-	token := body["token"]
-	newPass := body["password"]
-	if token == "" || newPass == "" {
-		corestub.RespondWithTraceError(w, "reset_missing_token_or_pass", http.StatusBadRequest)
+	var req PasswordResetConfirm
+	if !binding.DecodeJSON(w, r, &req, "reset_body_invalid") {
+		audit.Record(r, audit.Failure("", "password_reset", "invalid_request"))
 		return
 	}
+	token, newPass := req.Token, req.Password
 
-	email, err := sqlite.GetUsernameByTokenAndType(token, "password_reset")
+	email, err := sqlite.GetUsernameByTokenAndType(r.Context(), token, "password_reset")
 	if err != nil || email == "" {
-		corestub.RespondWithTraceError(w, "reset_token_invalid", http.StatusBadRequest)
+		audit.Record(r, audit.Failure("", "password_reset", "invalid_token"))
+		problem.Respond(w, r, "reset_token_invalid", http.StatusBadRequest)
 		return
 	}
 
-	user, err := sqlite.GetUserByEmail(email)
+	user, err := sqlite.GetUserByEmail(r.Context(), email)
 	if err != nil || user == nil {
-		corestub.RespondWithTraceError(w, "user_not_found", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure("", "password_reset", "user_not_found"))
+		problem.Respond(w, r, "user_not_found", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := checkNewPassword(r, user, newPass)
+	if err != nil {
+		audit.Record(r, audit.Failure(user.Username, "password_reset", "password_history_failed"))
+		problem.Respond(w, r, "password_history_failed", http.StatusInternalServerError)
+		return
+	}
+	if !res.OK {
+		audit.Record(r, audit.Failure(user.Username, "password_reset", policyFailureReason(res)))
+		respondPolicyViolation(w, r, res)
 		return
 	}
 
 	if appConfig == nil {
-		corestub.RespondWithTraceError(w, "missing_config", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(user.Username, "password_reset", "missing_config"))
+		problem.Respond(w, r, "missing_config", http.StatusInternalServerError)
 		return
 	}
 
-	if err := keycloak.ResetPassword(appConfig, user.Username, newPass); err != nil {
-		corestub.RespondWithTraceError(w, "kc_reset_failed", http.StatusInternalServerError)
+	if err := keycloak.ResetPassword(r.Context(), appConfig, user.Username, newPass); err != nil {
+		audit.Record(r, audit.Failure(user.Username, "password_reset", "keycloak_reset_failed"))
+		problem.Respond(w, r, "kc_reset_failed", http.StatusInternalServerError)
 		return
 	}
 
-	_ = sqlite.DeleteVerificationToken(token)
+	rememberPassword(r, user, newPass)
+	_ = sqlite.DeleteVerificationToken(r.Context(), token)
+	audit.Record(r, audit.Success(user.Username, "", "password_reset"))
+
+	events.Publish(events.Event{
+		Type:      events.PasswordReset,
+		Username:  user.Username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Reason:    "password reset via emailed token",
+	})
 
-	fmt.Printf("✅ Password reset for user: %s at %v\n", user.Username, time.Now())
+	slog.InfoContext(r.Context(), "✅ Password reset", "username", user.Username)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(GenericMessageResponse{
 		Message: "Password updated successfully",
@@ -1,35 +1,51 @@
 package handlers
 
 import (
-	"encoding/json"
+	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/reload"
 	"github.com/peithosecure/peitho-backend/pkg/prowler"
 )
 
-// ProwlerScanResponse is the full response payload for /security-scan
-type ProwlerScanResponse struct {
-	Status  string             `json:"status" example:"completed"`
-	Summary prowler.ScanResult `json:"summary"`
-}
-
 // ProwlerScanHandler godoc
-// @Summary Trigger Prowler security audit
-// @Description Runs a limited Peitho Prowler scan to check for signs of tampering, token leakage, or license gaps
+// @Summary Run the Prowler security scan
+// @Description Runs every registered security check against the running configuration (TLS, CORS,
+// @Description admin credentials, database permissions, license, SMTP TLS, JWKS) and returns the
+// @Description findings with a 0-100 score. format=sarif returns SARIF 2.1.0 for code-scanning tools.
 // @Tags security
 // @Produce json
-// @Success 200 {object} ProwlerScanResponse
-// @Failure 403 {object} map[string]string "License lock or tamper guard triggered"
+// @Security BearerAuth
+// @Security BasicAuth
+// @Param format query string false "json (default) or sarif"
+// @Success 200 {object} prowler.Report
+// @Failure 400 {object} problem.Problem "Unknown format"
+// @Failure 401 {object} problem.Problem "Missing or invalid admin credentials"
+// @Failure 403 {object} problem.Problem "License lock or tamper guard triggered, or caller is not an admin"
 // @Router /api/v1/security-scan [get]
 func ProwlerScanHandler(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = prowler.FormatJSON
+	}
+	if format != prowler.FormatJSON && format != prowler.FormatSARIF {
+		problem.Respond(w, r, "invalid_scan_format", http.StatusBadRequest)
+		return
+	}
 	corestub.TrackEvent("prowler_scan_triggered")
 
-	result := prowler.RunSecurityScan()
+	// Scan what is running now, reloads included; the startup copy goes stale on SIGHUP
+	cfg := reload.Current()
+	if cfg == nil {
+		cfg = GlobalConfig
+	}
+	report := prowler.Scan(r.Context(), cfg)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ProwlerScanResponse{
-		Status:  "completed",
-		Summary: result,
-	})
+	w.Header().Set("Content-Type", prowler.ContentType(format))
+	if err := prowler.Write(w, format, report); err != nil {
+		slog.Error("🛡️ Security scan response failed", "error", err)
+	}
 }
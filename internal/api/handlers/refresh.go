@@ -4,16 +4,16 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
-	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
 	"github.com/peithosecure/peitho-backend/internal/metrics"
-	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // RefreshRequest is used to request a new access token
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token" example:"eyJhbGc..."`
+	RefreshToken string `json:"refresh_token" validate:"required" example:"eyJhbGc..."`
 }
 
 // RefreshResponse represents the token response payload
@@ -32,33 +32,35 @@ type RefreshResponse struct {
 // @Produce json
 // @Param request body RefreshRequest true "Refresh token payload"
 // @Success 200 {object} RefreshResponse
-// @Failure 400 {object} map[string]string "Malformed request or missing refresh token"
-// @Failure 401 {object} map[string]string "Invalid or expired refresh token"
+// @Failure 400 {object} problem.Problem "Malformed request or missing refresh token"
+// @Failure 401 {object} problem.Problem "Invalid or expired refresh token"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
 // @Router /api/v1/auth/refresh [post]
 func RefreshHandler(w http.ResponseWriter, r *http.Request) {
 	var body RefreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		corestub.RespondWithTraceError(w, "refresh_parse_fail", http.StatusBadRequest)
+	if !binding.DecodeJSON(w, r, &body, "refresh_parse_fail") {
+		audit.Record(r, audit.Failure("", "token_refreshed", "invalid_request"))
 		return
 	}
 
-	tokenResp, err := keycloak.RefreshWithToken(GlobalConfig, body.RefreshToken)
+	tokenResp, err := keycloak.RefreshWithToken(r.Context(), GlobalConfig, body.RefreshToken)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "refresh_invalid", http.StatusUnauthorized)
+		// Keycloak rejected the token, so its claims are whatever the caller wrote; recording
+		// them would let anyone attribute failures to another user
+		audit.Record(r, audit.Failure("", "token_refreshed", "refresh_token_rejected"))
+		problem.Respond(w, r, "refresh_invalid", http.StatusUnauthorized)
 		return
 	}
 
 	metrics.IncRefreshed()
 
-	ctxUser := r.Context().Value(middleware.UserContextKey)
-	if claims, ok := ctxUser.(map[string]interface{}); ok {
-		if uname, ok := claims["preferred_username"].(string); ok {
-			_ = sqlite.LogAuditEvent(uname, "token_refreshed", r.RemoteAddr, r.UserAgent())
-		}
-	}
+	accessToken, _ := tokenResp["access_token"].(string)
+	username, subject := tokenIdentity(accessToken)
+	audit.Record(r, audit.Success(username, subject, "token_refreshed"))
 
 	w.Header().Set("Content-Type", "application/json")
-	accessToken, _ := tokenResp["access_token"].(string)
 	refreshToken, _ := tokenResp["refresh_token"].(string)
 	tokenType, _ := tokenResp["token_type"].(string)
 	expiresIn, _ := tokenResp["expires_in"].(float64)
@@ -1,19 +1,25 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // RegisterRequest defines payload for new account registration
 type RegisterRequest struct {
-	Username string `json:"username" example:"johndoe"`
-	Email    string `json:"email" example:"john@example.com"`
+	Username string `json:"username" validate:"required,max=64" example:"johndoe"`
+	Email    string `json:"email" validate:"required,email,max=254" example:"john@example.com"`
 }
 
 // RegisterResponse defines response after registration
@@ -29,30 +35,30 @@ type RegisterResponse struct {
 // @Produce json
 // @Param request body RegisterRequest true "Email and username payload"
 // @Success 201 {object} RegisterResponse
-// @Failure 400 {object} map[string]string "Malformed request or missing fields"
-// @Failure 409 {object} map[string]string "Email already registered and verified"
-// @Failure 500 {object} map[string]string "Database or email error"
+// @Failure 400 {object} problem.Problem "Malformed request or blank username"
+// @Failure 409 {object} problem.Problem "Email already registered and verified"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 500 {object} problem.Problem "Database or email error"
 // @Router /api/v1/auth/register [post]
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		corestub.RespondWithTraceError(w, "invalid_request", http.StatusBadRequest)
-		return
-	}
-
-	if req.Email == "" || !isValidEmail(req.Email) {
-		corestub.RespondWithTraceError(w, "email_format_invalid", http.StatusBadRequest)
+	if !binding.DecodeJSON(w, r, &req, "invalid_request") {
+		audit.Record(r, audit.Failure("", "user_registered", "invalid_request"))
 		return
 	}
 
 	if strings.TrimSpace(req.Username) == "" {
-		corestub.RespondWithTraceError(w, "username_missing", http.StatusBadRequest)
+		audit.Record(r, audit.Failure("", "user_registered", "missing_username"))
+		problem.Respond(w, r, "username_missing", http.StatusBadRequest)
 		return
 	}
 
-	existingUser, _ := sqlite.GetUserByEmail(req.Email)
+	existingUser, _ := sqlite.GetUserByEmail(r.Context(), req.Email)
 	if existingUser != nil && existingUser.EmailVerified == 1 {
-		corestub.RespondWithTraceError(w, "email_already_verified", http.StatusConflict)
+		audit.Record(r, audit.Failure(req.Username, "user_registered", "email_already_verified"))
+		problem.Respond(w, r, "email_already_verified", http.StatusConflict)
 		return
 	}
 
@@ -63,17 +69,24 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		ON CONFLICT(email) DO UPDATE SET username=excluded.username, email_verified=0
 	`, req.Username, req.Email, "user", 0)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "user_save_fail", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(req.Username, "user_registered", "user_save_failed"))
+		problem.Respond(w, r, "user_save_fail", http.StatusInternalServerError)
 		return
 	}
 
-	_ = sqlite.LogAuditEvent(req.Username, "user_registered", r.RemoteAddr, r.UserAgent())
+	audit.Record(r, audit.Success(req.Username, "", "user_registered"))
+	events.Publish(events.Event{
+		Type:      events.UserRegistered,
+		Username:  req.Username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
 
-	go func() {
-		if err := SendVerificationEmail(req.Username, req.Email); err != nil {
-			fmt.Printf("[!] Failed to send email to %s: %v\n", req.Email, err)
+	lifecycle.Go(func() {
+		if err := SendVerificationEmail(context.WithoutCancel(r.Context()), req.Username, req.Email); err != nil {
+			slog.ErrorContext(r.Context(), "❌ Failed to send verification email", "email", req.Email, "error", err)
 		}
-	}()
+	})
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(RegisterResponse{
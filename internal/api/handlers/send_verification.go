@@ -1,55 +1,56 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
 )
 
+// SendVerificationRequest defines the input for resending a verification email
+type SendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email,max=254" example:"user@example.com"`
+}
+
 // SendVerificationLinkHandler godoc
 // @Summary Resend verification email
 // @Description Sends a new verification email to the user
 // @Tags Email
 // @Accept json
 // @Produce plain
-// @Param emailRequest body map[string]string true "Email payload"
+// @Param emailRequest body SendVerificationRequest true "Email payload"
 // @Success 200 {string} string "Verification email sent"
-// @Failure 400 {string} string "Invalid request"
-// @Failure 404 {string} string "User not found"
+// @Failure 400 {object} problem.Problem "Invalid request"
+// @Failure 404 {object} problem.Problem "User not found"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
 // @Router /api/v1/auth/send-verification [post]
 func SendVerificationLinkHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email string `json:"email"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		fmt.Println("⚠️ Invalid request payload")
-		return
-	}
-
-	if !isValidEmail(req.Email) {
-		http.Error(w, "Invalid email format", http.StatusBadRequest)
-		fmt.Printf("⚠️ Invalid email format: %s\n", req.Email)
+	var req SendVerificationRequest
+	if !binding.DecodeJSON(w, r, &req, "invalid_request") {
+		slog.WarnContext(r.Context(), "⚠️ Invalid send-verification payload")
 		return
 	}
 
-	user, err := sqlite.GetUserByEmail(req.Email)
+	user, err := sqlite.GetUserByEmail(r.Context(), req.Email)
 	if err != nil || user == nil {
-		http.Error(w, "User not found", http.StatusNotFound)
-		fmt.Printf("❌ User not found: %s (err: %v)\n", req.Email, err)
+		problem.Respond(w, r, "user_not_found", http.StatusNotFound)
+		slog.WarnContext(r.Context(), "❌ User not found", "email", req.Email, "error", err)
 		return
 	}
 
-	go func() {
-		if err := SendVerificationEmail(user.Username, req.Email); err != nil {
-			fmt.Printf("[!] Failed to resend email to %s: %v\n", user.Username, err)
+	lifecycle.Go(func() {
+		if err := SendVerificationEmail(context.WithoutCancel(r.Context()), user.Username, req.Email); err != nil {
+			slog.ErrorContext(r.Context(), "❌ Failed to resend verification email", "username", user.Username, "error", err)
 		} else {
-			fmt.Printf("📨 Verification email sent to: %s\n", req.Email)
+			slog.InfoContext(r.Context(), "📨 Verification email sent", "email", req.Email)
 		}
-	}()
+	})
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Verification email sent"))
@@ -3,29 +3,47 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/health"
 )
 
 // StatusResponse defines branding and health status metadata
 type StatusResponse struct {
 	Brand         string `json:"brand" example:"PeithoSecure Lite"`
-	LicenseStatus string `json:"license_status" example:"Valid"`
+	LicenseStatus string `json:"license_status" example:"Valid" enums:"Valid,Invalid,Unknown"`
 	Copyright     string `json:"copyright" example:"© 2025 Peitho"`
-	Mood          string `json:"mood" example:"Stable"`
+	Mood          string `json:"mood" example:"Stable" enums:"Stable,Uneasy,Unstable"`
+	Version       string `json:"version" example:"1.2.0"`
 }
 
 // StatusHandler godoc
 // @Summary Service status and branding info
-// @Description Returns branding, license status, copyright, and system mood
+// @Description Returns branding, license status, copyright, and system mood. License status and mood come from the readiness checks: Uneasy means a non-critical dependency is failing, Unstable a critical one.
 // @Tags health
 // @Produce json
 // @Success 200 {object} StatusResponse
 // @Router /status [get]
 func StatusHandler(w http.ResponseWriter, r *http.Request) {
+	report := health.Run(r.Context())
+
 	resp := StatusResponse{
 		Brand:         "Peitho",
-		LicenseStatus: "Valid",
+		LicenseStatus: "Unknown",
 		Copyright:     "© 2025 Peitho",
 		Mood:          "Stable",
+		Version:       report.Build.Version,
+	}
+	if res, ok := report.Find("license"); ok {
+		resp.LicenseStatus = "Valid"
+		if res.Status != health.StatusOK {
+			resp.LicenseStatus = "Invalid"
+		}
+	}
+	switch report.Status {
+	case health.StatusDegraded:
+		resp.Mood = "Uneasy"
+	case health.StatusFail:
+		resp.Mood = "Unstable"
 	}
 
 	w.Header().Set("Content-Type", "application/json")
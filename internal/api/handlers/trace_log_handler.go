@@ -2,15 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/trace"
 )
 
+const maxTracePageSize = 500
+
 // TraceEventView is a stripped-down representation of a trace event
 type TraceEventView struct {
-	ID        string `json:"id" example:"7c8bfc1d"`
+	ID        string `json:"id" example:"7c8bfc1d9a4e2f3b6c5d8e7f0a1b2c3d"`
 	Message   string `json:"message" example:"unauthorized access detected"`
 	Actor     string `json:"actor" example:"USER"`
 	Event     string `json:"event" example:"auth_failed"`
@@ -20,15 +26,37 @@ type TraceEventView struct {
 }
 
 // TraceLogHandler godoc
-// @Summary View in-memory trace logs
-// @Description Returns recent trace events stored in memory (for debugging, audits, or escalations)
+// @Summary View trace logs
+// @Description Returns trace events, newest first. Unfiltered first pages are served from the in-memory ring; everything else comes from SQLite. When more results exist the X-Next-Cursor header carries the cursor for the next page.
 // @Tags logs
 // @Produce json
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor from a previous X-Next-Cursor header"
+// @Param actor query string false "Filter by actor (DEV, USER, HACKER)"
+// @Param severity query string false "Filter by severity (low, medium, high, critical)"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param until query string false "Only events at or before this RFC3339 time"
 // @Success 200 {array} TraceEventView
+// @Failure 400 {object} problem.Problem "Invalid filter"
+// @Failure 500 {object} problem.Problem "Trace query failed"
 // @Security ApiKeyAuth
 // @Router /api/v1/log/trace [get]
 func TraceLogHandler(w http.ResponseWriter, r *http.Request) {
-	traces := corestub.GetRecentTraces()
+	filter, err := parseTraceFilter(r)
+	if err != nil {
+		problem.RespondWithDetail(w, r, "invalid_trace_filter", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	traces, next, err := trace.Query(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, trace.ErrInvalidCursor) {
+			problem.RespondWithDetail(w, r, "invalid_trace_filter", http.StatusBadRequest, err.Error())
+			return
+		}
+		problem.Respond(w, r, "trace_query_failed", http.StatusInternalServerError)
+		return
+	}
 
 	payload := make([]TraceEventView, 0, len(traces))
 	for _, t := range traces {
@@ -43,6 +71,55 @@ func TraceLogHandler(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(payload)
 }
+
+func parseTraceFilter(r *http.Request) (trace.Filter, error) {
+	q := r.URL.Query()
+	f := trace.Filter{
+		Actor:  strings.ToUpper(q.Get("actor")),
+		Cursor: q.Get("cursor"),
+		Limit:  50,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, err
+		}
+		if n <= 0 {
+			return f, errors.New("limit must be positive")
+		}
+		if n > maxTracePageSize {
+			n = maxTracePageSize
+		}
+		f.Limit = n
+	}
+	if v := q.Get("severity"); v != "" {
+		severity, err := trace.NormalizeSeverity(v)
+		if err != nil {
+			return f, err
+		}
+		f.Severity = severity
+	}
+
+	var err error
+	if f.Since, err = parseTimeParam(q.Get("since")); err != nil {
+		return f, err
+	}
+	if f.Until, err = parseTimeParam(q.Get("until")); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
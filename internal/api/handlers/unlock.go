@@ -3,49 +3,53 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
+// UnlockValidateRequest carries a signed PQC license block
+type UnlockValidateRequest struct {
+	Block string `json:"block" validate:"required" example:"-----BEGIN PEITHO LICENSE-----..."`
+}
+
 // UnlockValidateHandler godoc
 // @Summary Validate unlock license
 // @Description Accepts a signed PQC license block, writes it to disk, and validates it
 // @Tags license
 // @Accept json
 // @Produce json
-// @Param unlockRequest body map[string]string true "License block payload"
+// @Param unlockRequest body UnlockValidateRequest true "License block payload"
 // @Success 200 {object} UnlockSuccessResponse "Unlock succeeded"
-// @Failure 400 {object} map[string]string "Invalid request"
-// @Failure 403 {object} map[string]string "Unlock validation failed"
-// @Failure 500 {object} map[string]string "Write failed"
+// @Failure 400 {object} problem.Problem "Invalid request"
+// @Failure 403 {object} problem.Problem "Unlock validation failed"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 500 {object} problem.Problem "Write failed"
 // @Router /api/v1/auth/unlock/validate [post]
 func UnlockValidateHandler(w http.ResponseWriter, r *http.Request) {
-	var body struct {
-		Block string `json:"block"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		corestub.RespondWithTraceError(w, "unlock_block_parse_fail", http.StatusBadRequest)
+	var body UnlockValidateRequest
+	if !binding.DecodeJSON(w, r, &body, "unlock_block_parse_fail") {
 		return
 	}
 
-	unlockPath := os.Getenv("UNLOCK_PATH")
-	if unlockPath == "" {
-		unlockPath = "/app/peitho-core/unlock.lic"
-	}
-
-	// Optional: backup existing license
-	if _, err := os.Stat(unlockPath); err == nil {
-		_ = os.Rename(unlockPath, unlockPath+".bak")
-	}
-
-	if err := os.WriteFile(unlockPath, []byte(body.Block), 0600); err != nil {
-		corestub.RespondWithTraceError(w, "unlock_write_failed", http.StatusInternalServerError)
+	if _, err := writeUnlockFile(body.Block); err != nil {
+		problem.Respond(w, r, "unlock_write_failed", http.StatusInternalServerError)
 		return
 	}
 
 	if _, err := corestub.ValidateUnlock(); err != nil {
-		corestub.RespondWithTraceError(w, "unlock_invalid", http.StatusForbidden)
+		events.Publish(events.Event{
+			Type:      events.LicenseInvalid,
+			IP:        middleware.ClientIP(r),
+			UserAgent: r.UserAgent(),
+			Reason:    err.Error(),
+		})
+		problem.Respond(w, r, "unlock_invalid", http.StatusForbidden)
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 )
 
@@ -27,18 +28,18 @@ const enforcedBrandingSignature = "Peitho 🔐"
 // @Tags License
 // @Produce json
 // @Success 200 {object} UnlockStatusResponse
-// @Failure 403 {object} map[string]string
+// @Failure 403 {object} problem.Problem
 // @Router /api/v1/auth/unlock-status [get]
 func UnlockStatusHandler(w http.ResponseWriter, r *http.Request) {
 	status, unlockedAt := corestub.UnlockStatus()
 
 	if !status {
-		corestub.RespondWithTraceError(w, "core_locked", http.StatusForbidden)
+		problem.Respond(w, r, "core_locked", http.StatusForbidden)
 		return
 	}
 
 	if enforcedBrandingSignature != "Peitho 🔐" {
-		corestub.RespondWithTraceError(w, "branding_override_detected", http.StatusForbidden)
+		problem.Respond(w, r, "branding_override_detected", http.StatusForbidden)
 		return
 	}
 
@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
-	"time"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/audit"
 	"github.com/peithosecure/peitho-backend/internal/auth/keycloak"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
 )
 
 // VerifyEmailHandler godoc
@@ -19,80 +22,84 @@ import (
 // @Produce json
 // @Param token query string true "Verification token"
 // @Success 200 {object} EmailVerificationResponse
-// @Failure 400 {object} map[string]string "Invalid token"
-// @Failure 409 {object} map[string]string "Already verified"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} problem.Problem "Invalid token"
+// @Failure 409 {object} problem.Problem "Already verified"
+// @Failure 500 {object} problem.Problem "Internal server error"
 // @Router /api/v1/auth/verify-email [get]
 func VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
 	if token == "" {
-		corestub.RespondWithTraceError(w, "missing_token", http.StatusBadRequest)
+		audit.Record(r, audit.Failure("", "email_verified", "missing_token"))
+		problem.Respond(w, r, "missing_token", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("🔍 [VerifyEmailHandler] Incoming token: %s\n", token)
+	slog.DebugContext(r.Context(), "🔍 Email verification token received", "token", token)
 
-	email, err := sqlite.GetUsernameByToken(token)
+	email, err := sqlite.GetUsernameByToken(r.Context(), token)
 	if err != nil || email == "" {
-		fmt.Printf("❌ [VerifyEmailHandler] Token lookup failed: %v\n", err)
-		corestub.RespondWithTraceError(w, "invalid_token", http.StatusBadRequest)
+		slog.WarnContext(r.Context(), "❌ Email verification token lookup failed", "error", err)
+		audit.Record(r, audit.Failure("", "email_verified", "invalid_token"))
+		problem.Respond(w, r, "invalid_token", http.StatusBadRequest)
 		return
 	}
 
-	user, err := sqlite.GetUserByEmail(email)
+	user, err := sqlite.GetUserByEmail(r.Context(), email)
 	if err != nil || user == nil {
-		fmt.Printf("❌ [VerifyEmailHandler] User not found for email: %s\n", email)
-		corestub.RespondWithTraceError(w, "user_not_found", http.StatusInternalServerError)
+		slog.WarnContext(r.Context(), "❌ User not found for verification token", "email", email)
+		audit.Record(r, audit.Failure("", "email_verified", "user_not_found"))
+		problem.Respond(w, r, "user_not_found", http.StatusInternalServerError)
 		return
 	}
 
 	if user.EmailVerified == 1 {
-		fmt.Printf("⚠️ [VerifyEmailHandler] Email already verified: %s\n", user.Username)
-		corestub.RespondWithTraceError(w, "already_verified", http.StatusConflict)
+		slog.InfoContext(r.Context(), "⚠️ Email already verified", "username", user.Username)
+		audit.Record(r, audit.Failure(user.Username, "email_verified", "already_verified"))
+		problem.Respond(w, r, "already_verified", http.StatusConflict)
 		return
 	}
 
-	if err := sqlite.MarkEmailVerified(user.Username); err != nil {
-		corestub.RespondWithTraceError(w, "verify_fail", http.StatusInternalServerError)
+	if err := sqlite.MarkEmailVerified(r.Context(), user.Username); err != nil {
+		audit.Record(r, audit.Failure(user.Username, "email_verified", "mark_verified_failed"))
+		problem.Respond(w, r, "verify_fail", http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Printf("✅ [VerifyEmailHandler] Email verified for user: %s at %v\n", user.Username, time.Now())
+	slog.InfoContext(r.Context(), "✅ Email verified", "username", user.Username)
 
-	err = keycloak.RegisterNewUserWithEmail(GlobalConfig, user.Username, "", user.Email)
+	err = keycloak.RegisterNewUserWithEmail(r.Context(), GlobalConfig, user.Username, "", user.Email)
 	if err != nil {
-		corestub.RespondWithTraceError(w, "keycloak_user_create_failed", http.StatusInternalServerError)
+		audit.Record(r, audit.Failure(user.Username, "email_verified", "keycloak_create_failed"))
+		problem.Respond(w, r, "keycloak_user_create_failed", http.StatusInternalServerError)
 		return
 	}
 
-	go func() {
+	lifecycle.Go(func() {
 		deviceID := r.Header.Get("Device-ID")
 		if deviceID == "" {
-			deviceID = "web-default"
+			deviceID = GlobalConfig.DeviceID
 		}
 
 		block, err := corestub.GenerateSignedLicense(user.Username, deviceID, true)
 		if err != nil {
-			fmt.Printf("[!] Failed to generate license for %s: %v\n", user.Username, err)
+			slog.ErrorContext(r.Context(), "❌ Failed to generate license", "username", user.Username, "error", err)
 			return
 		}
 
-		unlockPath := os.Getenv("UNLOCK_PATH")
-		if unlockPath == "" {
-			unlockPath = "/app/peitho-core/unlock.lic"
-		}
-		if _, err := os.Stat(unlockPath); err == nil {
-			_ = os.Rename(unlockPath, unlockPath+".bak")
-		}
-
-		if err := os.WriteFile(unlockPath, []byte(block), 0600); err != nil {
-			fmt.Printf("[!] Failed to write unlock.lic for %s: %v\n", user.Username, err)
+		if unlockPath, err := writeUnlockFile(block); err != nil {
+			slog.ErrorContext(r.Context(), "❌ Failed to write unlock.lic", "username", user.Username, "error", err)
 		} else {
-			fmt.Printf("[+] PQC license written for user: %s → %s\n", user.Username, unlockPath)
+			slog.InfoContext(r.Context(), "🔏 PQC license written", "username", user.Username, "path", unlockPath)
 		}
-	}()
+	})
 
-	_ = sqlite.LogAuditEvent(user.Username, "email_verified", r.RemoteAddr, r.UserAgent())
+	audit.Record(r, audit.Success(user.Username, "", "email_verified"))
+	events.Publish(events.Event{
+		Type:      events.EmailVerified,
+		Username:  user.Username,
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(EmailVerificationResponse{
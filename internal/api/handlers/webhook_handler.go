@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/api/binding"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/webhooks"
+)
+
+// WebhookSubscriptionRequest defines the payload for registering a webhook
+type WebhookSubscriptionRequest struct {
+	URL    string   `json:"url" validate:"required,url" example:"https://hooks.example.com/peitho"`
+	Events []string `json:"events" validate:"dive,required" example:"user_registered,password_reset"` // Event types, or "*" for all (default)
+	Secret string   `json:"secret,omitempty" example:"whsec_..."`                                     // Signing secret; generated when omitted
+}
+
+// WebhookSubscriptionCreated is returned once on creation and is the only time the secret is shown
+type WebhookSubscriptionCreated struct {
+	models.WebhookSubscription
+	Secret string `json:"secret" example:"whsec_3f9c..."`
+}
+
+// ListWebhooksHandler godoc
+// @Summary List webhook subscriptions
+// @Description Returns every registered webhook subscription (secrets are never included)
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 500 {object} problem.Problem "Query failed"
+// @Security BearerAuth
+// @Router /api/v1/admin/webhooks [get]
+func ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := sqlite.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		problem.Respond(w, r, "webhook_query_failed", http.StatusInternalServerError)
+		return
+	}
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(subs)
+}
+
+// CreateWebhookHandler godoc
+// @Summary Register a webhook subscription
+// @Description Registers an endpoint that receives HMAC-SHA256 signed event payloads (X-Peitho-Signature: t=<unix>,v1=<hex>)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body WebhookSubscriptionRequest true "Endpoint URL, event filter and optional secret"
+// @Success 201 {object} WebhookSubscriptionCreated
+// @Failure 400 {object} problem.Problem "Malformed request, invalid URL or unknown event type"
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 413 {object} problem.Problem "Request body too large"
+// @Failure 415 {object} problem.Problem "Content-Type is not application/json"
+// @Failure 422 {object} problem.Problem "Field validation failed"
+// @Failure 500 {object} problem.Problem "Subscription could not be stored"
+// @Security BearerAuth
+// @Router /api/v1/admin/webhooks [post]
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req WebhookSubscriptionRequest
+	if !binding.DecodeJSON(w, r, &req, "webhook_parse_fail") {
+		return
+	}
+
+	sub, err := webhooks.CreateSubscription(r.Context(), req.URL, req.Events, req.Secret)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrInvalidURL) || errors.Is(err, webhooks.ErrInvalidEvent) {
+			problem.RespondWithDetail(w, r, "webhook_invalid", http.StatusBadRequest, err.Error())
+			return
+		}
+		problem.Respond(w, r, "webhook_create_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(WebhookSubscriptionCreated{
+		WebhookSubscription: *sub,
+		Secret:              sub.Secret,
+	})
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook subscription
+// @Description Removes a subscription together with its delivery log
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} GenericMessageResponse
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 404 {object} problem.Problem "Subscription not found"
+// @Failure 500 {object} problem.Problem "Delete failed"
+// @Security BearerAuth
+// @Router /api/v1/admin/webhooks/{id} [delete]
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := sqlite.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			problem.Respond(w, r, "webhook_not_found", http.StatusNotFound)
+			return
+		}
+		problem.Respond(w, r, "webhook_delete_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(GenericMessageResponse{
+		Message: "Webhook subscription deleted",
+	})
+}
+
+// ListWebhookDeliveriesHandler godoc
+// @Summary Webhook delivery log
+// @Description Returns the most recent deliveries for a subscription, newest first
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param limit query int false "Maximum entries (default 100)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 404 {object} problem.Problem "Subscription not found"
+// @Failure 500 {object} problem.Problem "Query failed"
+// @Security BearerAuth
+// @Router /api/v1/admin/webhooks/{id}/deliveries [get]
+func ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	sub, err := sqlite.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		problem.Respond(w, r, "webhook_query_failed", http.StatusInternalServerError)
+		return
+	}
+	if sub == nil {
+		problem.Respond(w, r, "webhook_not_found", http.StatusNotFound)
+		return
+	}
+
+	limit := 100
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 && n <= 1000 {
+		limit = n
+	}
+
+	deliveries, err := sqlite.ListWebhookDeliveries(r.Context(), id, limit)
+	if err != nil {
+		problem.Respond(w, r, "webhook_query_failed", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
+
+// ReplayWebhookDeliveryHandler godoc
+// @Summary Replay a webhook delivery
+// @Description Queues a new delivery with the same payload as an earlier one, regardless of its outcome
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 401 {object} problem.Problem "Unauthorized"
+// @Failure 403 {object} problem.Problem "Admin role required"
+// @Failure 404 {object} problem.Problem "Delivery not found"
+// @Failure 500 {object} problem.Problem "Replay could not be queued"
+// @Security BearerAuth
+// @Router /api/v1/admin/webhooks/deliveries/{id}/replay [post]
+func ReplayWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	replay, err := webhooks.Replay(r.Context(), id)
+	if err != nil {
+		problem.Respond(w, r, "webhook_replay_failed", http.StatusInternalServerError)
+		return
+	}
+	if replay == nil {
+		problem.Respond(w, r, "webhook_delivery_not_found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(replay)
+}
@@ -0,0 +1,161 @@
+package problem
+
+import "sort"
+
+// Entry documents one error code: its usual HTTP status and a stable human title
+type Entry struct {
+	Code   string `json:"code" example:"auth_failed"`
+	Status int    `json:"status" example:"401"`
+	Title  string `json:"title" example:"Invalid username or password"`
+}
+
+// entries is the error catalog. Codes are part of the API contract: add new ones freely,
+// never rename or repurpose an existing code.
+var entries = []Entry{
+	// Request parsing and input
+	{"invalid_request", 400, "Request body is malformed"},
+	{"unsupported_media_type", 415, "Request body must be application/json"},
+	{"request_too_large", 413, "Request body is too large"},
+	{"validation_failed", 422, "Request body failed validation"},
+	{"parse_error", 400, "Request body could not be parsed"},
+	{"login_parse_fail", 400, "Login request body could not be parsed"},
+	{"refresh_parse_fail", 400, "Refresh request body could not be parsed"},
+	{"logout_parse_fail", 400, "Logout request body could not be parsed"},
+	{"reset_body_invalid", 400, "Password reset request body could not be parsed"},
+	{"event_payload_parse_fail", 400, "Event payload could not be parsed"},
+	{"unlock_block_parse_fail", 400, "Unlock block could not be parsed"},
+	{"email_invalid", 400, "Email address is invalid"},
+	{"email_format_invalid", 400, "Email address format is invalid"},
+	{"missing_email", 400, "Email address is required"},
+	{"missing_email_header", 400, "Email header is required"},
+	{"missing_email_param", 400, "Email query parameter is required"},
+	{"missing_username", 400, "Username is required"},
+	{"username_missing", 400, "Username is required"},
+	{"username_required", 400, "Username is required"},
+	{"missing_token", 400, "Token is required"},
+	{"missing_token_or_password", 400, "Token and password are required"},
+	{"reset_missing_token_or_pass", 400, "Reset token and new password are required"},
+	{"missing_deeplink_params", 400, "Deep link parameters are required"},
+	{"invalid_deeplink_type", 400, "Deep link type is not supported"},
+
+	// Authentication and authorization
+	{"auth_failed", 401, "Invalid username or password"},
+	{"missing_auth_header", 401, "Authorization header is required"},
+	{"invalid_auth_format", 401, "Authorization header must be a Bearer token"},
+	{"invalid_token", 401, "Token is invalid or expired"},
+	{"refresh_invalid", 401, "Refresh token is invalid or expired"},
+	{"logout_failed", 401, "Refresh token could not be revoked"},
+	{"unauthorized", 401, "Authentication is required"},
+	{"unauthorized_access", 401, "Authentication is required"},
+	{"integration_fetch_fail", 401, "Integrations could not be loaded for this user"},
+	{"insufficient_role", 403, "Caller lacks the required role"},
+	{"invalid_admin_credentials", 401, "Admin credentials are invalid"},
+	{"admin_ip_forbidden", 403, "Admin endpoints are not reachable from this address"},
+	{"admin_disabled", 404, "Admin endpoints are disabled"},
+	{"admin_auth_busy", 429, "Too many admin credential checks in progress"},
+	{"login_rate_limited", 429, "Too many login attempts"},
+	{"user_locked", 429, "Account is temporarily locked after failed logins"},
+
+	// Account lifecycle
+	{"user_not_found", 404, "User not found"},
+	{"user_not_verified", 400, "Email address is not verified"},
+	{"already_verified", 409, "Email address is already verified"},
+	{"email_already_verified", 409, "Email address is already registered and verified"},
+	{"password_policy_violation", 422, "Password does not meet the password policy"},
+	{"reset_token_invalid", 400, "Password reset token is invalid or expired"},
+	{"user_lookup_failed", 500, "User lookup failed"},
+	{"user_save_fail", 500, "User could not be saved"},
+	{"user_create_failed", 500, "Identity provider user could not be created"},
+	{"keycloak_check_failed", 500, "Identity provider lookup failed"},
+	{"keycloak_user_create_failed", 500, "Identity provider user could not be created"},
+	{"kc_reset_failed", 500, "Identity provider password reset failed"},
+	{"password_reset_failed", 500, "Password could not be set"},
+	{"force_reset_failed", 500, "Forced password reset could not be recorded"},
+	{"account_delete_failed", 500, "Account could not be deleted"},
+	{"verify_fail", 500, "Email verification could not be recorded"},
+	{"check_email_failed", 500, "Email lookup failed"},
+	{"email_send_failed", 500, "Email could not be sent"},
+	{"password_history_failed", 500, "Password history could not be checked"},
+	{"reset_email_failed", 500, "Password reset email could not be sent"},
+	{"reset_token_insert_failed", 500, "Password reset token could not be stored"},
+
+	// Licensing and integrity
+	{"core_locked", 403, "Core features are locked without a valid license"},
+	{"pqc_lock_enforced", 403, "PQC license is required"},
+	{"unlock_invalid", 403, "Unlock license is invalid"},
+	{"branding_override_detected", 403, "Branding tampering detected"},
+	{"tamper_detected", 403, "Request tampering detected"},
+	{"invalid_scan_format", 400, "Security scan format must be json or sarif"},
+	{"license_gen_failed", 500, "License could not be generated"},
+	{"license_write_failed", 500, "License could not be written"},
+	{"unlock_write_failed", 500, "Unlock license could not be written"},
+
+	// Lockdown
+	{"server_lockdown", 503, "Server is in lockdown"},
+	{"not_ready", 503, "Server is starting up or shutting down"},
+	{"ip_lockdown", 403, "Client IP is in lockdown"},
+	{"user_lockdown", 403, "User is in lockdown"},
+	{"lockdown_parse_fail", 400, "Lockdown request body could not be parsed"},
+	{"lockdown_invalid", 400, "Lockdown request is invalid"},
+	{"lockdown_id_invalid", 400, "Lockdown id is invalid"},
+	{"invalid_lockdown_scope", 422, "Lockdown scope must be server, ip or user"},
+	{"lockdown_not_active", 404, "No active lockdown with this id"},
+	{"lockdown_engage_failed", 500, "Lockdown could not be engaged"},
+	{"lockdown_lift_failed", 500, "Lockdown could not be lifted"},
+	{"lockdown_query_failed", 500, "Lockdowns could not be listed"},
+
+	// Trace, audit and export
+	{"invalid_actor", 422, "Actor is not recognised"},
+	{"invalid_severity", 422, "Severity is not recognised"},
+	{"invalid_trace_filter", 400, "Trace filter is invalid"},
+	{"invalid_audit_filter", 400, "Audit filter is invalid"},
+	{"invalid_export_filter", 400, "Export filter is invalid"},
+	{"invalid_export_format", 400, "Export format must be jsonl, csv or cef"},
+	{"invalid_export_source", 400, "Export source must be audit or trace"},
+	{"trace_persist_failed", 500, "Trace event could not be stored"},
+	{"trace_query_failed", 500, "Trace events could not be queried"},
+	{"audit_query_failed", 500, "Audit events could not be queried"},
+	{"audit_verify_failed", 500, "Audit chain could not be verified"},
+
+	// Webhooks
+	{"webhook_parse_fail", 400, "Webhook request body could not be parsed"},
+	{"webhook_invalid", 400, "Webhook subscription is invalid"},
+	{"webhook_not_found", 404, "Webhook subscription not found"},
+	{"webhook_delivery_not_found", 404, "Webhook delivery not found"},
+	{"webhook_create_failed", 500, "Webhook subscription could not be created"},
+	{"webhook_delete_failed", 500, "Webhook subscription could not be deleted"},
+	{"webhook_query_failed", 500, "Webhooks could not be queried"},
+	{"webhook_replay_failed", 500, "Webhook delivery could not be replayed"},
+
+	// Server
+	{"route_not_found", 404, "No such endpoint"},
+	{"method_not_allowed", 405, "Method not allowed on this endpoint"},
+	{"missing_config", 500, "Server configuration is incomplete"},
+	{"encode_fail", 500, "Response could not be encoded"},
+	{"response_encode_failed", 500, "Response could not be encoded"},
+}
+
+var catalog = func() map[string]Entry {
+	m := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		if _, dup := m[e.Code]; dup {
+			panic("problem: duplicate catalog code " + e.Code)
+		}
+		m[e.Code] = e
+	}
+	return m
+}()
+
+// Catalog returns every documented error code, sorted by code
+func Catalog() []Entry {
+	out := make([]Entry, len(entries))
+	copy(out, entries)
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// Lookup returns the catalog entry for code
+func Lookup(code string) (Entry, bool) {
+	e, ok := catalog[code]
+	return e, ok
+}
@@ -0,0 +1,100 @@
+// Package problem writes RFC 7807 application/problem+json error responses.
+// Every response carries a stable machine code from the catalog; clients should
+// branch on code, never on title or detail.
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/logging"
+)
+
+// ContentType is the media type of every error response
+const ContentType = "application/problem+json"
+
+// typePrefix builds the problem type URI from the code
+const typePrefix = "urn:peitho:problem:"
+
+// Problem is the error body returned by every endpoint
+// @Description RFC 7807 problem details with a stable machine code
+type Problem struct {
+	Type      string       `json:"type" example:"urn:peitho:problem:auth_failed"`
+	Title     string       `json:"title" example:"Invalid username or password"`
+	Status    int          `json:"status" example:"401"`
+	Detail    string       `json:"detail,omitempty" example:"since must be RFC3339"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/auth/login"`
+	Code      string       `json:"code" example:"auth_failed"`
+	RequestID string       `json:"request_id,omitempty" example:"4f9c2a7e0b1d4c8e9a6f3b2d1c0e5f7a"`
+	Timestamp time.Time    `json:"timestamp" example:"2025-05-16T10:00:00Z"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid input field
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
+}
+
+// Respond writes the problem for code with the given HTTP status
+func Respond(w http.ResponseWriter, r *http.Request, code string, status int) {
+	Write(w, r, New(r, code, status))
+}
+
+// RespondWithDetail writes the problem for code with a human-readable explanation
+func RespondWithDetail(w http.ResponseWriter, r *http.Request, code string, status int, detail string) {
+	p := New(r, code, status)
+	p.Detail = detail
+	Write(w, r, p)
+}
+
+// RespondWithFields writes the problem for code listing the offending input fields
+func RespondWithFields(w http.ResponseWriter, r *http.Request, code string, status int, fields []FieldError) {
+	p := New(r, code, status)
+	p.Errors = fields
+	Write(w, r, p)
+}
+
+// New builds the problem for code. The title comes from the catalog; codes missing
+// from it fall back to the status text and are logged so the catalog can be completed.
+// r may be nil outside a request.
+func New(r *http.Request, code string, status int) *Problem {
+	p := &Problem{
+		Type:      typePrefix + code,
+		Status:    status,
+		Code:      code,
+		Timestamp: time.Now().UTC(),
+	}
+	if e, ok := catalog[code]; ok {
+		p.Title = e.Title
+	} else {
+		p.Title = http.StatusText(status)
+		slog.Warn("⚠️ Error code missing from problem catalog", "code", code)
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = logging.RequestID(r.Context())
+	}
+	return p
+}
+
+// Write sends p. When the request id is not known from r it is taken from the
+// X-Request-ID response header set by the RequestID middleware.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get("X-Request-ID")
+	}
+	if r != nil {
+		slog.DebugContext(r.Context(), "problem response", "code", p.Code, "status", p.Status)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Del("Content-Length")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -1,25 +1,32 @@
 package routes
 
 import (
-	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/peithosecure/peitho-backend/internal/api/handlers"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
 	"github.com/peithosecure/peitho-backend/internal/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func SetupRoutes() *mux.Router {
 	r := mux.NewRouter()
+	r.NotFoundHandler = middleware.HTTPMetrics(middleware.Tracing(http.HandlerFunc(handlers.RouteNotFoundHandler)))
+	r.MethodNotAllowedHandler = middleware.HTTPMetrics(middleware.Tracing(http.HandlerFunc(handlers.MethodNotAllowedHandler)))
+	r.Use(middleware.HTTPMetrics, middleware.Tracing)
 
 	// Health check endpoints
 	r.HandleFunc("/healthz", handlers.HealthzHandler).Methods(http.MethodGet)
+	r.HandleFunc("/livez", handlers.LivezHandler).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", handlers.ReadyzHandler).Methods(http.MethodGet)
 	r.HandleFunc("/status", handlers.StatusHandler).Methods(http.MethodGet)
 
 	// Auth routes (including unlock endpoints)
 	authRouter := r.PathPrefix("/api/v1/auth").Subrouter()
+	authRouter.Use(middleware.LockdownGuard)
 	authRouter.HandleFunc("/register", handlers.RegisterHandler).Methods(http.MethodPost)
 	authRouter.HandleFunc("/login", handlers.LoginHandler).Methods(http.MethodPost)
 	authRouter.HandleFunc("/refresh", handlers.RefreshHandler).Methods(http.MethodPost)
@@ -32,6 +39,8 @@ func SetupRoutes() *mux.Router {
 	authRouter.HandleFunc("/request-password-reset", handlers.RequestPasswordResetHandler).Methods(http.MethodPost)
 	authRouter.HandleFunc("/reset-password", handlers.ResetPasswordHandler).Methods(http.MethodPost)
 	authRouter.HandleFunc("/setup-password", handlers.SetupPasswordHandler).Methods(http.MethodPost)
+	authRouter.HandleFunc("/password-policy", handlers.PasswordPolicyHandler).Methods(http.MethodGet)
+	authRouter.HandleFunc("/password-policy/check", handlers.PasswordCheckHandler).Methods(http.MethodPost)
 
 	// Add unlock-status and unlock-validate here inside authRouter
 	authRouter.HandleFunc("/unlock-status", handlers.UnlockStatusHandler).Methods(http.MethodGet)
@@ -39,28 +48,31 @@ func SetupRoutes() *mux.Router {
 
 	// Secure routes - protected by AuthGuard middleware
 	secureRouter := r.PathPrefix("/api/v1/auth/secure-sample").Subrouter()
-	secureRouter.Use(middleware.AuthGuard)
+	secureRouter.Use(middleware.AuthGuard, middleware.LockdownGuard)
 	secureRouter.HandleFunc("", handlers.SecureSampleHandler).Methods(http.MethodGet)
 
 	// PQC locked routes - group with UnlockGuardMiddleware for cleaner code
 	pqcRouter := r.PathPrefix("/api/v1").Subrouter()
-	pqcRouter.Use(middleware.UnlockGuardMiddleware)
-	pqcRouter.HandleFunc("/events/log", handlers.EngineEventHandler).Methods(http.MethodPost)
-	pqcRouter.HandleFunc("/security-scan", handlers.ProwlerScanHandler).Methods(http.MethodGet)
-	pqcRouter.HandleFunc("/metrics", handlers.MetricsHandler).Methods(http.MethodGet)
-	pqcRouter.HandleFunc("/admin-metrics", handlers.AdminMetricsHandler).Methods(http.MethodGet)
+	pqcRouter.Use(middleware.UnlockGuardMiddleware, middleware.LockdownGuard)
+	pqcRouter.Handle("/events/log", middleware.AdminGuard(http.HandlerFunc(handlers.EngineEventHandler))).Methods(http.MethodPost)
+	pqcRouter.Handle("/security-scan", middleware.AdminGuard(http.HandlerFunc(handlers.ProwlerScanHandler))).Methods(http.MethodGet)
+	pqcRouter.Handle("/metrics", middleware.AdminGuard(http.HandlerFunc(handlers.MetricsHandler))).Methods(http.MethodGet)
+	pqcRouter.Handle("/admin-metrics", middleware.AdminGuard(http.HandlerFunc(handlers.MetricsHandler))).Methods(http.MethodGet)
+
+	// Error code catalog (public API)
+	r.HandleFunc("/api/v1/errors", handlers.ErrorCatalogHandler).Methods(http.MethodGet)
 
 	// Token metrics (public API)
 	r.HandleFunc("/api/v1/metrics/tokens", handlers.TokenMetricsHandler).Methods(http.MethodGet)
 
 	// App integrations (Bearer-protected)
 	r.Handle("/api/v1/integrations",
-		middleware.AuthGuard(http.HandlerFunc(handlers.GetAppIntegrations)),
+		middleware.AuthGuard(middleware.LockdownGuard(http.HandlerFunc(handlers.GetAppIntegrations))),
 	).Methods(http.MethodGet)
 
 	// Audit analytics (Bearer-protected)
 	r.Handle("/api/v1/analytics/audit",
-		middleware.AuthGuard(http.HandlerFunc(handlers.AuditAnalyticsHandler)),
+		middleware.AuthGuard(middleware.LockdownGuard(http.HandlerFunc(handlers.AuditAnalyticsHandler))),
 	).Methods(http.MethodGet)
 
 	// Deep links - **Note:** two handlers for same path and method will conflict
@@ -72,17 +84,35 @@ func SetupRoutes() *mux.Router {
 	// Trace log (Bearer + PQC Unlock)
 	r.Handle("/api/v1/log/trace",
 		middleware.UnlockGuardMiddleware(
-			middleware.AuthGuard(http.HandlerFunc(handlers.TraceLogHandler))),
+			middleware.AuthGuard(middleware.LockdownGuard(http.HandlerFunc(handlers.TraceLogHandler)))),
 	).Methods(http.MethodGet)
 
+	// Admin routes (AdminGuard) — deliberately outside LockdownGuard so lockdowns can be lifted
+	adminRouter := r.PathPrefix("/api/v1/admin").Subrouter()
+	adminRouter.Use(middleware.AdminGuard)
+	adminRouter.HandleFunc("/readyz", handlers.ReadyzDetailHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/lockdowns", handlers.ListLockdownsHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/lockdowns", handlers.EngageLockdownHandler).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/lockdowns/{id:[0-9]+}", handlers.LiftLockdownHandler).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/webhooks", handlers.ListWebhooksHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/webhooks", handlers.CreateWebhookHandler).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/webhooks/{id:[0-9]+}", handlers.DeleteWebhookHandler).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", handlers.ListWebhookDeliveriesHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/webhooks/deliveries/{id:[0-9]+}/replay", handlers.ReplayWebhookDeliveryHandler).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/users/{username}/force-password-reset", handlers.ForcePasswordResetHandler).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/audit", handlers.AdminAuditHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/audit/summary", handlers.AdminAuditSummaryHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/audit/verify", handlers.AdminAuditVerifyHandler).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/audit/export", handlers.AdminAuditExportHandler).Methods(http.MethodGet)
+
 	// Swagger Docs endpoint
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.PathPrefix("/swagger/").Handler(middleware.DocsCSP(httpSwagger.WrapHandler))
 
 	// Log all registered routes (handle error)
 	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		path, _ := route.GetPathTemplate()
 		methods, _ := route.GetMethods()
-		fmt.Printf("📡 Registered route: %s [%v]\n", path, methods)
+		slog.Debug("📡 Registered route", "path", path, "methods", methods)
 		return nil
 	})
 	if err != nil {
@@ -91,3 +121,17 @@ func SetupRoutes() *mux.Router {
 
 	return r
 }
+
+// SetupInternalRoutes serves the internal listener: Prometheus metrics and the probes,
+// without the unlock guard /api/v1/metrics sits behind. Bind it to a private address only.
+func SetupInternalRoutes() *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(handlers.RouteNotFoundHandler)
+	r.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowedHandler)
+
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	r.HandleFunc("/healthz", handlers.HealthzHandler).Methods(http.MethodGet)
+	r.HandleFunc("/livez", handlers.LivezHandler).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", handlers.ReadyzDetailHandler).Methods(http.MethodGet)
+	return r
+}
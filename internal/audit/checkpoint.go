@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/scheduler"
+)
+
+var (
+	checkpointKey      []byte
+	checkpointInterval = time.Hour
+	checkpointMu       sync.Mutex
+)
+
+// Init loads the checkpoint signing key and interval
+func Init(cfg *config.Config) {
+	checkpointKey = []byte(cfg.AuditCheckpointKey)
+	if cfg.AuditCheckpointInterval > 0 {
+		checkpointInterval = cfg.AuditCheckpointInterval
+	}
+}
+
+// ScheduleCheckpoints registers the job that signs the chain head on every interval
+func ScheduleCheckpoints() {
+	if len(checkpointKey) == 0 {
+		log.Println("⚠️ PEITHO_AUDIT_CHECKPOINT_KEY not set — audit checkpoints disabled, chain hashes only.")
+		return
+	}
+	scheduler.Every("audit_checkpoint", checkpointInterval, func(ctx context.Context) error {
+		_, err := Checkpoint(ctx)
+		return err
+	})
+}
+
+// Checkpoint signs the current chain head. It returns nil when the log is empty
+// or nothing was appended since the previous checkpoint.
+func Checkpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	id, hash, err := sqlite.LatestAuditLink(ctx)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	last, err := sqlite.LatestAuditCheckpoint(ctx, models.CheckpointHead)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.LastEventID == id {
+		return nil, nil
+	}
+	return writeCheckpoint(ctx, models.CheckpointHead, id, hash)
+}
+
+// FinalCheckpoint signs the chain head at shutdown, so rows written since the last scheduled
+// checkpoint, including those audited while the event bus drains, are covered before the
+// database closes. It does nothing without a signing key.
+func FinalCheckpoint(ctx context.Context) error {
+	if len(checkpointKey) == 0 {
+		return nil
+	}
+	_, err := Checkpoint(ctx)
+	return err
+}
+
+// PruneThrough deletes audit rows with id <= upTo after recording a signed prune anchor,
+// so the oldest remaining row still verifies against the chain.
+func PruneThrough(ctx context.Context, upTo int64) (int64, error) {
+	hash, found, err := sqlite.AuditHashAt(ctx, upTo)
+	if err != nil || !found {
+		return 0, err
+	}
+	if _, err := writeCheckpoint(ctx, models.CheckpointPrune, upTo, hash); err != nil {
+		return 0, err
+	}
+	return sqlite.DeleteRowsUpTo(ctx, "audit_events", "id", upTo)
+}
+
+// writeCheckpoint signs and stores a checkpoint chained to the newest one of either kind.
+// The scheduler and retention may both write, so the read and insert are serialised.
+func writeCheckpoint(ctx context.Context, kind string, id int64, hash string) (*models.AuditCheckpoint, error) {
+	checkpointMu.Lock()
+	defer checkpointMu.Unlock()
+
+	prev, err := sqlite.LatestAuditCheckpoint(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	cp := &models.AuditCheckpoint{
+		Kind:        kind,
+		LastEventID: id,
+		LastHash:    hash,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	if prev != nil {
+		cp.PrevSignature = prev.Signature
+	}
+	cp.Signature = sign(*cp)
+	if err := sqlite.InsertAuditCheckpoint(ctx, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// sign is HMAC-SHA256 over "<last_event_id>|<last_hash>|<unix seconds>", with "|prune" appended for
+// prune anchors and "|<prev_signature>" for chained checkpoints. Checkpoints from before chaining
+// have no previous signature and keep verifying.
+func sign(cp models.AuditCheckpoint) string {
+	payload := strconv.FormatInt(cp.LastEventID, 10) + "|" + cp.LastHash + "|" + strconv.FormatInt(cp.CreatedAt.Unix(), 10)
+	if cp.Kind == models.CheckpointPrune {
+		payload += "|" + models.CheckpointPrune
+	}
+	if cp.PrevSignature != "" {
+		payload += "|" + cp.PrevSignature
+	}
+	h := hmac.New(sha256.New, checkpointKey)
+	h.Write([]byte(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
+)
+
+// Entry is what a handler knows about an auth lifecycle event; Record adds the request context
+type Entry struct {
+	Username string
+	Subject  string // Keycloak user id; taken from the request's JWT claims when they belong to Username
+	Event    string
+	Outcome  string // models.OutcomeSuccess or models.OutcomeFailure
+	Reason   string
+}
+
+// Success is shorthand for a successful entry
+func Success(username, subject, event string) Entry {
+	return Entry{Username: username, Subject: subject, Event: event, Outcome: models.OutcomeSuccess}
+}
+
+// Failure is shorthand for a failed entry with a machine-readable reason
+func Failure(username, event, reason string) Entry {
+	return Entry{Username: username, Event: event, Outcome: models.OutcomeFailure, Reason: reason}
+}
+
+// Record appends e to the audit chain with the caller's real IP, user agent and request id.
+// Audit writes never fail the request; errors are logged.
+func Record(r *http.Request, e Entry) {
+	if e.Subject == "" {
+		if caller, err := middleware.ExtractUsernameFromContext(r.Context()); err == nil && caller == e.Username {
+			e.Subject = middleware.ExtractSubjectFromContext(r.Context())
+		}
+	}
+	err := sqlite.InsertAuditEvent(r.Context(), &models.AuditEvent{
+		Username:  e.Username,
+		EventType: e.Event,
+		IPAddress: middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Outcome:   e.Outcome,
+		Reason:    e.Reason,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+		Subject:   e.Subject,
+	})
+	if err != nil {
+		log.Printf("⚠️ Audit: failed to record %s (%s) for %q: %v", e.Event, e.Outcome, e.Username, err)
+	}
+}
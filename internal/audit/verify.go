@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+// Report is the outcome of walking the audit chain
+type Report struct {
+	OK                 bool   `json:"ok" example:"false"`
+	EventsChecked      int    `json:"events_checked" example:"1042"`
+	CheckpointsChecked int    `json:"checkpoints_checked" example:"12"`
+	SignaturesVerified bool   `json:"signatures_verified" example:"true"` // false when no checkpoint key is configured
+	HeadID             int64  `json:"head_id,omitempty" example:"1042"`
+	HeadHash           string `json:"head_hash,omitempty" example:"9f86d081884c7d65..."`
+	AnchoredAfterID    int64  `json:"anchored_after_id,omitempty" example:"900"` // rows up to here were pruned by retention
+	UnsignedEvents     int    `json:"unsigned_events" example:"3"`               // rows newer than the latest signed checkpoint
+	BrokenAtID         int64  `json:"broken_at_id,omitempty" example:"311"`
+	Problem            string `json:"problem,omitempty" example:"row content does not match its stored hash"`
+}
+
+var errBroken = errors.New("audit chain broken")
+
+// Verify walks every audit row in order, recomputing each hash and its link to the previous row,
+// then checks every checkpoint against the rows it covers. It stops at the first broken link.
+// After retention pruning the walk is anchored at the latest prune checkpoint: the oldest
+// remaining row must link to the last pruned one.
+//
+// With a signing key, checkpoints must also form an unbroken signed chain whose heads never
+// move backwards. Because each row hash covers its predecessor, a head checkpoint vouches for
+// every row up to it; rows written after the newest one are counted as unsigned rather than
+// treated as broken, however long the server was down in between.
+func Verify(ctx context.Context) (*Report, error) {
+	report := &Report{SignaturesVerified: len(checkpointKey) > 0}
+
+	anchor, err := sqlite.LatestAuditCheckpoint(ctx, models.CheckpointPrune)
+	if err != nil {
+		return nil, err
+	}
+	prev := ""
+	if anchor != nil {
+		prev = anchor.LastHash
+		report.AnchoredAfterID = anchor.LastEventID
+	}
+	checkpoints, err := sqlite.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var signedThrough int64
+	for _, cp := range checkpoints {
+		signedThrough = max(signedThrough, cp.LastEventID)
+	}
+
+	err = sqlite.WalkAuditChain(ctx, func(e models.AuditEvent) error {
+		switch {
+		case anchor != nil && int64(e.ID) <= anchor.LastEventID:
+			report.Problem = "row survives below the retention prune anchor"
+		case e.PrevHash != prev:
+			report.Problem = "prev_hash does not match the preceding row (row deleted, inserted or reordered)"
+		case sqlite.AuditHash(e.PrevHash, &e) != e.Hash:
+			report.Problem = "row content does not match its stored hash"
+		}
+		if report.Problem != "" {
+			report.BrokenAtID = int64(e.ID)
+			return errBroken
+		}
+
+		report.EventsChecked++
+		if int64(e.ID) > signedThrough {
+			report.UnsignedEvents++
+		}
+		report.HeadID, report.HeadHash = int64(e.ID), e.Hash
+		prev = e.Hash
+		return nil
+	})
+	if errors.Is(err, errBroken) {
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prevSignature, chained := "", false
+	var prevHead int64
+	for _, cp := range checkpoints {
+		report.CheckpointsChecked++
+
+		if report.SignaturesVerified && !hmac.Equal([]byte(cp.Signature), []byte(sign(cp))) {
+			report.Problem = "checkpoint signature is invalid"
+			report.BrokenAtID = cp.LastEventID
+			return report, nil
+		}
+		// checkpoints written before chaining carry no previous signature; once one does,
+		// every later one must link to its predecessor
+		chained = chained || cp.PrevSignature != ""
+		if chained && cp.PrevSignature != prevSignature {
+			report.Problem = "checkpoint does not link to the previous one (checkpoint deleted or reordered)"
+			report.BrokenAtID = cp.LastEventID
+			return report, nil
+		}
+		prevSignature = cp.Signature
+		if cp.Kind == models.CheckpointHead {
+			if cp.LastEventID < prevHead {
+				report.Problem = "checkpoint covers fewer rows than an earlier one (log truncated and rewritten)"
+				report.BrokenAtID = cp.LastEventID
+				return report, nil
+			}
+			prevHead = cp.LastEventID
+		}
+		if anchor != nil && cp.LastEventID <= anchor.LastEventID {
+			continue // covered rows were pruned; the signature alone is what can be checked
+		}
+
+		hash, found, err := sqlite.AuditHashAt(ctx, cp.LastEventID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !found:
+			report.Problem = "row covered by a signed checkpoint is missing (log truncated)"
+		case hash != cp.LastHash:
+			report.Problem = "row hash differs from the signed checkpoint (chain rewritten)"
+		}
+		if report.Problem != "" {
+			report.BrokenAtID = cp.LastEventID
+			return report, nil
+		}
+	}
+
+	report.OK = true
+	return report, nil
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+// setup opens an empty database and a signing key for the rest of the test
+func setup(t *testing.T) context.Context {
+	t.Helper()
+	sqlite.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	prevKey := checkpointKey
+	checkpointKey = []byte("test-checkpoint-key")
+	t.Cleanup(func() {
+		checkpointKey = prevKey
+		_ = sqlite.Close()
+	})
+	return context.Background()
+}
+
+// appendEvents writes n audit rows created at the given time
+func appendEvents(t *testing.T, ctx context.Context, n int, at time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := sqlite.InsertAuditEvent(ctx, &models.AuditEvent{
+			Username:  "alice",
+			EventType: "login",
+			Outcome:   models.OutcomeSuccess,
+			Reason:    "r" + strconv.Itoa(i),
+			CreatedAt: at,
+		}); err != nil {
+			t.Fatalf("InsertAuditEvent: %v", err)
+		}
+	}
+}
+
+func checkpoint(t *testing.T, ctx context.Context) {
+	t.Helper()
+	if _, err := Checkpoint(ctx); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+}
+
+func exec(t *testing.T, query string, args ...any) {
+	t.Helper()
+	if _, err := sqlite.GetDB().Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name         string
+		build        func(t *testing.T, ctx context.Context)
+		wantOK       bool
+		wantBrokenAt int64
+		wantProblem  string
+		wantUnsigned int
+		wantAnchor   int64
+	}{
+		{
+			name: "intact chain",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 3, now)
+				checkpoint(t, ctx)
+				appendEvents(t, ctx, 2, now)
+				checkpoint(t, ctx)
+			},
+			wantOK: true,
+		},
+		{
+			name: "rows after the last checkpoint are unsigned, not broken",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 2, now)
+				checkpoint(t, ctx)
+				appendEvents(t, ctx, 3, now)
+			},
+			wantOK: true, wantUnsigned: 3,
+		},
+		{
+			name: "restart gap longer than the checkpoint interval",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 2, now.Add(-30*24*time.Hour))
+				checkpoint(t, ctx)
+				appendEvents(t, ctx, 2, now.Add(-10*checkpointInterval))
+				// the server was down for days; the first checkpoint after restart covers them
+				checkpoint(t, ctx)
+			},
+			wantOK: true,
+		},
+		{
+			name: "edited row",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 4, now)
+				checkpoint(t, ctx)
+				exec(t, `UPDATE audit_events SET username = 'mallory' WHERE id = 2`)
+			},
+			wantBrokenAt: 2, wantProblem: "row content does not match its stored hash",
+		},
+		{
+			name: "deleted row",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 4, now)
+				checkpoint(t, ctx)
+				exec(t, `DELETE FROM audit_events WHERE id = 2`)
+			},
+			wantBrokenAt: 3, wantProblem: "prev_hash does not match the preceding row (row deleted, inserted or reordered)",
+		},
+		{
+			name: "deleted tail row covered by a checkpoint",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 4, now)
+				checkpoint(t, ctx)
+				exec(t, `DELETE FROM audit_events WHERE id = 4`)
+			},
+			wantBrokenAt: 4, wantProblem: "row covered by a signed checkpoint is missing (log truncated)",
+		},
+		{
+			name: "deleted checkpoint",
+			build: func(t *testing.T, ctx context.Context) {
+				for i := 0; i < 3; i++ {
+					appendEvents(t, ctx, 2, now)
+					checkpoint(t, ctx)
+				}
+				exec(t, `DELETE FROM audit_checkpoints WHERE last_event_id = 4`)
+			},
+			wantBrokenAt: 6, wantProblem: "checkpoint does not link to the previous one (checkpoint deleted or reordered)",
+		},
+		{
+			name: "forged checkpoint",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 2, now)
+				checkpoint(t, ctx)
+				exec(t, `UPDATE audit_checkpoints SET last_event_id = 1`)
+			},
+			wantBrokenAt: 1, wantProblem: "checkpoint signature is invalid",
+		},
+		{
+			name: "prune anchor",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 5, now)
+				checkpoint(t, ctx)
+				if _, err := PruneThrough(ctx, 3); err != nil {
+					t.Fatalf("PruneThrough: %v", err)
+				}
+				appendEvents(t, ctx, 1, now)
+				checkpoint(t, ctx)
+			},
+			wantOK: true, wantAnchor: 3,
+		},
+		{
+			name: "row restored below the prune anchor",
+			build: func(t *testing.T, ctx context.Context) {
+				appendEvents(t, ctx, 5, now)
+				if _, err := PruneThrough(ctx, 3); err != nil {
+					t.Fatalf("PruneThrough: %v", err)
+				}
+				exec(t, `INSERT INTO audit_events (id, username, event_type, prev_hash, hash, created_at)
+					SELECT 1, username, event_type, prev_hash, hash, created_at FROM audit_events WHERE id = 4`)
+			},
+			wantBrokenAt: 1, wantProblem: "row survives below the retention prune anchor", wantAnchor: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := setup(t)
+			tt.build(t, ctx)
+
+			report, err := Verify(ctx)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if report.OK != tt.wantOK || report.BrokenAtID != tt.wantBrokenAt || report.Problem != tt.wantProblem {
+				t.Fatalf("report = ok %v, broken at %d (%q); want ok %v, broken at %d (%q)",
+					report.OK, report.BrokenAtID, report.Problem, tt.wantOK, tt.wantBrokenAt, tt.wantProblem)
+			}
+			if report.OK && report.UnsignedEvents != tt.wantUnsigned {
+				t.Fatalf("unsigned events = %d, want %d", report.UnsignedEvents, tt.wantUnsigned)
+			}
+			if report.AnchoredAfterID != tt.wantAnchor {
+				t.Fatalf("anchored after %d, want %d", report.AnchoredAfterID, tt.wantAnchor)
+			}
+		})
+	}
+}
+
+func TestFinalCheckpoint(t *testing.T) {
+	ctx := setup(t)
+	appendEvents(t, ctx, 2, time.Now())
+	if err := FinalCheckpoint(ctx); err != nil {
+		t.Fatalf("FinalCheckpoint: %v", err)
+	}
+	report, err := Verify(ctx)
+	if err != nil || !report.OK || report.UnsignedEvents != 0 || report.CheckpointsChecked != 1 {
+		t.Fatalf("after final checkpoint: %+v, %v", report, err)
+	}
+
+	checkpointKey = nil
+	appendEvents(t, ctx, 1, time.Now())
+	if err := FinalCheckpoint(ctx); err != nil {
+		t.Fatalf("FinalCheckpoint without key: %v", err)
+	}
+	if cps, _ := sqlite.ListAuditCheckpoints(ctx); len(cps) != 1 {
+		t.Fatalf("checkpoint written without a signing key: %d checkpoints", len(cps))
+	}
+}
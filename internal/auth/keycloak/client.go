@@ -30,8 +30,8 @@ func NewOIDCClient(cfg *config.Config) (*OIDCClient, error) {
 }
 
 // ResetPassword sets or resets a user's password in Keycloak
-func ResetPassword(cfg *config.Config, username, newPassword string) error {
-	userID, err := GetUserIDByUsername(cfg, username)
+func ResetPassword(ctx context.Context, cfg *config.Config, username, newPassword string) error {
+	userID, err := GetUserIDByUsername(ctx, cfg, username)
 	if err != nil {
 		return fmt.Errorf("cannot find user ID: %v", err)
 	}
@@ -42,20 +42,20 @@ func ResetPassword(cfg *config.Config, username, newPassword string) error {
 		"temporary": false,
 	}
 
-	return sendAdminRequest(cfg, "PUT", "/admin/realms/peitho/users/"+userID+"/reset-password", payload)
+	return sendAdminRequest(ctx, cfg, "reset_password", "PUT", "/admin/realms/peitho/users/"+userID+"/reset-password", payload)
 }
 
 // Optional: alias for clarity in Option B
-func SetPassword(cfg *config.Config, username, newPassword string) error {
-	return ResetPassword(cfg, username, newPassword)
+func SetPassword(ctx context.Context, cfg *config.Config, username, newPassword string) error {
+	return ResetPassword(ctx, cfg, username, newPassword)
 }
 
 // GetUserIDByUsername fetches the Keycloak user ID from the username
-func GetUserIDByUsername(cfg *config.Config, username string) (string, error) {
+func GetUserIDByUsername(ctx context.Context, cfg *config.Config, username string) (string, error) {
 	var users []struct {
 		ID string `json:"id"`
 	}
-	err := sendAdminRequest(cfg, "GET", "/admin/realms/peitho/users?username="+username, nil, &users)
+	err := sendAdminRequest(ctx, cfg, "find_user", "GET", "/admin/realms/peitho/users?username="+username, nil, &users)
 	if err != nil {
 		return "", err
 	}
@@ -75,18 +75,19 @@ type KeycloakClient struct {
 }
 
 // GetRealmClients fetches all registered clients in the realm
-func GetRealmClients(cfg *config.Config) ([]KeycloakClient, error) {
+func GetRealmClients(ctx context.Context, cfg *config.Config) ([]KeycloakClient, error) {
 	var clients []KeycloakClient
-	err := sendAdminRequest(cfg, "GET", "/admin/realms/peitho/clients", nil, &clients)
+	err := sendAdminRequest(ctx, cfg, "list_clients", "GET", "/admin/realms/peitho/clients", nil, &clients)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get clients: %w", err)
 	}
 	return clients, nil
 }
 
-// sendAdminRequest sends a Keycloak admin API request and optionally parses response
-func sendAdminRequest(cfg *config.Config, method, path string, body interface{}, result ...interface{}) error {
-	token, err := fetchAdminToken(cfg)
+// sendAdminRequest sends a Keycloak admin API request, timed as operation op, and optionally
+// parses the response
+func sendAdminRequest(ctx context.Context, cfg *config.Config, op, method, path string, body interface{}, result ...interface{}) error {
+	token, err := fetchAdminToken(ctx, cfg)
 	if err != nil {
 		return err
 	}
@@ -99,14 +100,14 @@ func sendAdminRequest(cfg *config.Config, method, path string, body interface{},
 		}
 	}
 
-	req, err := http.NewRequest(method, cfg.KeycloakInternalURL+path, bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, method, cfg.KeycloakInternalURL+path, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := do(op, req)
 	if err != nil {
 		return err
 	}
@@ -124,19 +125,19 @@ func sendAdminRequest(cfg *config.Config, method, path string, body interface{},
 }
 
 // fetchAdminToken gets a short-lived access token using admin credentials
-func fetchAdminToken(cfg *config.Config) (string, error) {
+func fetchAdminToken(ctx context.Context, cfg *config.Config) (string, error) {
 	data := "grant_type=password" +
 		"&client_id=admin-cli" +
 		"&username=" + cfg.KeycloakAdmin +
 		"&password=" + cfg.KeycloakAdminPassword
 
-	req, err := http.NewRequest("POST", cfg.KeycloakInternalURL+"/realms/master/protocol/openid-connect/token", bytes.NewBufferString(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.KeycloakInternalURL+"/realms/master/protocol/openid-connect/token", bytes.NewBufferString(data))
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := do("admin_token", req)
 	if err != nil {
 		return "", err
 	}
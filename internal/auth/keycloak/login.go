@@ -2,14 +2,20 @@ package keycloak
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
 )
 
 type TokenResponse struct {
@@ -21,9 +27,27 @@ type TokenResponse struct {
 
 var client = &http.Client{}
 
+// do sends req in a client span named after operation op, passing the trace on to Keycloak,
+// and records its latency under op
+func do(op string, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.StartClient(req.Context(), "keycloak "+op, req)
+	req = req.WithContext(ctx)
+	tracing.Inject(ctx, req.Header)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	metrics.ObserveKeycloak(op, start, status)
+	tracing.EndClient(span, resp, err)
+	return resp, err
+}
+
 // RegisterNewUserWithEmail creates a new Keycloak user and optionally sets their password
-func RegisterNewUserWithEmail(cfg *config.Config, username, password, email string) error {
-	token, err := getAdminToken(cfg)
+func RegisterNewUserWithEmail(ctx context.Context, cfg *config.Config, username, password, email string) error {
+	token, err := getAdminToken(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to get admin token: %w", err)
 	}
@@ -37,14 +61,14 @@ func RegisterNewUserWithEmail(cfg *config.Config, username, password, email stri
 	}
 
 	bodyBytes, _ := json.Marshal(user)
-	req, err := http.NewRequest("POST", cfg.KeycloakInternalURL+"/admin/realms/peitho/users", bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.KeycloakInternalURL+"/admin/realms/peitho/users", bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := do("create_user", req)
 	if err != nil {
 		return err
 	}
@@ -55,31 +79,31 @@ func RegisterNewUserWithEmail(cfg *config.Config, username, password, email stri
 		return fmt.Errorf("failed to create user: %s", string(body))
 	}
 
-	userID, err := findUserIDByUsername(cfg, token, username)
+	userID, err := findUserIDByUsername(ctx, cfg, token, username)
 	if err != nil {
 		return fmt.Errorf("failed to find created user: %w", err)
 	}
 
 	// ✅ Skip setting password if empty (for deferred flow)
 	if password != "" {
-		if err := resetUserPassword(cfg, token, userID, password); err != nil {
+		if err := resetUserPassword(ctx, cfg, token, userID, password); err != nil {
 			return fmt.Errorf("user created but failed to set password: %w", err)
 		}
 	} else {
-		fmt.Printf("🔒 Skipping password setup for user %s — will be set later.\n", username)
+		slog.Info("🔒 Skipping password setup until the user sets one", "username", username)
 	}
 
 	return nil
 }
 
 // UserExists checks if a user already exists in Keycloak
-func UserExists(cfg *config.Config, username string) (bool, error) {
-	token, err := getAdminToken(cfg)
+func UserExists(ctx context.Context, cfg *config.Config, username string) (bool, error) {
+	token, err := getAdminToken(ctx, cfg)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = findUserIDByUsername(cfg, token, username)
+	_, err = findUserIDByUsername(ctx, cfg, token, username)
 	if err != nil {
 		if err.Error() == "user not found" {
 			return false, nil
@@ -90,7 +114,7 @@ func UserExists(cfg *config.Config, username string) (bool, error) {
 }
 
 // LoginWithPassword authenticates user
-func LoginWithPassword(cfg *config.Config, username, password string) (*TokenResponse, error) {
+func LoginWithPassword(ctx context.Context, cfg *config.Config, username, password string) (*TokenResponse, error) {
 	data := url.Values{}
 	data.Set("grant_type", "password")
 	data.Set("client_id", cfg.KeycloakClientID)
@@ -98,13 +122,13 @@ func LoginWithPassword(cfg *config.Config, username, password string) (*TokenRes
 	data.Set("username", username)
 	data.Set("password", password)
 
-	req, err := http.NewRequest("POST", cfg.KeycloakURL, bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.KeycloakURL, bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create login request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := client.Do(req)
+	resp, err := do("login", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send login request: %w", err)
 	}
@@ -124,25 +148,31 @@ func LoginWithPassword(cfg *config.Config, username, password string) (*TokenRes
 }
 
 // RefreshWithToken refreshes access token
-func RefreshWithToken(cfg *config.Config, refreshToken string) (map[string]interface{}, error) {
+func RefreshWithToken(ctx context.Context, cfg *config.Config, refreshToken string) (map[string]interface{}, error) {
 	form := url.Values{}
 	form.Set("grant_type", "refresh_token")
 	form.Set("refresh_token", refreshToken)
 	form.Set("client_id", cfg.KeycloakClientID)
 	form.Set("client_secret", cfg.KeycloakClientSecret)
 
-	return sendTokenRequest(cfg, form)
+	return sendTokenRequest(ctx, cfg, form)
 }
 
 // RevokeRefreshToken invalidates refresh token
-func RevokeRefreshToken(cfg *config.Config, refreshToken string) error {
+func RevokeRefreshToken(ctx context.Context, cfg *config.Config, refreshToken string) error {
 	form := url.Values{}
 	form.Set("client_id", cfg.KeycloakClientID)
 	form.Set("client_secret", cfg.KeycloakClientSecret)
 	form.Set("refresh_token", refreshToken)
 
 	logoutURL := cfg.KeycloakIssuerURL + "/protocol/openid-connect/logout"
-	resp, err := client.PostForm(logoutURL, form)
+	req, err := http.NewRequestWithContext(ctx, "POST", logoutURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := do("revoke", req)
 	if err != nil {
 		return err
 	}
@@ -158,14 +188,14 @@ func RevokeRefreshToken(cfg *config.Config, refreshToken string) error {
 
 // Helpers
 
-func sendTokenRequest(cfg *config.Config, form url.Values) (map[string]interface{}, error) {
-	req, err := http.NewRequest("POST", cfg.KeycloakURL, bytes.NewBufferString(form.Encode()))
+func sendTokenRequest(ctx context.Context, cfg *config.Config, form url.Values) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.KeycloakURL, bytes.NewBufferString(form.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := client.Do(req)
+	resp, err := do("refresh", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send token request: %w", err)
 	}
@@ -184,20 +214,20 @@ func sendTokenRequest(cfg *config.Config, form url.Values) (map[string]interface
 	return respData, nil
 }
 
-func getAdminToken(cfg *config.Config) (string, error) {
+func getAdminToken(ctx context.Context, cfg *config.Config) (string, error) {
 	data := url.Values{}
 	data.Set("grant_type", "password")
 	data.Set("client_id", "admin-cli")
 	data.Set("username", cfg.KeycloakAdmin)
 	data.Set("password", cfg.KeycloakAdminPassword)
 
-	req, err := http.NewRequest("POST", cfg.KeycloakInternalURL+"/realms/master/protocol/openid-connect/token", bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.KeycloakInternalURL+"/realms/master/protocol/openid-connect/token", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("failed to create admin login request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := client.Do(req)
+	resp, err := do("admin_token", req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send admin login request: %w", err)
 	}
@@ -218,14 +248,14 @@ func getAdminToken(cfg *config.Config) (string, error) {
 	return tokenResp.AccessToken, nil
 }
 
-func findUserIDByUsername(cfg *config.Config, token, username string) (string, error) {
-	req, err := http.NewRequest("GET", cfg.KeycloakInternalURL+"/admin/realms/peitho/users?username="+url.QueryEscape(username), nil)
+func findUserIDByUsername(ctx context.Context, cfg *config.Config, token, username string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", cfg.KeycloakInternalURL+"/admin/realms/peitho/users?username="+url.QueryEscape(username), nil)
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := client.Do(req)
+	resp, err := do("find_user", req)
 	if err != nil {
 		return "", err
 	}
@@ -250,7 +280,7 @@ func findUserIDByUsername(cfg *config.Config, token, username string) (string, e
 	return users[0].ID, nil
 }
 
-func resetUserPassword(cfg *config.Config, token, userID, newPassword string) error {
+func resetUserPassword(ctx context.Context, cfg *config.Config, token, userID, newPassword string) error {
 	payload := map[string]interface{}{
 		"type":      "password",
 		"value":     newPassword,
@@ -258,14 +288,14 @@ func resetUserPassword(cfg *config.Config, token, userID, newPassword string) er
 	}
 	bodyBytes, _ := json.Marshal(payload)
 
-	req, err := http.NewRequest("PUT", cfg.KeycloakInternalURL+"/admin/realms/peitho/users/"+userID+"/reset-password", bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "PUT", cfg.KeycloakInternalURL+"/admin/realms/peitho/users/"+userID+"/reset-password", bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := do("reset_password", req)
 	if err != nil {
 		return err
 	}
@@ -280,24 +310,24 @@ func resetUserPassword(cfg *config.Config, token, userID, newPassword string) er
 }
 
 // DeleteUser removes a Keycloak user by username
-func DeleteUser(cfg *config.Config, username string) error {
-	token, err := getAdminToken(cfg)
+func DeleteUser(ctx context.Context, cfg *config.Config, username string) error {
+	token, err := getAdminToken(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to get admin token: %w", err)
 	}
 
-	userID, err := findUserIDByUsername(cfg, token, username)
+	userID, err := findUserIDByUsername(ctx, cfg, token, username)
 	if err != nil {
 		return fmt.Errorf("failed to find user ID: %w", err)
 	}
 
-	req, err := http.NewRequest("DELETE", cfg.KeycloakInternalURL+"/admin/realms/peitho/users/"+userID, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", cfg.KeycloakInternalURL+"/admin/realms/peitho/users/"+userID, nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := client.Do(req)
+	resp, err := do("delete_user", req)
 	if err != nil {
 		return err
 	}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	"github.com/peithosecure/peitho-backend/internal/config"
 )
 
@@ -20,20 +21,20 @@ func AuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				problem.Respond(w, r, "missing_auth_header", http.StatusUnauthorized)
 				return
 			}
 
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+				problem.Respond(w, r, "invalid_auth_format", http.StatusUnauthorized)
 				return
 			}
 
 			token := parts[1]
 			claims, err := VerifyIDToken(cfg, token)
 			if err != nil {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				problem.Respond(w, r, "invalid_token", http.StatusUnauthorized)
 				return
 			}
 
@@ -0,0 +1,85 @@
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"github.com/peithosecure/peitho-backend/internal/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestKeycloakSpans(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(ctx context.Context, cfg *config.Config) error
+		status    int
+		wantSpan  string
+		wantPath  string
+		wantError bool
+	}{
+		{
+			name: "refresh", status: http.StatusOK, wantSpan: "keycloak refresh", wantPath: "/token",
+			call: func(ctx context.Context, cfg *config.Config) error {
+				_, err := RefreshWithToken(ctx, cfg, "rt")
+				return err
+			},
+		},
+		{
+			name: "revoke", status: http.StatusNoContent, wantSpan: "keycloak revoke", wantPath: "/protocol/openid-connect/logout",
+			call: func(ctx context.Context, cfg *config.Config) error { return RevokeRefreshToken(ctx, cfg, "rt") },
+		},
+		{
+			name: "keycloak outage fails the span", status: http.StatusServiceUnavailable, wantSpan: "keycloak revoke", wantPath: "/protocol/openid-connect/logout", wantError: true,
+			call: func(ctx context.Context, cfg *config.Config) error { return RevokeRefreshToken(ctx, cfg, "rt") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracingtest.RecordSpans(t)
+			var traceparent, path string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent, path = r.Header.Get("traceparent"), r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"access_token":"at"}`))
+				}
+			}))
+			defer srv.Close()
+			cfg := &config.Config{KeycloakURL: srv.URL + "/token", KeycloakIssuerURL: srv.URL, KeycloakClientID: "peitho"}
+
+			ctx, parent := tracing.Start(context.Background(), "POST /api/v1/auth/refresh", trace.WithSpanKind(trace.SpanKindServer))
+			err := tt.call(ctx, cfg)
+			parent.End()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("call error = %v, want error %v", err, tt.wantError)
+			}
+
+			spans := exp.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want the Keycloak call and its parent", len(spans))
+			}
+			span := spans[0]
+			if span.Name != tt.wantSpan || span.SpanKind != trace.SpanKindClient {
+				t.Fatalf("span = %q (%v), want %q (client)", span.Name, span.SpanKind, tt.wantSpan)
+			}
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Fatal("Keycloak span is not a child of the request span")
+			}
+			if path != tt.wantPath {
+				t.Fatalf("Keycloak saw path %q, want %q", path, tt.wantPath)
+			}
+			if want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"; traceparent != want {
+				t.Fatalf("traceparent = %q, want %q", traceparent, want)
+			}
+			if (span.Status.Code == codes.Error) != tt.wantError {
+				t.Fatalf("span status = %v, want error %v", span.Status.Code, tt.wantError)
+			}
+		})
+	}
+}
@@ -2,9 +2,16 @@ package peitho
 
 import (
 	"log"
+
+	"github.com/peithosecure/peitho-backend/internal/events"
 )
 
 // TriggerEventHook is a public-stubbed event monitor (real roast hooks private)
 func TriggerEventHook(userID string, reason string) {
 	log.Printf("[EventMonitor] Activity detected for user %s: %s", userID, reason)
+	events.Publish(events.Event{
+		Type:     events.UserActivity,
+		Username: userID,
+		Reason:   reason,
+	})
 }
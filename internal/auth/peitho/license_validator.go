@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -25,25 +26,19 @@ func init() {
 	}
 }
 
-// ValidateLicenseToken performs a local unlock.lic validation check,
-// binding the engine hash and enforcing license integrity.
-func ValidateLicenseToken(_ string) error {
-	// ✅ Skip if already marked validated
-	if os.Getenv("PEITHO_LICENSE_HASH_OK") == "true" {
-		fmt.Println("🔁 License already validated — skipping revalidation")
-		return nil
-	}
-
-	const unlockPath = "./peitho-core/unlock.lic"
-
-	raw, err := os.ReadFile(unlockPath)
+// CheckLicenseFile reads an unlock.lic file and verifies its signature, returning the
+// decoded payload. Unlike ValidateLicenseToken it has no side effects, so the readiness
+// probe can call it repeatedly.
+func CheckLicenseFile(path string) (LicensePayload, error) {
+	var lic LicensePayload
+	raw, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("🔒 unlock.lic missing: %w", err)
+		return lic, fmt.Errorf("🔒 unlock.lic missing: %w", err)
 	}
 
 	parts := strings.SplitN(string(raw), "||", 2)
 	if len(parts) != 2 {
-		return errors.New("🧨 invalid unlock.lic structure (missing || delimiter)")
+		return lic, errors.New("🧨 invalid unlock.lic structure (missing || delimiter)")
 	}
 
 	sigB64 := strings.TrimSpace(parts[0])
@@ -51,13 +46,29 @@ func ValidateLicenseToken(_ string) error {
 
 	// ✅ Verify signature
 	if err := corestub.ValidateLicenseSignature(sigB64, payload); err != nil {
-		return fmt.Errorf("🛑 license verification failed: %w", err)
+		return lic, fmt.Errorf("🛑 license verification failed: %w", err)
 	}
 
 	// ✅ Decode payload
-	var lic LicensePayload
 	if err := json.Unmarshal(payload, &lic); err != nil {
-		return errors.New("❌ failed to parse license payload JSON")
+		return lic, errors.New("❌ failed to parse license payload JSON")
+	}
+	return lic, nil
+}
+
+// ValidateLicenseToken performs a local unlock.lic validation check of the file at
+// unlockPath, binding the engine hash and enforcing license integrity. Unless
+// allowMultiDevice is set, the license must be bound to deviceID.
+func ValidateLicenseToken(unlockPath, deviceID string, allowMultiDevice bool) error {
+	// ✅ Skip if already marked validated
+	if os.Getenv("PEITHO_LICENSE_HASH_OK") == "true" {
+		slog.Info("🔁 License already validated — skipping revalidation")
+		return nil
+	}
+
+	lic, err := CheckLicenseFile(unlockPath)
+	if err != nil {
+		return err
 	}
 
 	// 🔐 Assign hash for later runtime comparison
@@ -72,23 +83,16 @@ func ValidateLicenseToken(_ string) error {
 	}
 
 	// ✅ Valid license — print diagnostics
-	fmt.Println("🔓 License validated successfully.")
-	fmt.Println("📧 Email         :", lic.Email)
-	fmt.Println("🖥️  Licensed For :", lic.DeviceID)
-	fmt.Println("🔐 Branding Lock :", lic.BrandingRequired)
+	slog.Info("🔓 License validated successfully",
+		"email", lic.Email, "device_id", lic.DeviceID, "branding_required", lic.BrandingRequired)
 
-	if os.Getenv("PEITHO_ALLOW_MULTI_DEVICE") == "true" {
-		fmt.Println("⚠️  Device binding check is DISABLED (multi-device mode).")
+	if allowMultiDevice {
+		slog.Warn("⚠️ Device binding check is DISABLED (multi-device mode)")
 		return nil
 	}
 
-	actualDeviceID := os.Getenv("PEITHO_DEVICE_ID")
-	if actualDeviceID == "" {
-		actualDeviceID = "web-default"
-	}
-
-	if lic.DeviceID != actualDeviceID {
-		return fmt.Errorf("🚫 device mismatch: license bound to '%s', current is '%s'", lic.DeviceID, actualDeviceID)
+	if lic.DeviceID != deviceID {
+		return fmt.Errorf("🚫 device mismatch: license bound to '%s', current is '%s'", lic.DeviceID, deviceID)
 	}
 
 	return nil
@@ -0,0 +1,211 @@
+// Package breach looks passwords up in a local copy of a breached-password corpus, for
+// deployments that cannot call external breach APIs.
+//
+// The corpus is an index built once from a Have I Been Pwned SHA-1 dump ("ordered by hash",
+// lines of HASH:COUNT). It stores the leading bytes of each hash as fixed-size records in
+// ascending order, so a lookup is a binary search of about thirty reads regardless of size.
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	magic      = "PTBRIDX1"
+	headerSize = 24 // magic, record size, 7 bytes padding, record count
+
+	// DefaultPrefixBytes keeps 80 bits of each hash: a tenth of the full dump's size, with
+	// a false-positive chance around one in 10^15 per lookup.
+	DefaultPrefixBytes = 10
+)
+
+// ErrUnsorted is returned by Build when the dump is not ordered by hash
+var ErrUnsorted = errors.New("breach dump must be ordered by hash")
+
+// Index is an opened corpus. It is safe for concurrent use.
+type Index struct {
+	f       *os.File
+	recSize int
+	count   int64
+}
+
+// Open validates the header of the index at path and keeps it open for lookups
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("breach index %s: %w", path, err)
+	}
+	if string(hdr[:len(magic)]) != magic {
+		f.Close()
+		return nil, fmt.Errorf("breach index %s: not a breach index (rebuild with peithoctl breach index)", path)
+	}
+	recSize, count := int64(hdr[8]), binary.BigEndian.Uint64(hdr[16:])
+	if recSize < 4 || recSize > sha1.Size {
+		f.Close()
+		return nil, fmt.Errorf("breach index %s: invalid record size %d", path, recSize)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Compared as a record count rather than a byte size, which a corrupt count could overflow
+	body := info.Size() - headerSize
+	if body%recSize != 0 || uint64(body/recSize) != count {
+		f.Close()
+		return nil, fmt.Errorf("breach index %s: %d bytes of records, header promises %d records of %d bytes (truncated build?)",
+			path, body, count, recSize)
+	}
+	return &Index{f: f, recSize: int(recSize), count: int64(count)}, nil
+}
+
+// Len is the number of hashes in the index
+func (ix *Index) Len() int64 { return ix.count }
+
+// Close releases the index file
+func (ix *Index) Close() error { return ix.f.Close() }
+
+// Contains reports whether password's SHA-1 appears in the corpus
+func (ix *Index) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	return ix.ContainsHash(sum)
+}
+
+// ContainsHash reports whether a SHA-1 digest appears in the corpus
+func (ix *Index) ContainsHash(sum [sha1.Size]byte) (bool, error) {
+	key := sum[:ix.recSize]
+	rec := make([]byte, ix.recSize)
+
+	lo, hi := int64(0), ix.count
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := ix.f.ReadAt(rec, headerSize+mid*int64(ix.recSize)); err != nil {
+			return false, fmt.Errorf("breach index read: %w", err)
+		}
+		switch bytes.Compare(rec, key) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// BuildOptions tunes Build
+type BuildOptions struct {
+	PrefixBytes int // bytes of each hash to keep, 4-20; DefaultPrefixBytes when zero
+	MinCount    int // skip hashes seen fewer times than this in breaches; 0 keeps all
+}
+
+// BuildStats summarises a Build
+type BuildStats struct {
+	Lines      int64 `json:"lines"`
+	Written    int64 `json:"written"`
+	BelowMin   int64 `json:"below_min_count"`
+	Duplicates int64 `json:"duplicates"`
+}
+
+// Build reads a HIBP SHA-1 dump from r and writes an index to path. The index is written
+// to a temporary file and renamed into place, so a running server never sees a partial one.
+func Build(r io.Reader, path string, opts BuildOptions) (BuildStats, error) {
+	var stats BuildStats
+	if opts.PrefixBytes == 0 {
+		opts.PrefixBytes = DefaultPrefixBytes
+	}
+	if opts.PrefixBytes < 4 || opts.PrefixBytes > sha1.Size {
+		return stats, fmt.Errorf("prefix bytes must be between 4 and %d, got %d", sha1.Size, opts.PrefixBytes)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return stats, err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	var hdr [headerSize]byte
+	copy(hdr[:], magic)
+	hdr[8] = byte(opts.PrefixBytes)
+	if _, err := f.Write(hdr[:]); err != nil {
+		return stats, err
+	}
+
+	out := bufio.NewWriterSize(f, 1<<20)
+	in := bufio.NewScanner(r)
+	var prev, last, sum [sha1.Size]byte
+	for in.Scan() {
+		stats.Lines++
+		line := strings.TrimSpace(in.Text())
+		if line == "" {
+			continue
+		}
+
+		hexHash, countStr, hasCount := strings.Cut(line, ":")
+		if len(hexHash) != 2*sha1.Size {
+			return stats, fmt.Errorf("line %d: expected a 40 character SHA-1 hash", stats.Lines)
+		}
+		if _, err := hex.Decode(sum[:], []byte(hexHash)); err != nil {
+			return stats, fmt.Errorf("line %d: %w", stats.Lines, err)
+		}
+		if bytes.Compare(sum[:], prev[:]) < 0 {
+			return stats, fmt.Errorf("line %d: %w", stats.Lines, ErrUnsorted)
+		}
+		prev = sum
+
+		if hasCount && opts.MinCount > 0 {
+			if n, err := strconv.Atoi(countStr); err == nil && n < opts.MinCount {
+				stats.BelowMin++
+				continue
+			}
+		}
+		// Hashes that share the kept prefix collapse into one record
+		if stats.Written > 0 && bytes.Equal(sum[:opts.PrefixBytes], last[:opts.PrefixBytes]) {
+			stats.Duplicates++
+			continue
+		}
+		if _, err := out.Write(sum[:opts.PrefixBytes]); err != nil {
+			return stats, err
+		}
+		stats.Written++
+		last = sum
+	}
+	if err := in.Err(); err != nil {
+		return stats, err
+	}
+	if err := out.Flush(); err != nil {
+		return stats, err
+	}
+
+	binary.BigEndian.PutUint64(hdr[16:], uint64(stats.Written))
+	if _, err := f.WriteAt(hdr[:], 0); err != nil {
+		return stats, err
+	}
+	if err := f.Sync(); err != nil {
+		return stats, err
+	}
+	if err := f.Close(); err != nil {
+		return stats, err
+	}
+	return stats, os.Rename(tmp, path)
+}
@@ -0,0 +1,295 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// dump returns a HIBP-style dump of the given passwords, ordered by hash
+func dump(passwords ...string) string {
+	lines := make([]string, 0, len(passwords))
+	for _, p := range passwords {
+		sum := sha1.Sum([]byte(p))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":3")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func digest(t *testing.T, hexHash string) [sha1.Size]byte {
+	t.Helper()
+	var sum [sha1.Size]byte
+	if _, err := hex.Decode(sum[:], []byte(hexHash)); err != nil {
+		t.Fatalf("decode %q: %v", hexHash, err)
+	}
+	return sum
+}
+
+func build(t *testing.T, input string, opts BuildOptions) (string, BuildStats, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breach.idx")
+	stats, err := Build(strings.NewReader(input), path, opts)
+	return path, stats, err
+}
+
+func TestBuild(t *testing.T) {
+	const (
+		a = "0000000000000000000000000000000000000001"
+		b = "0000000000000000000000000000000000000002"
+		c = "00000001FF000000000000000000000000000000"
+		d = "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"
+	)
+	tests := []struct {
+		name    string
+		input   string
+		opts    BuildOptions
+		want    BuildStats
+		wantErr error
+		errText string
+	}{
+		{
+			name:  "sorted dump",
+			input: a + ":1\n" + c + ":2\n" + d + ":3\n",
+			want:  BuildStats{Lines: 3, Written: 3},
+		},
+		{
+			name:  "lowercase hashes, blank lines and no counts",
+			input: strings.ToLower(a) + "\n\n" + strings.ToLower(d) + "\n",
+			want:  BuildStats{Lines: 3, Written: 2},
+		},
+		{
+			name:  "empty dump",
+			input: "",
+			want:  BuildStats{},
+		},
+		{
+			name:  "exact duplicate collapses",
+			input: a + ":1\n" + a + ":1\n" + d + ":1\n",
+			want:  BuildStats{Lines: 3, Written: 2, Duplicates: 1},
+		},
+		{
+			name:  "hashes sharing the kept prefix collapse",
+			input: a + ":1\n" + b + ":1\n" + c + ":1\n",
+			opts:  BuildOptions{PrefixBytes: 4},
+			want:  BuildStats{Lines: 3, Written: 2, Duplicates: 1},
+		},
+		{
+			name:  "full-length records keep near collisions apart",
+			input: a + ":1\n" + b + ":1\n",
+			opts:  BuildOptions{PrefixBytes: sha1.Size},
+			want:  BuildStats{Lines: 2, Written: 2},
+		},
+		{
+			name:  "min count skips rare hashes",
+			input: a + ":1\n" + c + ":10\n" + d + ":x\n",
+			opts:  BuildOptions{MinCount: 5},
+			want:  BuildStats{Lines: 3, Written: 2, BelowMin: 1},
+		},
+		{
+			name:    "unsorted dump",
+			input:   d + ":1\n" + a + ":1\n",
+			wantErr: ErrUnsorted,
+		},
+		{
+			name:    "unsorted after a duplicate",
+			input:   c + ":1\n" + c + ":1\n" + b + ":1\n",
+			wantErr: ErrUnsorted,
+		},
+		{
+			name:    "short hash",
+			input:   "ABCDEF:1\n",
+			errText: "line 1: expected a 40 character SHA-1 hash",
+		},
+		{
+			name:    "non-hex hash",
+			input:   a + ":1\n" + strings.Repeat("Z", 40) + ":1\n",
+			errText: "line 2:",
+		},
+		{
+			name:    "prefix too short",
+			opts:    BuildOptions{PrefixBytes: 3},
+			errText: "prefix bytes must be between 4 and 20",
+		},
+		{
+			name:    "prefix too long",
+			opts:    BuildOptions{PrefixBytes: 21},
+			errText: "prefix bytes must be between 4 and 20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, stats, err := build(t, tt.input, tt.opts)
+			if tt.wantErr != nil || tt.errText != "" {
+				if err == nil {
+					t.Fatal("Build succeeded, want an error")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.errText != "" && !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.errText)
+				}
+				// A failed build leaves neither the index nor its temporary file behind
+				for _, p := range []string{path, path + ".tmp"} {
+					if _, err := os.Stat(p); !os.IsNotExist(err) {
+						t.Errorf("%s exists after a failed build", filepath.Base(p))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+
+			ix, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer ix.Close()
+			if ix.Len() != tt.want.Written {
+				t.Errorf("Len = %d, want %d", ix.Len(), tt.want.Written)
+			}
+		})
+	}
+}
+
+func TestContainsHash(t *testing.T) {
+	breached := []string{"password", "123456", "letmein", "qwerty", "hunter2", "correct horse"}
+	path, _, err := build(t, dump(breached...), BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer ix.Close()
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"123456", true},
+		{"hunter2", true},
+		{"correct horse", true},
+		{"Password", false},
+		{"", false},
+		{"a much longer passphrase nobody has leaked", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got, err := ix.Contains(tt.password)
+			if err != nil {
+				t.Fatalf("Contains: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsHashBoundaries(t *testing.T) {
+	const (
+		first = "0000000000000000000000000000000000000001"
+		mid   = "8000000000000000000000000000000000000000"
+		last  = "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"
+	)
+	tests := []struct {
+		name  string
+		input string
+		opts  BuildOptions
+		hash  string
+		want  bool
+	}{
+		{"first record", first + "\n" + mid + "\n" + last + "\n", BuildOptions{}, first, true},
+		{"last record", first + "\n" + mid + "\n" + last + "\n", BuildOptions{}, last, true},
+		{"below the first record", mid + "\n" + last + "\n", BuildOptions{}, first, false},
+		{"above the last record", first + "\n" + mid + "\n", BuildOptions{}, last, false},
+		{"empty index", "", BuildOptions{}, mid, false},
+		// Only the prefix is stored, so a hash sharing it is reported as breached
+		{"prefix collision matches", first + "\n", BuildOptions{PrefixBytes: 4}, "00000000FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", true},
+		{"full-length record does not", first + "\n", BuildOptions{PrefixBytes: sha1.Size}, "0000000000000000000000000000000000000002", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _, err := build(t, tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			ix, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer ix.Close()
+
+			got, err := ix.ContainsHash(digest(t, tt.hash))
+			if err != nil {
+				t.Fatalf("ContainsHash: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ContainsHash(%s) = %v, want %v", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsCorruptIndex(t *testing.T) {
+	path, _, err := build(t, dump("password", "123456", "letmein"), BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+		errText string
+	}{
+		{"empty file", func([]byte) []byte { return nil }, "EOF"},
+		{"short header", func(b []byte) []byte { return b[:headerSize-1] }, "unexpected EOF"},
+		{"wrong magic", func(b []byte) []byte { b[0] = 'X'; return b }, "not a breach index"},
+		{"record size too small", func(b []byte) []byte { b[8] = 3; return b }, "invalid record size 3"},
+		{"record size too large", func(b []byte) []byte { b[8] = 21; return b }, "invalid record size 21"},
+		{"truncated records", func(b []byte) []byte { return b[:len(b)-1] }, "truncated build"},
+		{"trailing bytes", func(b []byte) []byte { return append(b, 0) }, "truncated build"},
+		{"count larger than the file", func(b []byte) []byte { b[23]++; return b }, "truncated build"},
+		{"count overflowing the size", func(b []byte) []byte { b[16] = 0x80; return b }, "truncated build"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "corrupt.idx")
+			if err := os.WriteFile(p, tt.corrupt(append([]byte(nil), good...)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			ix, err := Open(p)
+			if err == nil {
+				ix.Close()
+				t.Fatal("Open succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.errText)
+			}
+		})
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.idx")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v, want not-exist", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package buildinfo reports the version and commit a binary was built from. Release builds
+// set them at link time (see the Makefile):
+//
+//	go build -ldflags "-X github.com/peithosecure/peitho-backend/internal/buildinfo.Version=1.2.0 \
+//	    -X github.com/peithosecure/peitho-backend/internal/buildinfo.Commit=3f2c1e9"
+//
+// Without them, the VCS details the Go toolchain embeds are used where available.
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags -X
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version" example:"1.2.0"`
+	Commit    string `json:"commit,omitempty" example:"3f2c1e9"`
+	Date      string `json:"date,omitempty" example:"2025-06-01T12:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.1"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build information, filling gaps from the embedded VCS stamp
+func Get() Info {
+	once.Do(func() {
+		info = Info{Version: Version, Commit: Commit, Date: Date}
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		info.GoVersion = bi.GoVersion
+		var dirty bool
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				dirty = s.Value == "true"
+			}
+		}
+		if dirty && Commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	})
+	return info
+}
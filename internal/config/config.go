@@ -1,42 +1,182 @@
+// Package config holds every setting the server and peithoctl read at startup.
+//
+// Each Config field declares where it comes from in its struct tags:
+//
+//	env      environment variable name
+//	key      dotted path in the config file ("smtp.host"); "-" for env-only settings
+//	default  value used when no source sets the field
+//	secret   "true" to redact the value in logs and allow loading it from a file
+//	reload   "true" when a running server applies changes without a restart
+//	validate go-playground/validator rules checked after loading
+//
+// Sources are applied in increasing precedence: defaults, the config file named by
+// PEITHO_CONFIG_FILE (YAML or TOML), environment variables, and finally <ENV>_FILE
+// variables pointing at secret files such as Docker or Kubernetes secret mounts.
+//
+// The server loads the configuration again on SIGHUP and when the config file or a secret
+// file changes; see Merge.
 package config
 
 import (
-	"os"
+	"log/slog"
+	"reflect"
+	"time"
 )
 
 type Config struct {
-	Port                  string
-	LicenseToken          string
-	SQLitePath            string
-	KeycloakIssuerURL     string
-	KeycloakURL           string
-	KeycloakInternalURL   string
-	KeycloakClientID      string
-	KeycloakClientSecret  string
-	KeycloakAdmin         string
-	KeycloakAdminPassword string
-	AdminMetricsUsername  string
-	AdminMetricsPassword  string
+	Port            string        `env:"SERVER_PORT" key:"server.port" default:"8080" validate:"port"`
+	ListenMode      string        `env:"PEITHO_LISTEN_MODE" key:"server.listen_mode" default:"tls" validate:"oneof=http tls mtls"`
+	TLSCertFile     string        `env:"TLS_CERT_FILE" key:"server.tls_cert_file" validate:"omitempty,file"`
+	TLSKeyFile      string        `env:"TLS_KEY_FILE" key:"server.tls_key_file" validate:"omitempty,file"`
+	TLSClientCAFile string        `env:"TLS_CLIENT_CA_FILE" key:"server.tls_client_ca_file" validate:"omitempty,file"`
+	TLSClientAuth   string        `env:"TLS_CLIENT_AUTH" key:"server.tls_client_auth" default:"optional" validate:"oneof=optional require"`
+	InternalAddr    string        `env:"PEITHO_INTERNAL_ADDR" key:"server.internal_addr" validate:"omitempty,hostname_port"`
+	TrustedProxies  []string      `env:"TRUSTED_PROXIES" key:"server.trusted_proxies" validate:"dive,cidr|ip"`
+	RoastOnly       bool          `env:"PEITHO_ROAST_ONLY" key:"server.roast_only"`
+	ShutdownTimeout time.Duration `env:"PEITHO_SHUTDOWN_TIMEOUT" key:"server.shutdown_timeout" default:"30s" validate:"min=1s"`
+	ShutdownDrain   time.Duration `env:"PEITHO_SHUTDOWN_DRAIN_DELAY" key:"server.shutdown_drain_delay" default:"5s" validate:"min=0s"`
+	ConfigWatch     time.Duration `env:"PEITHO_CONFIG_WATCH_INTERVAL" key:"server.config_watch_interval" default:"10s" validate:"min=0s"`
+	HealthTimeout   time.Duration `env:"PEITHO_HEALTH_TIMEOUT" key:"health.timeout" default:"2s" validate:"min=100ms"`
+	HealthCacheTTL  time.Duration `env:"PEITHO_HEALTH_CACHE_TTL" key:"health.cache_ttl" default:"10s" validate:"min=0s"`
+	LicenseToken    string        `env:"PEITHO_LICENSE_TOKEN" key:"license.token" secret:"true"`
+	UnlockPath      string        `env:"UNLOCK_PATH" key:"license.unlock_path" default:"/app/peitho-core/unlock.lic" validate:"required"`
+	// The device unlock.lic must be bound to, unless multi-device mode is on
+	DeviceID         string `env:"PEITHO_DEVICE_ID" key:"license.device_id" default:"web-default"`
+	AllowMultiDevice bool   `env:"PEITHO_ALLOW_MULTI_DEVICE" key:"license.allow_multi_device"`
+	SQLitePath       string `env:"PEITHO_SQLITE_PATH" key:"database.sqlite_path" default:"peitho_secure.db" validate:"required"`
+
+	KeycloakIssuerURL     string `env:"KEYCLOAK_ISSUER_URL" key:"keycloak.issuer_url" validate:"omitempty,http_url"`
+	KeycloakURL           string `env:"KEYCLOAK_URL" key:"keycloak.url" validate:"omitempty,http_url"`
+	KeycloakInternalURL   string `env:"KEYCLOAK_INTERNAL_URL" key:"keycloak.internal_url" validate:"omitempty,http_url"`
+	KeycloakClientID      string `env:"KEYCLOAK_CLIENT_ID" key:"keycloak.client_id"`
+	KeycloakClientSecret  string `env:"KEYCLOAK_CLIENT_SECRET" key:"keycloak.client_secret" secret:"true"`
+	KeycloakAdmin         string `env:"KEYCLOAK_ADMIN" key:"keycloak.admin"`
+	KeycloakAdminPassword string `env:"KEYCLOAK_ADMIN_PASSWORD" key:"keycloak.admin_password" secret:"true"`
+	// Replaced by the PEITHO_ADMIN_* settings; still read so that setting them fails loudly
+	AdminMetricsUsername string `env:"ADMIN_METRICS_USERNAME" key:"admin_metrics.username"`
+	AdminMetricsPassword string `env:"ADMIN_METRICS_PASSWORD" key:"admin_metrics.password" secret:"true"`
+
+	// Admin endpoints accept Keycloak bearer tokens carrying AdminRole and/or basic auth
+	// against AdminUsername and an argon2id AdminPasswordHash (peithoctl admin hash-password).
+	// "none" switches them off. AdminAllowedIPs, when set, also restricts where callers come from.
+	AdminAuth         []string `env:"PEITHO_ADMIN_AUTH" key:"admin.auth" default:"bearer" reload:"true" validate:"min=1,dive,oneof=bearer basic none"`
+	AdminRole         string   `env:"PEITHO_ADMIN_ROLE" key:"admin.role" default:"admin" reload:"true" validate:"required"`
+	AdminUsername     string   `env:"PEITHO_ADMIN_USERNAME" key:"admin.username" reload:"true"`
+	AdminPasswordHash string   `env:"PEITHO_ADMIN_PASSWORD_HASH" key:"admin.password_hash" secret:"true" reload:"true" validate:"omitempty,startswith=$argon2id$"`
+	AdminAllowedIPs   []string `env:"PEITHO_ADMIN_ALLOWED_IPS" key:"admin.allowed_ips" reload:"true" validate:"dive,cidr|ip"`
+
+	SMTPHost      string `env:"SMTP_HOST" key:"smtp.host" reload:"true"`
+	SMTPPort      string `env:"SMTP_PORT" key:"smtp.port" validate:"omitempty,port" reload:"true"`
+	SMTPUsername  string `env:"SMTP_USERNAME" key:"smtp.username" reload:"true"`
+	SMTPPassword  string `env:"SMTP_PASSWORD" key:"smtp.password" secret:"true" reload:"true"`
+	FromEmail     string `env:"FROM_EMAIL" key:"smtp.from" default:"no-reply@peithosecure.io" validate:"email" reload:"true"`
+	FrontendURL   string `env:"FRONTEND_URL" key:"frontend.url" default:"http://localhost:3000" validate:"http_url" reload:"true"`
+	AppLinkScheme string `env:"APP_LINK_SCHEME" key:"frontend.app_link_scheme" reload:"true"`
+
+	LoginMaxAttempts int           `env:"PEITHO_LOGIN_MAX_ATTEMPTS" key:"rate_limit.login_max_attempts" default:"3" reload:"true" validate:"min=1"`
+	LoginWindow      time.Duration `env:"PEITHO_LOGIN_WINDOW" key:"rate_limit.login_window" default:"5m" reload:"true" validate:"min=1s"`
+	LoginLockout     time.Duration `env:"PEITHO_LOGIN_LOCKOUT" key:"rate_limit.login_lockout" default:"30m" reload:"true" validate:"min=1s"`
+
+	// Origins are exact ("https://app.example.com"), wildcard subdomains
+	// ("https://*.example.com") or "*" for any origin without credentials
+	CORSOrigins          []string      `env:"CORS_ALLOWED_ORIGINS" key:"cors.allowed_origins" default:"*" reload:"true" validate:"min=1,dive,origin"`
+	CORSAllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" key:"cors.allow_credentials" reload:"true"`
+	CORSAllowedMethods   []string      `env:"CORS_ALLOWED_METHODS" key:"cors.allowed_methods" default:"GET,POST,PUT,DELETE,OPTIONS" reload:"true" validate:"min=1"`
+	CORSAllowedHeaders   []string      `env:"CORS_ALLOWED_HEADERS" key:"cors.allowed_headers" default:"Content-Type,Authorization,X-Request-ID" reload:"true"`
+	CORSExposedHeaders   []string      `env:"CORS_EXPOSED_HEADERS" key:"cors.exposed_headers" default:"X-Request-ID,X-Next-Cursor" reload:"true"`
+	CORSMaxAge           time.Duration `env:"CORS_MAX_AGE" key:"cors.max_age" default:"10m" reload:"true" validate:"min=0s,max=24h"`
+
+	HSTSMaxAge            time.Duration `env:"PEITHO_HSTS_MAX_AGE" key:"security_headers.hsts_max_age" default:"365d" reload:"true" validate:"min=0s"`
+	HSTSIncludeSubdomains bool          `env:"PEITHO_HSTS_INCLUDE_SUBDOMAINS" key:"security_headers.hsts_include_subdomains" default:"true" reload:"true"`
+	HSTSPreload           bool          `env:"PEITHO_HSTS_PRELOAD" key:"security_headers.hsts_preload" reload:"true"`
+	ReferrerPolicy        string        `env:"PEITHO_REFERRER_POLICY" key:"security_headers.referrer_policy" default:"no-referrer" reload:"true" validate:"oneof=no-referrer no-referrer-when-downgrade origin origin-when-cross-origin same-origin strict-origin strict-origin-when-cross-origin"`
+	// CSP frame-ancestors sources; the default forbids framing altogether
+	FrameAncestors []string `env:"PEITHO_FRAME_ANCESTORS" key:"security_headers.frame_ancestors" default:"'none'" reload:"true" validate:"min=1,dive,required"`
+
+	TraceRingSize           int           `env:"PEITHO_TRACE_RING_SIZE" key:"trace.ring_size" default:"256" validate:"min=1"`
+	LockdownSeverity        string        `env:"PEITHO_LOCKDOWN_SEVERITY" key:"lockdown.severity" validate:"omitempty,oneof=low medium high critical LOW MEDIUM HIGH CRITICAL"`
+	LockdownTTL             time.Duration `env:"PEITHO_LOCKDOWN_TTL" key:"lockdown.ttl" validate:"min=0s"`
+	WebhookMaxAttempts      int           `env:"PEITHO_WEBHOOK_MAX_ATTEMPTS" key:"webhooks.max_attempts" default:"6" validate:"min=1"`
+	WebhookTimeout          time.Duration `env:"PEITHO_WEBHOOK_TIMEOUT" key:"webhooks.timeout" default:"10s" validate:"min=1s"`
+	AuditCheckpointKey      string        `env:"PEITHO_AUDIT_CHECKPOINT_KEY" key:"audit.checkpoint_key" secret:"true"`
+	AuditCheckpointInterval time.Duration `env:"PEITHO_AUDIT_CHECKPOINT_INTERVAL" key:"audit.checkpoint_interval" default:"1h" validate:"min=0s"`
+	SyslogAddr              string        `env:"PEITHO_SYSLOG_ADDR" key:"syslog.addr" validate:"omitempty,hostname_port"`
+	SyslogNetwork           string        `env:"PEITHO_SYSLOG_NETWORK" key:"syslog.network" validate:"omitempty,oneof=udp tcp tls"`
+	SyslogCAFile            string        `env:"PEITHO_SYSLOG_CA_FILE" key:"syslog.ca_file" validate:"omitempty,file"`
+
+	RetentionInterval     time.Duration `env:"PEITHO_RETENTION_INTERVAL" key:"retention.interval" default:"1h" validate:"min=0s"`
+	RetentionArchiveDir   string        `env:"PEITHO_RETENTION_ARCHIVE_DIR" key:"retention.archive_dir"`
+	AuditRetentionMaxAge  time.Duration `env:"PEITHO_RETENTION_AUDIT_MAX_AGE" key:"retention.audit.max_age" validate:"min=0s"`
+	AuditRetentionMaxRows int           `env:"PEITHO_RETENTION_AUDIT_MAX_ROWS" key:"retention.audit.max_rows" validate:"min=0"`
+	TraceRetentionMaxAge  time.Duration `env:"PEITHO_RETENTION_TRACE_MAX_AGE" key:"retention.trace.max_age" validate:"min=0s"`
+	TraceRetentionMaxRows int           `env:"PEITHO_RETENTION_TRACE_MAX_ROWS" key:"retention.trace.max_rows" validate:"min=0"`
+	TokenRetentionMaxAge  time.Duration `env:"PEITHO_RETENTION_TOKENS_MAX_AGE" key:"retention.tokens.max_age" default:"7d" validate:"min=0s"`
+	TokenRetentionMaxRows int           `env:"PEITHO_RETENTION_TOKENS_MAX_ROWS" key:"retention.tokens.max_rows" validate:"min=0"`
+	RoastRetentionMaxAge  time.Duration `env:"PEITHO_RETENTION_ROASTS_MAX_AGE" key:"retention.roasts.max_age" validate:"min=0s"`
+	RoastRetentionMaxRows int           `env:"PEITHO_RETENTION_ROASTS_MAX_ROWS" key:"retention.roasts.max_rows" validate:"min=0"`
+	VacuumInterval        time.Duration `env:"PEITHO_VACUUM_INTERVAL" key:"retention.vacuum_interval" default:"24h" validate:"min=0s"`
+	VacuumMode            string        `env:"PEITHO_VACUUM_MODE" key:"retention.vacuum_mode" validate:"omitempty,oneof=incremental full off"`
+
+	LogFormat string `env:"PEITHO_LOG_FORMAT" key:"log.format" validate:"omitempty,oneof=text json TEXT JSON"`
+	LogLevel  string `env:"PEITHO_LOG_LEVEL" key:"log.level" validate:"omitempty,loglevel" reload:"true"`
+
+	// TracingExporter picks where OpenTelemetry spans go. With otlp the standard
+	// OTEL_EXPORTER_OTLP_* variables apply unless PEITHO_OTLP_ENDPOINT is set.
+	TracingExporter      string `env:"PEITHO_TRACING_EXPORTER" key:"tracing.exporter" default:"none" validate:"oneof=none stdout otlp"`
+	OTLPEndpoint         string `env:"PEITHO_OTLP_ENDPOINT" key:"tracing.otlp_endpoint" validate:"omitempty,url"`
+	TracingSamplePercent int    `env:"PEITHO_TRACING_SAMPLE_PERCENT" key:"tracing.sample_percent" default:"100" validate:"min=0,max=100"`
+
+	PasswordMinLength     int           `env:"PEITHO_PASSWORD_MIN_LENGTH" key:"password.min_length" default:"12" validate:"min=0"`
+	PasswordMaxLength     int           `env:"PEITHO_PASSWORD_MAX_LENGTH" key:"password.max_length" default:"128" validate:"min=0"`
+	PasswordRequireUpper  bool          `env:"PEITHO_PASSWORD_REQUIRE_UPPER" key:"password.require_upper" default:"true"`
+	PasswordRequireLower  bool          `env:"PEITHO_PASSWORD_REQUIRE_LOWER" key:"password.require_lower" default:"true"`
+	PasswordRequireDigit  bool          `env:"PEITHO_PASSWORD_REQUIRE_DIGIT" key:"password.require_digit" default:"true"`
+	PasswordRequireSymbol bool          `env:"PEITHO_PASSWORD_REQUIRE_SYMBOL" key:"password.require_symbol"`
+	PasswordMaxRepeat     int           `env:"PEITHO_PASSWORD_MAX_REPEAT" key:"password.max_repeat" default:"3" validate:"min=0"`
+	PasswordMinStrength   int           `env:"PEITHO_PASSWORD_MIN_STRENGTH" key:"password.min_strength" default:"3" validate:"min=0,max=4"`
+	PasswordHistory       int           `env:"PEITHO_PASSWORD_HISTORY" key:"password.history" default:"5" validate:"min=0,max=24"`
+	PasswordMaxAge        time.Duration `env:"PEITHO_PASSWORD_MAX_AGE" key:"password.max_age" validate:"min=0s"`
+	BreachIndexPath       string        `env:"PEITHO_BREACH_INDEX" key:"password.breach_index" validate:"omitempty,file"`
+
+	// sources records which source set each field, by env name
+	sources map[string]string
+	// files lists the config and secret files read, for change detection
+	files []string
 }
 
+// LoadConfig loads the configuration from the file named by PEITHO_CONFIG_FILE, if any,
+// and the environment. The returned error joins every problem found.
 func LoadConfig() (*Config, error) {
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080" // Default port
+	return Load(ConfigFileFromEnv())
+}
+
+// LogValue renders every field for structured logs, with secret fields redacted
+func (c *Config) LogValue() slog.Value {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Tag.Get("secret") == "true" {
+			attrs = append(attrs, slog.String(f.Name, redact(v.Field(i).String())))
+			continue
+		}
+		attrs = append(attrs, slog.Any(f.Name, v.Field(i).Interface()))
 	}
+	return slog.GroupValue(attrs...)
+}
+
+// String keeps %v and %+v from printing secrets
+func (c *Config) String() string {
+	return c.LogValue().String()
+}
 
-	return &Config{
-		Port:                  port,
-		LicenseToken:          os.Getenv("PEITHO_LICENSE_TOKEN"),
-		SQLitePath:            os.Getenv("PEITHO_SQLITE_PATH"),
-		KeycloakIssuerURL:     os.Getenv("KEYCLOAK_ISSUER_URL"),
-		KeycloakURL:           os.Getenv("KEYCLOAK_URL"),
-		KeycloakInternalURL:   os.Getenv("KEYCLOAK_INTERNAL_URL"),
-		KeycloakClientID:      os.Getenv("KEYCLOAK_CLIENT_ID"),
-		KeycloakClientSecret:  os.Getenv("KEYCLOAK_CLIENT_SECRET"),
-		KeycloakAdmin:         os.Getenv("KEYCLOAK_ADMIN"),
-		KeycloakAdminPassword: os.Getenv("KEYCLOAK_ADMIN_PASSWORD"),
-		AdminMetricsUsername:  os.Getenv("ADMIN_METRICS_USERNAME"),
-		AdminMetricsPassword:  os.Getenv("ADMIN_METRICS_PASSWORD"),
-	}, nil
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
 }
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the variable holding the config file path
+const ConfigFileEnv = "PEITHO_CONFIG_FILE"
+
+// Sources reported by Settings
+const (
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceEnv        = "env"
+	SourceSecretFile = "secret-file"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigFileFromEnv returns the config file path from PEITHO_CONFIG_FILE, or "" for none
+func ConfigFileFromEnv() string {
+	return os.Getenv(ConfigFileEnv)
+}
+
+// Load builds a Config from defaults, the file at path (skipped when empty), the environment
+// and secret files, then validates it. The error joins every problem found, and the Config
+// is returned even when it is non-nil so callers can show what was loaded.
+func Load(path string) (*Config, error) {
+	c := &Config{sources: map[string]string{}}
+	fields := c.fields()
+
+	var errs []error
+	for _, f := range fields {
+		if f.def != "" {
+			if err := setValue(f.value, f.def); err != nil {
+				errs = append(errs, fmt.Errorf("%s default: %w", f.env, err))
+			}
+		}
+		c.sources[f.env] = SourceDefault
+	}
+
+	if path != "" {
+		errs = append(errs, c.applyFile(path, fields)...)
+	}
+	errs = append(errs, c.applyEnv(fields)...)
+
+	if err := c.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return c, errors.Join(errs...)
+}
+
+type field struct {
+	env    string
+	key    string
+	def    string
+	secret bool
+	reload bool
+	value  reflect.Value
+}
+
+func (c *Config) fields() []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	out := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		env := sf.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		out = append(out, field{
+			env:    env,
+			key:    sf.Tag.Get("key"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret") == "true",
+			reload: sf.Tag.Get("reload") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return out
+}
+
+// applyFile reads a YAML or TOML file of nested sections. Secret settings may name a file
+// to read instead ("client_secret_file"). Keys that match no setting are errors, so typos
+// do not silently fall back to defaults.
+func (c *Config) applyFile(path string, fields []field) []error {
+	c.files = append(c.files, path)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("config file: %w", err)}
+	}
+
+	tree := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		return []error{fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)}
+	}
+	if err != nil {
+		return []error{fmt.Errorf("config file %s: %w", path, err)}
+	}
+
+	values := map[string]any{}
+	flatten("", tree, values)
+
+	var errs []error
+	for _, f := range fields {
+		if f.key == "" || f.key == "-" {
+			continue
+		}
+		v, inline := values[f.key]
+		if inline {
+			delete(values, f.key)
+			if err := setFromFile(f.value, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+			} else if v != nil {
+				c.sources[f.env] = SourceFile
+			}
+		}
+		if !f.secret {
+			continue
+		}
+		ref, ok := values[f.key+"_file"]
+		if !ok {
+			continue
+		}
+		delete(values, f.key+"_file")
+		secretPath, isString := ref.(string)
+		switch {
+		case inline:
+			errs = append(errs, fmt.Errorf("%s: set either %s or %s_file, not both", f.key, f.key, f.key))
+		case !isString:
+			errs = append(errs, fmt.Errorf("%s_file: must be a path", f.key))
+		default:
+			if err := c.readSecret(f, secretPath); err != nil {
+				errs = append(errs, fmt.Errorf("%s_file: %w", f.key, err))
+			}
+		}
+	}
+
+	unknown := make([]string, 0, len(values))
+	for k := range values {
+		unknown = append(unknown, k)
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		errs = append(errs, fmt.Errorf("config file %s: unknown setting %q", path, k))
+	}
+	return errs
+}
+
+// applyEnv overrides fields from their environment variables, then from <ENV>_FILE for
+// secrets. Empty variables count as unset, matching how compose files pass blanks through.
+func (c *Config) applyEnv(fields []field) []error {
+	var errs []error
+	for _, f := range fields {
+		v := os.Getenv(f.env)
+		if v != "" {
+			if err := setValue(f.value, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			} else {
+				c.sources[f.env] = SourceEnv
+			}
+		}
+		if !f.secret {
+			continue
+		}
+		secretPath := os.Getenv(f.env + "_FILE")
+		if secretPath == "" {
+			continue
+		}
+		if v != "" {
+			errs = append(errs, fmt.Errorf("%s: set either %s or %s_FILE, not both", f.env, f.env, f.env))
+			continue
+		}
+		if err := c.readSecret(f, secretPath); err != nil {
+			errs = append(errs, fmt.Errorf("%s_FILE: %w", f.env, err))
+		}
+	}
+	return errs
+}
+
+// readSecret loads a secret from a mounted file, dropping the trailing newline most
+// tools write
+func (c *Config) readSecret(f field, path string) error {
+	c.files = append(c.files, path)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	secret := strings.TrimRight(string(raw), "\r\n")
+	if secret == "" {
+		return fmt.Errorf("%s is empty", path)
+	}
+	f.value.SetString(secret)
+	c.sources[f.env] = SourceSecretFile
+	return nil
+}
+
+// flatten turns nested sections into dotted keys
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if section, ok := v.(map[string]any); ok {
+			flatten(k, section, out)
+			continue
+		}
+		out[k] = v
+	}
+}
+
+// setFromFile assigns a decoded YAML/TOML value. Lists may be written as arrays or as
+// comma-separated strings; null leaves the field unchanged.
+func setFromFile(v reflect.Value, raw any) error {
+	switch raw := raw.(type) {
+	case nil:
+		return nil
+	case []any:
+		if v.Kind() != reflect.Slice {
+			return errors.New("must be a single value, not a list")
+		}
+		items := make([]string, 0, len(raw))
+		for _, item := range raw {
+			items = append(items, fmt.Sprint(item))
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	default:
+		return setValue(v, fmt.Sprint(raw))
+	}
+}
+
+// setValue parses s into v according to v's type
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts Go durations ("30m") and a whole number of days ("90d")
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("must be a duration such as 30m, 12h or 90d, got %q", s)
+	}
+	return d, nil
+}
+
+// splitList splits a comma-separated value, dropping blanks
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Setting is one effective value and where it came from, as shown by peithoctl config check
+type Setting struct {
+	Env    string `json:"env"`
+	Key    string `json:"key,omitempty"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Settings lists every field in declaration order with secrets redacted
+func (c *Config) Settings() []Setting {
+	fields := c.fields()
+	out := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		s := Setting{Env: f.env, Value: formatValue(f.value), Source: c.sources[f.env]}
+		if f.key != "-" {
+			s.Key = f.key
+		}
+		if f.secret {
+			s.Value = redact(s.Value)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func formatValue(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Files lists the config file and secret files the Config was loaded from
+func (c *Config) Files() []string {
+	return append([]string(nil), c.files...)
+}
+
+// Fingerprint summarises the size and modification time of files, for watchers that poll
+// them for changes. Stat follows symlinks, so Kubernetes secret updates, which swap a
+// symlink, are noticed too.
+func Fingerprint(files ...string) string {
+	var b strings.Builder
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:missing;", f)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// Merge returns a copy of c carrying next's reloadable settings, for a running server that
+// loaded next after starting with c. applied lists the reloadable settings that changed;
+// restart lists changed settings that only take effect after a restart. Both use env names.
+func (c *Config) Merge(next *Config) (merged *Config, applied, restart []string) {
+	merged = &Config{sources: map[string]string{}, files: next.files}
+	cur, nxt, out := c.fields(), next.fields(), merged.fields()
+	for i, f := range cur {
+		out[i].value.Set(f.value)
+		merged.sources[f.env] = c.sources[f.env]
+		if reflect.DeepEqual(f.value.Interface(), nxt[i].value.Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.env)
+			continue
+		}
+		out[i].value.Set(nxt[i].value)
+		merged.sources[f.env] = next.sources[f.env]
+		applied = append(applied, f.env)
+	}
+	return merged, applied, restart
+}
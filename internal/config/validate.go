@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Report fields by their environment variable, the name operators know them by
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		return f.Tag.Get("env")
+	})
+	_ = v.RegisterValidation("port", func(fl validator.FieldLevel) bool {
+		n, err := strconv.Atoi(fl.Field().String())
+		return err == nil && n >= 1 && n <= 65535
+	})
+	_ = v.RegisterValidation("loglevel", func(fl validator.FieldLevel) bool {
+		var level slog.Level
+		return level.UnmarshalText([]byte(fl.Field().String())) == nil
+	})
+	_ = v.RegisterValidation("origin", func(fl validator.FieldLevel) bool {
+		return validOrigin(fl.Field().String())
+	})
+	return v
+}
+
+// validOrigin accepts "*" and scheme://host[:port] origins, where host may start with "*."
+// to match any subdomain
+func validOrigin(s string) bool {
+	if s == "*" {
+		return true
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "*.")
+	return host != "" && !strings.Contains(host, "*")
+}
+
+// Validate checks every field's rules and the rules that span fields. It reports all
+// problems at once, joined, rather than stopping at the first.
+func (c *Config) Validate() error {
+	var errs []error
+	if err := validate.Struct(c); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return err
+		}
+		for _, fe := range verrs {
+			errs = append(errs, fmt.Errorf("%s: %s", fieldName(fe), message(fe)))
+		}
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.ListenMode == "mtls" && c.TLSClientCAFile == "" {
+		errs = append(errs, errors.New("PEITHO_LISTEN_MODE=mtls requires TLS_CLIENT_CA_FILE"))
+	}
+	if c.ListenMode != "mtls" && c.TLSClientCAFile != "" {
+		errs = append(errs, errors.New("TLS_CLIENT_CA_FILE is only used with PEITHO_LISTEN_MODE=mtls"))
+	}
+	if c.CORSAllowCredentials && slices.Contains(c.CORSOrigins, "*") {
+		errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOWED_ORIGINS=*; list the origins"))
+	}
+	if (c.SMTPUsername == "") != (c.SMTPPassword == "") {
+		errs = append(errs, errors.New("SMTP_USERNAME and SMTP_PASSWORD must be set together"))
+	}
+	if c.PasswordMaxLength > 0 && c.PasswordMinLength > c.PasswordMaxLength {
+		errs = append(errs, fmt.Errorf("PEITHO_PASSWORD_MIN_LENGTH (%d) exceeds PEITHO_PASSWORD_MAX_LENGTH (%d)",
+			c.PasswordMinLength, c.PasswordMaxLength))
+	}
+	if c.SyslogAddr == "" && (c.SyslogNetwork != "" || c.SyslogCAFile != "") {
+		errs = append(errs, errors.New("PEITHO_SYSLOG_NETWORK and PEITHO_SYSLOG_CA_FILE require PEITHO_SYSLOG_ADDR"))
+	}
+	basicAdmin := slices.Contains(c.AdminAuth, "basic")
+	if slices.Contains(c.AdminAuth, "none") && len(c.AdminAuth) > 1 {
+		errs = append(errs, errors.New("PEITHO_ADMIN_AUTH=none cannot be combined with other methods"))
+	}
+	if basicAdmin && (c.AdminUsername == "" || c.AdminPasswordHash == "") {
+		errs = append(errs, errors.New("PEITHO_ADMIN_AUTH=basic requires PEITHO_ADMIN_USERNAME and PEITHO_ADMIN_PASSWORD_HASH"))
+	}
+	if !basicAdmin && (c.AdminUsername != "" || c.AdminPasswordHash != "") {
+		errs = append(errs, errors.New("PEITHO_ADMIN_USERNAME and PEITHO_ADMIN_PASSWORD_HASH are only used with PEITHO_ADMIN_AUTH=basic"))
+	}
+	if c.AdminMetricsUsername != "" || c.AdminMetricsPassword != "" {
+		errs = append(errs, errors.New("ADMIN_METRICS_USERNAME and ADMIN_METRICS_PASSWORD are no longer supported; "+
+			"set PEITHO_ADMIN_AUTH=basic with PEITHO_ADMIN_USERNAME and PEITHO_ADMIN_PASSWORD_HASH (peithoctl admin hash-password)"))
+	}
+	if c.LockdownSeverity != "" && c.LockdownTTL <= 0 {
+		errs = append(errs, errors.New("PEITHO_LOCKDOWN_SEVERITY requires a PEITHO_LOCKDOWN_TTL above zero; automatic lockdowns must expire"))
+	}
+	if c.OTLPEndpoint != "" && c.TracingExporter != "otlp" {
+		errs = append(errs, errors.New("PEITHO_OTLP_ENDPOINT is only used with PEITHO_TRACING_EXPORTER=otlp"))
+	}
+	return errors.Join(errs...)
+}
+
+// fieldName drops the struct name from the validator namespace, leaving e.g. "TRUSTED_PROXIES[1]"
+func fieldName(fe validator.FieldError) string {
+	if _, rest, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return rest
+	}
+	return fe.Field()
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "port":
+		return fmt.Sprintf("must be a port between 1 and 65535, got %q", fe.Value())
+	case "loglevel":
+		return fmt.Sprintf("must be debug, info, warn or error, got %q", fe.Value())
+	case "email":
+		return fmt.Sprintf("must be a valid email address, got %q", fe.Value())
+	case "http_url":
+		return fmt.Sprintf("must be an http(s) URL, got %q", fe.Value())
+	case "file":
+		return fmt.Sprintf("file %q does not exist", fe.Value())
+	case "hostname_port":
+		return fmt.Sprintf("must be host:port, got %q", fe.Value())
+	case "cidr|ip":
+		return fmt.Sprintf("must be an IP address or CIDR range, got %q", fe.Value())
+	case "origin":
+		return fmt.Sprintf("must be *, scheme://host[:port] or scheme://*.domain, got %q", fe.Value())
+	case "startswith":
+		// the value may be a secret, so it is not echoed
+		return fmt.Sprintf("must start with %q", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", options(fe.Param()), fe.Value())
+	case "min":
+		return fmt.Sprintf("must be at least %s, got %v", fe.Param(), fe.Value())
+	case "max":
+		return fmt.Sprintf("must be at most %s, got %v", fe.Param(), fe.Value())
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+// options lists oneof choices, leaving out the upper-case spellings accepted for convenience
+func options(param string) string {
+	var out []string
+	for _, o := range strings.Fields(param) {
+		if o == strings.ToLower(o) {
+			out = append(out, o)
+		}
+	}
+	return strings.Join(out, ", ")
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 )
 
 // 📦 License logic fallback (licenseguard)
@@ -59,42 +61,18 @@ func GenerateSignedLicense(email, deviceID string, brandingRequired bool) (strin
 
 // 🧠 Roast-safe error responder (observer)
 
+// RespondWithTraceError writes an application/problem+json error for code msg.
+//
+// Deprecated: use problem.Respond, which also records the request path.
 func RespondWithTraceError(w http.ResponseWriter, msg string, code int) {
 	log.Printf("[🎭] Stub: TraceError triggered — '%s'. Next time, try not being you.", msg)
-	http.Error(w, msg+" [stubbed]", code)
+	problem.Write(w, nil, problem.New(nil, msg, code))
 }
 
 func TrackEvent(event string) {
 	log.Printf("[📡] Stub: TrackEvent → %s (recorded into our invisible memory)", event)
 }
 
-// 🔍 Trace log memory stub
-
-type StubTrace struct {
-	ID        string
-	Message   string
-	Actor     string
-	Event     string
-	Severity  string
-	Lock      bool
-	Timestamp time.Time
-}
-
-func GetRecentTraces() []StubTrace {
-	log.Println("[📼] Stub: GetRecentTraces() — it’s all made up anyway.")
-	return []StubTrace{
-		{
-			ID:        "stub-0001",
-			Message:   "no core linked — you're flying blind",
-			Actor:     "HACKER",
-			Event:     "stub_boot",
-			Severity:  "high",
-			Lock:      true,
-			Timestamp: time.Now(),
-		},
-	}
-}
-
 // 🛡 Branding check fallback (integrity)
 
 func ValidateBrand() bool {
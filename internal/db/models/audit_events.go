@@ -9,5 +9,44 @@ type AuditEvent struct {
 	EventType string    `json:"event_type"` // e.g. login, logout, password_reset
 	IPAddress string    `json:"ip_address"`
 	UserAgent string    `json:"user_agent"`
+	Outcome   string    `json:"outcome,omitempty"`    // success or failure; empty on rows written before outcomes were recorded
+	Reason    string    `json:"reason,omitempty"`     // machine-readable cause, mainly for failures
+	RequestID string    `json:"request_id,omitempty"` // X-Request-ID of the originating HTTP request
+	Subject   string    `json:"subject,omitempty"`    // Keycloak user id (JWT sub)
 	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"` // hash of the preceding row, empty for the first
+	Hash      string    `json:"hash"`      // SHA-256 over this row's content and PrevHash
+}
+
+// AuditDailyCount is the number of events of one type recorded on one UTC day
+type AuditDailyCount struct {
+	Day       string `json:"day" example:"2025-05-16"`
+	EventType string `json:"event_type" example:"login_failed"`
+	Count     int    `json:"count" example:"12"`
+}
+
+// Audit outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Audit checkpoint kinds
+const (
+	CheckpointHead  = "head"  // periodic snapshot of the chain head
+	CheckpointPrune = "prune" // last row removed by retention; the oldest remaining row links to it
+)
+
+// AuditCheckpoint is an HMAC-signed snapshot of one link in the audit chain
+type AuditCheckpoint struct {
+	ID          int64  `json:"id"`
+	Kind        string `json:"kind"`
+	LastEventID int64  `json:"last_event_id"`
+	LastHash    string `json:"last_hash"`
+	Signature   string `json:"-"`
+	// PrevSignature is the signature of the checkpoint before this one, signed into this
+	// one so checkpoints cannot be deleted unnoticed. Empty for the first checkpoint and
+	// for checkpoints written before chaining was introduced.
+	PrevSignature string    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
 }
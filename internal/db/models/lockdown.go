@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Lockdown represents a lockdown placed on the server, a user or an IP
+type Lockdown struct {
+	ID        int64      `json:"id"`
+	Scope     string     `json:"scope"`  // server, user or ip
+	Target    string     `json:"target"` // empty for server scope
+	Reason    string     `json:"reason"`
+	Source    string     `json:"source"` // who or what engaged it
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy  string     `json:"lifted_by,omitempty"`
+}
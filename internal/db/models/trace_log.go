@@ -4,8 +4,14 @@ import "time"
 
 // TraceLog represents an in-memory or persistent trace event
 type TraceLog struct {
-	ID        int64     `db:"id"`
-	UserID    int64     `db:"user_id"`
+	Seq       int64     `db:"seq"`
+	ID        string    `db:"id"`
+	Actor     string    `db:"actor"`
 	Event     string    `db:"event"`
+	Severity  string    `db:"severity"`
+	Lock      bool      `db:"lock"`
+	Scope     string    `db:"scope"`
+	Target    string    `db:"target"`
+	Message   string    `db:"message"`
 	CreatedAt time.Time `db:"created_at"`
 }
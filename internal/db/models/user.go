@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // LoginRequest represents the payload for login
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
@@ -14,13 +16,18 @@ type LoginResponse struct {
 	TokenType     string `json:"token_type"`
 	ExpiresIn     int    `json:"expires_in"`
 	EmailVerified bool   `json:"email_verified"` // <-- Added email verification info
+	// PasswordExpired asks the client to send the user through a password reset: the
+	// password is older than the configured maximum age or an admin forced a reset.
+	PasswordExpired bool `json:"password_expired"`
 }
 
 // User represents a user record in the database
 type User struct {
-	ID            int    `json:"id"`
-	Username      string `json:"username"`
-	Email         string `json:"email"`
-	Role          string `json:"role"`
-	EmailVerified int    `json:"email_verified"`
+	ID                 int        `json:"id"`
+	Username           string     `json:"username"`
+	Email              string     `json:"email"`
+	Role               string     `json:"role"`
+	EmailVerified      int        `json:"email_verified"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at,omitempty"`
+	ForcePasswordReset int        `json:"force_password_reset"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// WebhookSubscription is an outbound webhook endpoint and the events it wants
+type WebhookSubscription struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // event types, or "*" for all
+	Secret    string    `json:"-"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one attempt chain to deliver an event to a subscription
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"` // pending, delivered or failed
+	Attempts       int        `json:"attempts"`
+	ResponseCode   int        `json:"response_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	ReplayOf       int64      `json:"replay_of,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/utils"
+)
+
+// auditChainMu serialises inserts so every row links to the row committed just before it
+var auditChainMu sync.Mutex
+
+// auditCanonical is the exact content covered by an audit row's hash.
+// New fields must be tagged omitempty so rows written before they existed keep verifying.
+type auditCanonical struct {
+	PrevHash  string `json:"prev_hash"`
+	Username  string `json:"username"`
+	EventType string `json:"event_type"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	CreatedAt string `json:"created_at"`
+	Outcome   string `json:"outcome,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// AuditHash computes the chained hash of e on top of prevHash
+func AuditHash(prevHash string, e *models.AuditEvent) string {
+	b, _ := json.Marshal(auditCanonical{
+		PrevHash:  prevHash,
+		Username:  e.Username,
+		EventType: e.EventType,
+		IPAddress: e.IPAddress,
+		UserAgent: e.UserAgent,
+		CreatedAt: e.CreatedAt.UTC().Format(timeLayout),
+		Outcome:   e.Outcome,
+		Reason:    e.Reason,
+		RequestID: e.RequestID,
+		Subject:   e.Subject,
+	})
+	return utils.HashString(string(b))
+}
+
+// insertChainedAuditEvent appends e to the chain. Callers must hold auditChainMu.
+func insertChainedAuditEvent(ctx context.Context, e *models.AuditEvent) error {
+	var prev string
+	err := GetDB().QueryRowContext(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&prev)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Retention may have emptied the table; continue from the last pruned link
+		err = GetDB().QueryRowContext(ctx, `
+			SELECT last_hash FROM audit_checkpoints WHERE kind = 'prune' ORDER BY id DESC LIMIT 1
+		`).Scan(&prev)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	e.PrevHash = prev
+	e.Hash = AuditHash(prev, e)
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO audit_events (username, event_type, ip_address, user_agent, outcome, reason, request_id, subject, created_at, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Username, e.EventType, e.IPAddress, e.UserAgent, e.Outcome, e.Reason, e.RequestID, e.Subject,
+		e.CreatedAt.UTC().Format(timeLayout), e.PrevHash, e.Hash)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	e.ID = int(id)
+	return err
+}
+
+const auditColumns = `id, username, event_type, ip_address, user_agent, outcome, reason, request_id, subject, created_at, prev_hash, hash`
+
+func scanAuditEvent(s rowScanner) (*models.AuditEvent, error) {
+	var e models.AuditEvent
+	var ip, ua sql.NullString
+	if err := s.Scan(&e.ID, &e.Username, &e.EventType, &ip, &ua, &e.Outcome, &e.Reason, &e.RequestID, &e.Subject,
+		&e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+		return nil, err
+	}
+	e.IPAddress, e.UserAgent = ip.String, ua.String
+	return &e, nil
+}
+
+// WalkAuditChain calls fn for every audit row in insertion order, stopping at the first error
+func WalkAuditChain(ctx context.Context, fn func(models.AuditEvent) error) error {
+	ctx, done := observe(ctx, "walk_audit_chain")
+	defer done()
+	rows, err := GetDB().QueryContext(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_events
+		ORDER BY id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(*e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LatestAuditLink returns the id and hash of the newest audit row, or zero values when the log is empty
+func LatestAuditLink(ctx context.Context) (int64, string, error) {
+	ctx, done := observe(ctx, "latest_audit_link")
+	defer done()
+	var id int64
+	var hash string
+	err := GetDB().QueryRowContext(ctx, `SELECT id, hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&id, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	return id, hash, err
+}
+
+// AuditHashAt returns the stored hash of one row; found is false when the row no longer exists
+func AuditHashAt(ctx context.Context, id int64) (hash string, found bool, err error) {
+	ctx, done := observe(ctx, "audit_hash_at")
+	defer done()
+	err = GetDB().QueryRowContext(ctx, `SELECT hash FROM audit_events WHERE id = ?`, id).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	return hash, err == nil, err
+}
+
+// backfillAuditChain hashes rows written before the chain existed, in id order
+func backfillAuditChain() error {
+	auditChainMu.Lock()
+	defer auditChainMu.Unlock()
+
+	var events []models.AuditEvent
+	if err := WalkAuditChain(context.Background(), func(e models.AuditEvent) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	prev := ""
+	for i := range events {
+		hash := AuditHash(prev, &events[i])
+		if _, err := tx.Exec(`UPDATE audit_events SET prev_hash = ?, hash = ? WHERE id = ?`, prev, hash, events[i].ID); err != nil {
+			return err
+		}
+		prev = hash
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		log.Printf("🔗 Audit chain backfilled over %d existing event(s)", len(events))
+	}
+	return nil
+}
+
+// --- Audit checkpoints ---
+
+func InsertAuditCheckpoint(ctx context.Context, c *models.AuditCheckpoint) error {
+	ctx, done := observe(ctx, "insert_audit_checkpoint")
+	defer done()
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO audit_checkpoints (kind, last_event_id, last_hash, signature, prev_signature, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, c.Kind, c.LastEventID, c.LastHash, c.Signature, c.PrevSignature, c.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return err
+	}
+	c.ID, err = res.LastInsertId()
+	return err
+}
+
+// ListAuditCheckpoints returns every checkpoint, oldest first
+func ListAuditCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	ctx, done := observe(ctx, "list_audit_checkpoints")
+	defer done()
+	rows, err := GetDB().QueryContext(ctx, `
+		SELECT id, kind, last_event_id, last_hash, signature, prev_signature, created_at
+		FROM audit_checkpoints
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []models.AuditCheckpoint
+	for rows.Next() {
+		var c models.AuditCheckpoint
+		if err := rows.Scan(&c.ID, &c.Kind, &c.LastEventID, &c.LastHash, &c.Signature, &c.PrevSignature, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, rows.Err()
+}
+
+// LatestAuditCheckpoint returns the newest checkpoint of a kind ("" for any kind), or nil
+// when none has been written
+func LatestAuditCheckpoint(ctx context.Context, kind string) (*models.AuditCheckpoint, error) {
+	ctx, done := observe(ctx, "latest_audit_checkpoint")
+	defer done()
+	var c models.AuditCheckpoint
+	err := GetDB().QueryRowContext(ctx, `
+		SELECT id, kind, last_event_id, last_hash, signature, prev_signature, created_at
+		FROM audit_checkpoints
+		WHERE ? = '' OR kind = ?
+		ORDER BY id DESC LIMIT 1
+	`, kind, kind).Scan(&c.ID, &c.Kind, &c.LastEventID, &c.LastHash, &c.Signature, &c.PrevSignature, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
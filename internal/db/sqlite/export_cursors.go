@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetExportCursor returns the saved position of a named exporter; found is false when none is stored
+func GetExportCursor(ctx context.Context, name string) (cursor int64, found bool, err error) {
+	ctx, done := observe(ctx, "get_export_cursor")
+	defer done()
+	err = GetDB().QueryRowContext(ctx, `SELECT cursor FROM export_cursors WHERE name = ?`, name).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return cursor, err == nil, err
+}
+
+func SetExportCursor(ctx context.Context, name string, cursor int64) error {
+	ctx, done := observe(ctx, "set_export_cursor")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, `
+		INSERT INTO export_cursors (name, cursor, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET cursor = excluded.cursor, updated_at = excluded.updated_at
+	`, name, cursor, time.Now().UTC().Format(timeLayout))
+	return err
+}
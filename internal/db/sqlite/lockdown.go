@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+)
+
+const lockdownColumns = `id, scope, target, reason, source, created_at, expires_at, lifted_at, lifted_by`
+
+// --- Lockdowns ---
+
+func InsertLockdown(ctx context.Context, l *models.Lockdown) error {
+	ctx, done := observe(ctx, "insert_lockdown")
+	defer done()
+	var expires interface{}
+	if l.ExpiresAt != nil {
+		expires = l.ExpiresAt.UTC().Format(timeLayout)
+	}
+
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO lockdowns (scope, target, reason, source, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, l.Scope, l.Target, l.Reason, l.Source, l.CreatedAt.UTC().Format(timeLayout), expires)
+	if err != nil {
+		return err
+	}
+	l.ID, err = res.LastInsertId()
+	return err
+}
+
+// LiftLockdown marks a lockdown as lifted; it returns sql.ErrNoRows if it was not active
+func LiftLockdown(ctx context.Context, id int64, liftedBy string, at time.Time) error {
+	ctx, done := observe(ctx, "lift_lockdown")
+	defer done()
+	res, err := GetDB().ExecContext(ctx, `
+		UPDATE lockdowns SET lifted_at = ?, lifted_by = ?
+		WHERE id = ? AND lifted_at IS NULL
+	`, at.UTC().Format(timeLayout), liftedBy, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetLockdown(ctx context.Context, id int64) (*models.Lockdown, error) {
+	ctx, done := observe(ctx, "get_lockdown")
+	defer done()
+	row := GetDB().QueryRowContext(ctx, `SELECT `+lockdownColumns+` FROM lockdowns WHERE id = ?`, id)
+	l, err := scanLockdown(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return l, err
+}
+
+// ListLockdowns returns lockdowns newest first; lifted ones only when includeLifted is set
+func ListLockdowns(ctx context.Context, includeLifted bool, limit int) ([]models.Lockdown, error) {
+	ctx, done := observe(ctx, "list_lockdowns")
+	defer done()
+	query := `SELECT ` + lockdownColumns + ` FROM lockdowns`
+	if !includeLifted {
+		query += ` WHERE lifted_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+
+	rows, err := GetDB().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lockdowns []models.Lockdown
+	for rows.Next() {
+		l, err := scanLockdown(rows)
+		if err != nil {
+			return nil, err
+		}
+		lockdowns = append(lockdowns, *l)
+	}
+	return lockdowns, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLockdown(s rowScanner) (*models.Lockdown, error) {
+	var l models.Lockdown
+	var expires, lifted sql.NullTime
+	if err := s.Scan(&l.ID, &l.Scope, &l.Target, &l.Reason, &l.Source, &l.CreatedAt, &expires, &lifted, &l.LiftedBy); err != nil {
+		return nil, err
+	}
+	if expires.Valid {
+		l.ExpiresAt = &expires.Time
+	}
+	if lifted.Valid {
+		l.LiftedAt = &lifted.Time
+	}
+	return &l, nil
+}
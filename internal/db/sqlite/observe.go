@@ -0,0 +1,33 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observe times query and, inside a traced request or job, records it as a child span. Run
+// the query with the returned context and call done when it has finished. Queries with no
+// span in ctx, such as the webhook and syslog pollers, are only timed so they do not flood
+// the exporter with one-span traces. Call it only in the function that issues the SQL;
+// wrappers that delegate to another query function are covered by that one.
+func observe(ctx context.Context, query string) (_ context.Context, done func()) {
+	stop := metrics.ObserveQuery(query)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, stop
+	}
+	ctx, span := tracing.Start(ctx, "sqlite "+query,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "sqlite"),
+			attribute.String("db.operation.name", query),
+		),
+	)
+	return ctx, func() {
+		span.End()
+		stop()
+	}
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"github.com/peithosecure/peitho-backend/internal/tracing/tracingtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	InitDB(filepath.Join(t.TempDir(), "observe.db"))
+	t.Cleanup(func() { _ = Close() })
+}
+
+func TestObserveSpans(t *testing.T) {
+	tests := []struct {
+		name   string
+		traced bool
+		run    func(ctx context.Context) error
+		want   []string
+	}{
+		{
+			name: "query inside a request", traced: true,
+			run:  func(ctx context.Context) error { return InsertEmailToken(ctx, "a@example.com", "tok", "verify") },
+			want: []string{"sqlite insert_email_token"},
+		},
+		{
+			name: "wrapper records only the query it delegates to", traced: true,
+			run: func(ctx context.Context) error {
+				_, _ = GetUsernameByToken(ctx, "missing")
+				return nil
+			},
+			want: []string{"sqlite get_username_by_token_and_type"},
+		},
+		{
+			name: "audit wrapper", traced: true,
+			run:  func(ctx context.Context) error { return LogAuditEvent(ctx, "alice", "login", "127.0.0.1", "test") },
+			want: []string{"sqlite insert_audit_event"},
+		},
+		{
+			name: "background query is not traced",
+			run:  func(ctx context.Context) error { return InsertEmailToken(ctx, "a@example.com", "tok", "verify") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			exp := tracingtest.RecordSpans(t)
+
+			ctx := context.Background()
+			var parentID trace.SpanID
+			if tt.traced {
+				var parent trace.Span
+				ctx, parent = tracing.Start(ctx, "request")
+				parentID = parent.SpanContext().SpanID()
+				defer parent.End()
+			}
+			if err := tt.run(ctx); err != nil {
+				t.Fatalf("query: %v", err)
+			}
+
+			var names []string
+			for _, s := range exp.GetSpans() {
+				if s.Name == "request" {
+					continue
+				}
+				names = append(names, s.Name)
+				if s.SpanKind != trace.SpanKindClient || s.Parent.SpanID() != parentID {
+					t.Errorf("%s: kind %v, parent %s; want a client child of the request span", s.Name, s.SpanKind, s.Parent.SpanID())
+				}
+				if !slices.Contains(s.Attributes, attribute.String("db.system.name", "sqlite")) {
+					t.Errorf("%s: missing db.system.name=sqlite in %v", s.Name, s.Attributes)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("query spans = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
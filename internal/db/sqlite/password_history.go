@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// --- Password history ---
+
+// ListPasswordHistory returns the newest limit password hashes stored for a user
+func ListPasswordHistory(ctx context.Context, userID, limit int) ([]string, error) {
+	ctx, done := observe(ctx, "list_password_history")
+	defer done()
+	rows, err := GetDB().QueryContext(ctx, `
+		SELECT hash FROM password_history
+		WHERE user_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
+
+// RecordPasswordChange stamps the user's password change, clears a forced reset and, when
+// hash is set, appends it to the history while keeping only the newest keep entries
+func RecordPasswordChange(ctx context.Context, userID int, hash string, keep int, at time.Time) error {
+	ctx, done := observe(ctx, "record_password_change")
+	defer done()
+	tx, err := GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET password_changed_at = ?, force_password_reset = 0 WHERE id = ?
+	`, at.UTC().Format(timeLayout), userID); err != nil {
+		return err
+	}
+	if hash != "" {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO password_history (user_id, hash, created_at) VALUES (?, ?, ?)
+		`, userID, hash, at.UTC().Format(timeLayout)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, userID, userID, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetForcePasswordReset flags a user to change their password at next login; it returns
+// sql.ErrNoRows if the user does not exist
+func SetForcePasswordReset(ctx context.Context, username string, force bool) error {
+	ctx, done := observe(ctx, "set_force_password_reset")
+	defer done()
+	flag := 0
+	if force {
+		flag = 1
+	}
+	res, err := GetDB().ExecContext(ctx, `UPDATE users SET force_password_reset = ? WHERE username = ?`, flag, username)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// backfillPasswordChangedAt starts the password age clock for existing accounts at upgrade
+// time, so enabling a maximum age does not expire every password at once
+func backfillPasswordChangedAt() error {
+	_, err := GetDB().Exec(`UPDATE users SET password_changed_at = ? WHERE password_changed_at IS NULL`,
+		time.Now().UTC().Format(timeLayout))
+	return err
+}
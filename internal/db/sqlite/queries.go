@@ -1,9 +1,11 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/peithosecure/peitho-backend/internal/db/models"
@@ -11,59 +13,69 @@ import (
 
 // --- User queries ---
 
-func GetUserByEmail(email string) (*models.User, error) {
-	row := DB.QueryRow(`
-		SELECT id, username, email, role, email_verified 
-		FROM users 
-		WHERE email = ?
-	`, email)
+const userColumns = `id, username, email, role, email_verified, password_changed_at, force_password_reset`
 
+func scanUser(s rowScanner) (*models.User, error) {
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.EmailVerified)
+	var changed sql.NullTime
+	if err := s.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.EmailVerified, &changed, &user.ForcePasswordReset); err != nil {
+		return nil, err
+	}
+	if changed.Valid {
+		user.PasswordChangedAt = &changed.Time
+	}
+	return &user, nil
+}
+
+func GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, done := observe(ctx, "get_user_by_email")
+	defer done()
+	row := DB.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email)
+	user, err := scanUser(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
-	return &user, err
+	return user, err
 }
 
-func GetUserByUsername(username string) (*models.User, error) {
-	row := DB.QueryRow(`
-		SELECT id, username, email, role, email_verified 
-		FROM users 
-		WHERE username = ?
-	`, username)
-
-	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.EmailVerified)
+func GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, done := observe(ctx, "get_user_by_username")
+	defer done()
+	row := DB.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, username)
+	user, err := scanUser(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
-	return &user, err
+	return user, err
 }
 
 // --- Email token logic ---
 
-func CreateVerificationToken(username, token string, expiresAt time.Time) error {
-	user, err := GetUserByUsername(username)
+func CreateVerificationToken(ctx context.Context, username, token string, expiresAt time.Time) error {
+	user, err := GetUserByUsername(ctx, username)
 	if err != nil || user == nil {
 		return errors.New("user not found")
 	}
-	return InsertEmailToken(user.Email, token, "verify")
+	return InsertEmailToken(ctx, user.Email, token, "verify")
 }
 
-func InsertEmailToken(email, token, tokenType string) error {
-	_, err := GetDB().Exec(`
+func InsertEmailToken(ctx context.Context, email, token, tokenType string) error {
+	ctx, done := observe(ctx, "insert_email_token")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, `
 		INSERT INTO email_tokens (email, token, type, created_at)
 		VALUES (?, ?, ?, datetime('now'))
 	`, email, token, tokenType)
 	return err
 }
 
-func GetUsernameByToken(token string) (string, error) {
-	return GetUsernameByTokenAndType(token, "verify")
+func GetUsernameByToken(ctx context.Context, token string) (string, error) {
+	return GetUsernameByTokenAndType(ctx, token, "verify")
 }
 
-func GetUsernameByTokenAndType(token, tokenType string) (string, error) {
+func GetUsernameByTokenAndType(ctx context.Context, token, tokenType string) (string, error) {
+	ctx, done := observe(ctx, "get_username_by_token_and_type")
+	defer done()
 	var email string
 	query := `
 		SELECT email FROM email_tokens
@@ -71,25 +83,28 @@ func GetUsernameByTokenAndType(token, tokenType string) (string, error) {
 		AND datetime(created_at, '+1 hour') > datetime('now')
 	`
 
-	err := GetDB().QueryRow(query, token, tokenType).Scan(&email)
+	err := GetDB().QueryRowContext(ctx, query, token, tokenType).Scan(&email)
 
 	if err != nil {
-		fmt.Printf("⚠️ Token lookup failed (%s): %v\n", tokenType, err)
+		slog.Debug("⚠️ Token lookup failed", "type", tokenType, "error", err)
 	} else {
-		fmt.Printf("✅ Token lookup success for: %s (type=%s)\n", email, tokenType)
+		slog.Debug("✅ Token lookup succeeded", "email", email, "type", tokenType)
 	}
-	fmt.Println("🕒 Backend UTC time:", time.Now().UTC().Format(time.RFC3339))
 
 	return email, err
 }
 
-func DeleteVerificationToken(token string) error {
-	_, err := GetDB().Exec(`DELETE FROM email_tokens WHERE token = ?`, token)
+func DeleteVerificationToken(ctx context.Context, token string) error {
+	ctx, done := observe(ctx, "delete_verification_token")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, `DELETE FROM email_tokens WHERE token = ?`, token)
 	return err
 }
 
-func MarkEmailVerified(username string) error {
-	_, err := GetDB().Exec(`
+func MarkEmailVerified(ctx context.Context, username string) error {
+	ctx, done := observe(ctx, "mark_email_verified")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, `
 		UPDATE users SET email_verified = 1 WHERE username = ?
 	`, username)
 	return err
@@ -97,23 +112,101 @@ func MarkEmailVerified(username string) error {
 
 // --- Audit Logging (Unified) ---
 
-func LogAuditEvent(username, eventType, ip, userAgent string) error {
-	now := time.Now().UTC().Format("2006-01-02 15:04:05")
-	_, err := GetDB().Exec(`
-		INSERT INTO audit_events (username, event_type, ip_address, user_agent, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, username, eventType, ip, userAgent, now)
-	return err
+// LogAuditEvent appends an event without outcome or request context to the hash-chained audit log
+func LogAuditEvent(ctx context.Context, username, eventType, ip, userAgent string) error {
+	return InsertAuditEvent(ctx, &models.AuditEvent{
+		Username:  username,
+		EventType: eventType,
+		IPAddress: ip,
+		UserAgent: userAgent,
+	})
+}
+
+// InsertAuditEvent appends e to the hash-chained audit log, stamping CreatedAt when unset
+func InsertAuditEvent(ctx context.Context, e *models.AuditEvent) error {
+	ctx, done := observe(ctx, "insert_audit_event")
+	defer done()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	auditChainMu.Lock()
+	defer auditChainMu.Unlock()
+	return insertChainedAuditEvent(ctx, e)
+}
+
+// AuditFilter narrows an audit_events query. Zero values are ignored.
+type AuditFilter struct {
+	Username   string
+	EventTypes []string
+	IP         string
+	Outcome    string
+	Since      time.Time
+	Until      time.Time
+	AfterID    int64 // cursor: rows past this id in the requested order
+	Ascending  bool
+	Limit      int
+}
+
+func (f AuditFilter) where() (string, []interface{}) {
+	var where []string
+	var args []interface{}
+
+	if f.Username != "" {
+		where = append(where, "username = ?")
+		args = append(args, f.Username)
+	}
+	if len(f.EventTypes) > 0 {
+		where = append(where, "event_type IN (?"+strings.Repeat(", ?", len(f.EventTypes)-1)+")")
+		for _, t := range f.EventTypes {
+			args = append(args, t)
+		}
+	}
+	if f.IP != "" {
+		where = append(where, "ip_address = ?")
+		args = append(args, f.IP)
+	}
+	if f.Outcome != "" {
+		where = append(where, "outcome = ?")
+		args = append(args, f.Outcome)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.Since.UTC().Format(timeLayout))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "created_at <= ?")
+		args = append(args, f.Until.UTC().Format(timeLayout))
+	}
+	if f.AfterID > 0 {
+		if f.Ascending {
+			where = append(where, "id > ?")
+		} else {
+			where = append(where, "id < ?")
+		}
+		args = append(args, f.AfterID)
+	}
+
+	if len(where) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
 }
 
-func GetAuditEventsByUsername(username string, limit int) ([]models.AuditEvent, error) {
-	rows, err := DB.Query(`
-		SELECT id, username, event_type, ip_address, user_agent, created_at
-		FROM audit_events
-		WHERE username = ?
-		ORDER BY created_at DESC
-		LIMIT ?
-	`, username, limit)
+// QueryAuditEvents returns one page of audit events ordered by id (newest first unless Ascending)
+func QueryAuditEvents(ctx context.Context, f AuditFilter) ([]models.AuditEvent, error) {
+	ctx, done := observe(ctx, "query_audit_events")
+	defer done()
+	where, args := f.where()
+	order := "DESC"
+	if f.Ascending {
+		order = "ASC"
+	}
+	query := `SELECT ` + auditColumns + ` FROM audit_events` +
+		where + " ORDER BY id " + order + " LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := GetDB().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -121,13 +214,40 @@ func GetAuditEventsByUsername(username string, limit int) ([]models.AuditEvent,
 
 	var events []models.AuditEvent
 	for rows.Next() {
-		var e models.AuditEvent
-		var ts string
-		if err := rows.Scan(&e.ID, &e.Username, &e.EventType, &e.IPAddress, &e.UserAgent, &ts); err != nil {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// CountAuditEventsByDay aggregates matching events per UTC day and event type, newest day first.
+// Cursor, ordering and limit fields of the filter are ignored.
+func CountAuditEventsByDay(ctx context.Context, f AuditFilter) ([]models.AuditDailyCount, error) {
+	ctx, done := observe(ctx, "count_audit_events_by_day")
+	defer done()
+	f.AfterID = 0
+	where, args := f.where()
+	rows, err := GetDB().QueryContext(ctx, `
+		SELECT date(created_at) AS day, event_type, COUNT(*)
+		FROM audit_events`+where+`
+		GROUP BY day, event_type
+		ORDER BY day DESC, event_type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.AuditDailyCount
+	for rows.Next() {
+		var c models.AuditDailyCount
+		if err := rows.Scan(&c.Day, &c.EventType, &c.Count); err != nil {
 			return nil, err
 		}
-		e.CreatedAt, _ = time.ParseInLocation("2006-01-02 15:04:05", ts, time.UTC)
-		events = append(events, e)
+		counts = append(counts, c)
 	}
-	return events, nil
+	return counts, rows.Err()
 }
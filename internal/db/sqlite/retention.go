@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Retention helpers take table and column names from the fixed policy list in
+// internal/retention, never from user input, so they are formatted into the SQL.
+
+const pruneBatchSize = 1000
+
+// PruneBoundary returns the highest id among the oldest rows that violate a policy:
+// rows whose timeCol is before cutoff, plus any rows beyond the newest maxRows.
+// Zero cutoff or maxRows disables that rule. It returns 0 when nothing is due.
+func PruneBoundary(ctx context.Context, table, idCol, timeCol string, cutoff time.Time, maxRows int) (int64, error) {
+	ctx, done := observe(ctx, "prune_boundary")
+	defer done()
+	var boundary int64
+
+	if !cutoff.IsZero() {
+		var id sql.NullInt64
+		err := GetDB().QueryRowContext(ctx, fmt.Sprintf(`SELECT MAX(%s) FROM %s WHERE %s < ?`, idCol, table, timeCol),
+			cutoff.UTC().Format(timeLayout)).Scan(&id)
+		if err != nil {
+			return 0, err
+		}
+		boundary = id.Int64
+	}
+
+	if maxRows > 0 {
+		var id int64
+		err := GetDB().QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM %s ORDER BY %s DESC LIMIT 1 OFFSET ?`, idCol, table, idCol),
+			maxRows).Scan(&id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+		if id > boundary {
+			boundary = id
+		}
+	}
+	return boundary, nil
+}
+
+// ArchiveRows writes every row with idCol <= upTo as one JSON object per line, oldest first
+func ArchiveRows(ctx context.Context, table, idCol string, upTo int64, w io.Writer) (int, error) {
+	ctx, done := observe(ctx, "archive_rows")
+	defer done()
+	rows, err := GetDB().QueryContext(ctx, fmt.Sprintf(`SELECT * FROM %s WHERE %s <= ? ORDER BY %s`, table, idCol, idCol), upTo)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	n := 0
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+
+		record := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			if b, ok := values[i].([]byte); ok {
+				record[col] = string(b)
+			} else {
+				record[col] = values[i]
+			}
+		}
+		if err := enc.Encode(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// DeleteRowsUpTo removes rows with idCol <= upTo in small batches so writers are never blocked for long
+func DeleteRowsUpTo(ctx context.Context, table, idCol string, upTo int64) (int64, error) {
+	ctx, done := observe(ctx, "delete_rows_up_to")
+	defer done()
+	var total int64
+	for {
+		res, err := GetDB().ExecContext(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE %s IN (SELECT %s FROM %s WHERE %s <= ? ORDER BY %s LIMIT ?)`,
+			table, idCol, idCol, table, idCol, idCol), upTo, pruneBatchSize)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if n < pruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// --- Vacuum ---
+
+// AutoVacuumMode reports PRAGMA auto_vacuum: 0 none, 1 full, 2 incremental
+func AutoVacuumMode(ctx context.Context) (int, error) {
+	ctx, done := observe(ctx, "auto_vacuum_mode")
+	defer done()
+	var mode int
+	err := GetDB().QueryRowContext(ctx, `PRAGMA auto_vacuum;`).Scan(&mode)
+	return mode, err
+}
+
+// EnableIncrementalVacuum switches the file to incremental auto-vacuum. SQLite only applies
+// the change after a full VACUUM, which this runs once. Both statements must run on the same
+// connection: the pending mode is connection state, and a VACUUM on another pooled connection
+// would leave the file as it was.
+func EnableIncrementalVacuum(ctx context.Context) error {
+	ctx, done := observe(ctx, "enable_incremental_vacuum")
+	defer done()
+	conn, err := GetDB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL;`); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `VACUUM;`)
+	return err
+}
+
+func Vacuum(ctx context.Context) error {
+	ctx, done := observe(ctx, "vacuum")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, `VACUUM;`)
+	return err
+}
+
+// IncrementalVacuum releases up to pages free pages back to the filesystem (0 = all)
+func IncrementalVacuum(ctx context.Context, pages int) error {
+	ctx, done := observe(ctx, "incremental_vacuum")
+	defer done()
+	_, err := GetDB().ExecContext(ctx, fmt.Sprintf(`PRAGMA incremental_vacuum(%d);`, pages))
+	return err
+}
@@ -1,23 +1,39 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
 
-// InitDB initializes the SQLite connection using env or fallback path
-func InitDB() {
-	dbPath := os.Getenv("PEITHO_SQLITE_PATH")
-	if dbPath == "" {
-		dbPath = "peitho_secure.db"
-	}
+// SchemaVersion is stored in PRAGMA user_version once InitDB has migrated the database. Bump
+// it whenever createTables or migrateColumns changes, so an older binary refuses to run
+// against a newer database.
+const SchemaVersion = 1
+
+// requiredTables must exist for the server to run
+var requiredTables = []string{
+	"users",
+	"roast_logs",
+	"email_tokens",
+	"audit_events",
+	"audit_checkpoints",
+	"export_cursors",
+	"trace_events",
+	"lockdowns",
+	"webhook_subscriptions",
+	"webhook_deliveries",
+	"password_history",
+}
 
+// InitDB initializes the SQLite connection to the database file at dbPath
+func InitDB(dbPath string) {
 	var err error
 	DB, err = sql.Open("sqlite3", dbPath)
 	if err != nil {
@@ -29,8 +45,59 @@ func InitDB() {
 		log.Fatalf("❌ Failed to set WAL mode: %v", err)
 	}
 
+	var version int
+	if err := DB.QueryRow(`PRAGMA user_version;`).Scan(&version); err != nil {
+		log.Fatalf("❌ Failed to read schema version: %v", err)
+	}
+	if version > SchemaVersion {
+		log.Fatalf("🚨 Database schema version %d is newer than this build supports (%d). Upgrade peitho-server.", version, SchemaVersion)
+	}
+
 	createTables()
+	migrateColumns()
 	verifySchema()
+
+	if _, err := DB.Exec(fmt.Sprintf(`PRAGMA user_version = %d;`, SchemaVersion)); err != nil {
+		log.Fatalf("❌ Failed to record schema version: %v", err)
+	}
+}
+
+// Ping checks that the database answers and carries the schema this build expects. It backs
+// the readiness probe.
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database not initialized")
+	}
+	if err := DB.PingContext(ctx); err != nil {
+		return err
+	}
+	var version int
+	if err := DB.QueryRowContext(ctx, `PRAGMA user_version;`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("schema version %d, expected %d", version, SchemaVersion)
+	}
+	missing, err := missingTable(ctx)
+	if err != nil {
+		return err
+	}
+	if missing != "" {
+		return fmt.Errorf("missing required table %s", missing)
+	}
+	return nil
+}
+
+// Close checkpoints the write-ahead log into the main database file and closes the
+// connection, so the next start does not begin with WAL recovery
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	if _, err := DB.Exec(`PRAGMA wal_checkpoint(TRUNCATE);`); err != nil {
+		log.Printf("⚠️ WAL checkpoint failed: %v", err)
+	}
+	return DB.Close()
 }
 
 // createTables defines all required tables for PeithoSecure Lite
@@ -64,6 +131,70 @@ func createTables() {
 			user_agent TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);`,
+		`CREATE TABLE IF NOT EXISTS audit_checkpoints (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			last_event_id INTEGER NOT NULL,
+			last_hash TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS export_cursors (
+			name TEXT PRIMARY KEY,
+			cursor INTEGER NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS trace_events (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			id TEXT NOT NULL UNIQUE,
+			actor TEXT NOT NULL,
+			event TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			lock INTEGER NOT NULL DEFAULT 0,
+			message TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_trace_events_created_at ON trace_events (created_at);`,
+		`CREATE TABLE IF NOT EXISTS lockdowns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			scope TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP,
+			lifted_at TIMESTAMP,
+			lifted_by TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			events TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			replay_of INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			delivered_at TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);`,
+		`CREATE TABLE IF NOT EXISTS password_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			hash TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history (user_id, id);`,
 	}
 
 	for _, stmt := range stmts {
@@ -75,23 +206,98 @@ func createTables() {
 	log.Println("✅ Database tables initialized successfully.")
 }
 
+// migrateColumns adds columns introduced after a table was first shipped
+func migrateColumns() {
+	columns := []struct {
+		table, column, ddl string
+		backfill           func() error
+	}{
+		{"trace_events", "scope", `ALTER TABLE trace_events ADD COLUMN scope TEXT NOT NULL DEFAULT ''`, nil},
+		{"trace_events", "target", `ALTER TABLE trace_events ADD COLUMN target TEXT NOT NULL DEFAULT ''`, nil},
+		{"audit_events", "prev_hash", `ALTER TABLE audit_events ADD COLUMN prev_hash TEXT NOT NULL DEFAULT ''`, nil},
+		{"audit_events", "outcome", `ALTER TABLE audit_events ADD COLUMN outcome TEXT NOT NULL DEFAULT ''`, nil},
+		{"audit_events", "reason", `ALTER TABLE audit_events ADD COLUMN reason TEXT NOT NULL DEFAULT ''`, nil},
+		{"audit_events", "request_id", `ALTER TABLE audit_events ADD COLUMN request_id TEXT NOT NULL DEFAULT ''`, nil},
+		{"audit_events", "subject", `ALTER TABLE audit_events ADD COLUMN subject TEXT NOT NULL DEFAULT ''`, nil},
+		// hash last: its backfill reads every other audit column
+		{"audit_events", "hash", `ALTER TABLE audit_events ADD COLUMN hash TEXT NOT NULL DEFAULT ''`, backfillAuditChain},
+		{"audit_checkpoints", "kind", `ALTER TABLE audit_checkpoints ADD COLUMN kind TEXT NOT NULL DEFAULT 'head'`, nil},
+		{"audit_checkpoints", "prev_signature", `ALTER TABLE audit_checkpoints ADD COLUMN prev_signature TEXT NOT NULL DEFAULT ''`, nil},
+		{"users", "password_changed_at", `ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP`, backfillPasswordChangedAt},
+		{"users", "force_password_reset", `ALTER TABLE users ADD COLUMN force_password_reset INTEGER NOT NULL DEFAULT 0`, nil},
+	}
+
+	for _, c := range columns {
+		exists, err := columnExists(c.table, c.column)
+		if err != nil {
+			log.Fatalf("❌ Failed to inspect %s.%s: %v", c.table, c.column, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := DB.Exec(c.ddl); err != nil {
+			log.Fatalf("❌ Failed to add column %s.%s: %v", c.table, c.column, err)
+		}
+		log.Printf("🧬 Migrated schema: added %s.%s", c.table, c.column)
+		if c.backfill != nil {
+			if err := c.backfill(); err != nil {
+				log.Fatalf("❌ Failed to backfill %s.%s: %v", c.table, c.column, err)
+			}
+		}
+	}
+}
+
+func columnExists(table, column string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf(`PRAGMA table_info(%s);`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // verifySchema performs a runtime sanity check for table presence
 func verifySchema() {
-	requiredTables := []string{
-		"users",
-		"roast_logs",
-		"email_tokens",
-		"audit_events",
+	missing, err := missingTable(context.Background())
+	if err != nil {
+		log.Fatalf("❌ Failed to verify schema: %v", err)
 	}
+	if missing != "" {
+		log.Fatalf("🚨 Missing required table: %s. Please reset your database.", missing)
+	}
+	log.Println("🧪 Schema verification passed: All required tables exist.")
+}
 
+// missingTable returns the first required table that does not exist, or ""
+func missingTable(ctx context.Context) (string, error) {
 	for _, table := range requiredTables {
-		query := fmt.Sprintf(`SELECT name FROM sqlite_master WHERE type='table' AND name='%s';`, table)
 		var name string
-		if err := DB.QueryRow(query).Scan(&name); err != nil {
-			log.Fatalf("🚨 Missing required table: %s. Please reset your database.", table)
+		err := DB.QueryRowContext(ctx, `SELECT name FROM sqlite_master WHERE type='table' AND name=?;`, table).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return table, nil
+		}
+		if err != nil {
+			return "", err
 		}
 	}
-	log.Println("🧪 Schema verification passed: All required tables exist.")
+	return "", nil
 }
 
 // GetDB exposes the active SQLite DB instance
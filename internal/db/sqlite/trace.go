@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// TraceFilter narrows a trace_events query. Zero values are ignored.
+type TraceFilter struct {
+	Actor     string
+	Severity  string
+	Since     time.Time
+	Until     time.Time
+	BeforeSeq int64 // cursor: only return rows older than this sequence number
+	AfterSeq  int64 // cursor: only return rows newer than this sequence number
+	Ascending bool
+	Limit     int
+}
+
+// --- Trace events ---
+
+func InsertTraceEvent(ctx context.Context, t *models.TraceLog) error {
+	ctx, done := observe(ctx, "insert_trace_event")
+	defer done()
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO trace_events (id, actor, event, severity, lock, scope, target, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.ID, t.Actor, t.Event, t.Severity, t.Lock, t.Scope, t.Target, t.Message, t.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return err
+	}
+	t.Seq, err = res.LastInsertId()
+	return err
+}
+
+func QueryTraceEvents(ctx context.Context, f TraceFilter) ([]models.TraceLog, error) {
+	ctx, done := observe(ctx, "query_trace_events")
+	defer done()
+	var where []string
+	var args []interface{}
+
+	if f.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Severity != "" {
+		where = append(where, "severity = ?")
+		args = append(args, f.Severity)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.Since.UTC().Format(timeLayout))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "created_at <= ?")
+		args = append(args, f.Until.UTC().Format(timeLayout))
+	}
+	if f.BeforeSeq > 0 {
+		where = append(where, "seq < ?")
+		args = append(args, f.BeforeSeq)
+	}
+	if f.AfterSeq > 0 {
+		where = append(where, "seq > ?")
+		args = append(args, f.AfterSeq)
+	}
+
+	query := `SELECT seq, id, actor, event, severity, lock, scope, target, message, created_at FROM trace_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if f.Ascending {
+		query += " ORDER BY seq ASC LIMIT ?"
+	} else {
+		query += " ORDER BY seq DESC LIMIT ?"
+	}
+	args = append(args, f.Limit)
+
+	rows, err := GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traces []models.TraceLog
+	for rows.Next() {
+		var t models.TraceLog
+		if err := rows.Scan(&t.Seq, &t.ID, &t.Actor, &t.Event, &t.Severity, &t.Lock, &t.Scope, &t.Target, &t.Message, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		traces = append(traces, t)
+	}
+	return traces, rows.Err()
+}
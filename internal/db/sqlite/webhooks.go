@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+)
+
+const deliveryColumns = `id, subscription_id, event_type, payload, status, attempts, response_code, last_error, replay_of, next_attempt_at, created_at, delivered_at`
+
+// --- Webhook subscriptions ---
+
+func InsertWebhookSubscription(ctx context.Context, s *models.WebhookSubscription) error {
+	ctx, done := observe(ctx, "insert_webhook_subscription")
+	defer done()
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, events, secret, active, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, s.URL, strings.Join(s.Events, ","), s.Secret, s.Active, s.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return err
+	}
+	s.ID, err = res.LastInsertId()
+	return err
+}
+
+func ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ctx, done := observe(ctx, "list_webhook_subscriptions")
+	defer done()
+	rows, err := GetDB().QueryContext(ctx, `
+		SELECT id, url, events, secret, active, created_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.WebhookSubscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *s)
+	}
+	return subs, rows.Err()
+}
+
+func GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	ctx, done := observe(ctx, "get_webhook_subscription")
+	defer done()
+	row := GetDB().QueryRowContext(ctx, `
+		SELECT id, url, events, secret, active, created_at
+		FROM webhook_subscriptions
+		WHERE id = ?
+	`, id)
+	s, err := scanWebhookSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// DeleteWebhookSubscription removes a subscription and its delivery log
+func DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	ctx, done := observe(ctx, "delete_webhook_subscription")
+	defer done()
+	tx, err := GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE subscription_id = ?`, id); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func scanWebhookSubscription(s rowScanner) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	var events string
+	if err := s.Scan(&sub.ID, &sub.URL, &events, &sub.Secret, &sub.Active, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	if events != "" {
+		sub.Events = strings.Split(events, ",")
+	}
+	return &sub, nil
+}
+
+// --- Webhook deliveries ---
+
+func InsertWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, done := observe(ctx, "insert_webhook_delivery")
+	defer done()
+	res, err := GetDB().ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, replay_of, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, d.SubscriptionID, d.EventType, d.Payload, d.Status, d.ReplayOf,
+		d.NextAttemptAt.UTC().Format(timeLayout), d.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return err
+	}
+	d.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateWebhookDelivery stores the outcome of a delivery attempt
+func UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, done := observe(ctx, "update_webhook_delivery")
+	defer done()
+	var delivered interface{}
+	if d.DeliveredAt != nil {
+		delivered = d.DeliveredAt.UTC().Format(timeLayout)
+	}
+	_, err := GetDB().ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_code = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?
+	`, d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt.UTC().Format(timeLayout), delivered, d.ID)
+	return err
+}
+
+// DueWebhookDeliveries returns pending deliveries whose next attempt is at or before now
+func DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	ctx, done := observe(ctx, "due_webhook_deliveries")
+	defer done()
+	return queryWebhookDeliveries(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`, now.UTC().Format(timeLayout), limit)
+}
+
+func ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	ctx, done := observe(ctx, "list_webhook_deliveries")
+	defer done()
+	return queryWebhookDeliveries(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, subscriptionID, limit)
+}
+
+func GetWebhookDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	ctx, done := observe(ctx, "get_webhook_delivery")
+	defer done()
+	deliveries, err := queryWebhookDeliveries(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+func queryWebhookDeliveries(ctx context.Context, query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var delivered sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.ResponseCode, &d.LastError, &d.ReplayOf, &d.NextAttemptAt, &d.CreatedAt, &delivered); err != nil {
+			return nil, err
+		}
+		if delivered.Valid {
+			d.DeliveredAt = &delivered.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
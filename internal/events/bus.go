@@ -0,0 +1,250 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+)
+
+// Type identifies a security event
+type Type string
+
+// Security events
+const (
+	LoginFailed            Type = "login_failed"
+	RateLimited            Type = "rate_limited"
+	UserLockedOut          Type = "user_locked_out"
+	TamperDetected         Type = "tamper_detected"
+	PasswordResetRequested Type = "password_reset_requested"
+	PasswordReset          Type = "password_reset"
+	LicenseInvalid         Type = "license_invalid"
+	SecurityAlert          Type = "security_alert"
+	UserActivity           Type = "user_activity"
+)
+
+// Account lifecycle events. Handlers already write their own audit rows for
+// these, so the builtin audit and trace subscribers skip them.
+const (
+	UserRegistered      Type = "user_registered"
+	EmailVerified       Type = "email_verified"
+	PasswordSet         Type = "password_set"
+	AccountDeleted      Type = "account_deleted"
+	PasswordResetForced Type = "password_reset_forced"
+)
+
+// SecurityTypes lists the security events
+var SecurityTypes = []Type{
+	LoginFailed, RateLimited, UserLockedOut, TamperDetected, PasswordResetRequested,
+	PasswordReset, LicenseInvalid, SecurityAlert, UserActivity,
+}
+
+// LifecycleTypes lists the account lifecycle events
+var LifecycleTypes = []Type{UserRegistered, EmailVerified, PasswordSet, AccountDeleted, PasswordResetForced}
+
+// Known reports whether t is one of the declared event types
+func Known(t Type) bool {
+	for _, list := range [][]Type{SecurityTypes, LifecycleTypes} {
+		for _, known := range list {
+			if t == known {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Event is a security-relevant occurrence published on the bus
+type Event struct {
+	Type      Type
+	Username  string
+	IP        string
+	UserAgent string
+	Severity  string // trace severity; builtin subscribers pick a default per type when empty
+	Reason    string
+	Time      time.Time
+}
+
+// Handler consumes events
+type Handler func(Event)
+
+// OverflowPolicy decides what an async subscriber does when its queue is full
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the event being published
+	DropNewest OverflowPolicy = iota
+	// Block waits up to AsyncOptions.BlockTimeout for room, then drops
+	Block
+)
+
+// AsyncOptions configures the queue in front of an async subscriber
+type AsyncOptions struct {
+	Buffer       int
+	Policy       OverflowPolicy
+	BlockTimeout time.Duration
+}
+
+type subscription struct {
+	name    string
+	types   map[Type]bool // nil means every type
+	handler Handler
+	queue   chan Event // nil for synchronous subscribers
+	opts    AsyncOptions
+}
+
+// Bus fans published events out to subscribers
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscription
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{}
+}
+
+var defaultBus = New()
+
+// Default returns the process-wide bus
+func Default() *Bus { return defaultBus }
+
+// Publish sends an event on the default bus
+func Publish(ev Event) { defaultBus.Publish(ev) }
+
+// Subscribe registers a synchronous subscriber on the default bus
+func Subscribe(name string, h Handler, types ...Type) { defaultBus.Subscribe(name, h, types...) }
+
+// SubscribeAsync registers a queued subscriber on the default bus
+func SubscribeAsync(name string, h Handler, opts AsyncOptions, types ...Type) {
+	defaultBus.SubscribeAsync(name, h, opts, types...)
+}
+
+// Close drains the default bus
+func Close() { defaultBus.Close() }
+
+// Subscribe registers h to run inline in Publish. Keep synchronous handlers cheap.
+// With no types given, h receives every event.
+func (b *Bus) Subscribe(name string, h Handler, types ...Type) {
+	b.add(&subscription{name: name, types: typeSet(types), handler: h})
+}
+
+// SubscribeAsync registers h behind a bounded queue drained by its own goroutine
+func (b *Bus) SubscribeAsync(name string, h Handler, opts AsyncOptions, types ...Type) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 128
+	}
+	if opts.Policy == Block && opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = 100 * time.Millisecond
+	}
+
+	sub := &subscription{
+		name:    name,
+		types:   typeSet(types),
+		handler: h,
+		queue:   make(chan Event, opts.Buffer),
+		opts:    opts,
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for ev := range sub.queue {
+			deliver(sub, ev)
+		}
+	}()
+	b.add(sub)
+}
+
+// Publish delivers ev to every interested subscriber. It never blocks longer
+// than the largest BlockTimeout among async subscribers.
+func (b *Bus) Publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	for _, sub := range b.subs {
+		if sub.types != nil && !sub.types[ev.Type] {
+			continue
+		}
+		if sub.queue == nil {
+			deliver(sub, ev)
+			continue
+		}
+		b.enqueue(sub, ev)
+	}
+}
+
+// Close stops accepting events and waits for async subscribers to drain their queues
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		if sub.queue != nil {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+func (b *Bus) add(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, sub)
+}
+
+func (b *Bus) enqueue(sub *subscription, ev Event) {
+	select {
+	case sub.queue <- ev:
+		return
+	default:
+	}
+
+	if sub.opts.Policy == Block {
+		timer := time.NewTimer(sub.opts.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case sub.queue <- ev:
+			return
+		case <-timer.C:
+		}
+	}
+
+	metrics.EventsDropped.WithLabelValues(sub.name).Inc()
+	log.Printf("⚠️ Event bus: subscriber %s is backed up, dropped %s", sub.name, ev.Type)
+}
+
+func deliver(sub *subscription, ev Event) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("💥 Event bus: subscriber %s panicked on %s: %v", sub.name, ev.Type, rec)
+		}
+	}()
+	sub.handler(ev)
+}
+
+func typeSet(types []Type) map[Type]bool {
+	if len(types) == 0 {
+		return nil
+	}
+	set := make(map[Type]bool, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
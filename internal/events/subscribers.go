@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"log"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/trace"
+)
+
+// defaultSeverity is the trace severity used when a publisher leaves it empty
+var defaultSeverity = map[Type]string{
+	LoginFailed:            trace.SeverityLow,
+	RateLimited:            trace.SeverityMedium,
+	UserLockedOut:          trace.SeverityMedium,
+	TamperDetected:         trace.SeverityHigh,
+	PasswordResetRequested: trace.SeverityLow,
+	PasswordReset:          trace.SeverityMedium,
+	LicenseInvalid:         trace.SeverityHigh,
+	SecurityAlert:          trace.SeverityMedium,
+	UserActivity:           trace.SeverityLow,
+}
+
+// RegisterBuiltins wires the audit, trace and metrics subscribers onto the default bus
+func RegisterBuiltins() {
+	Subscribe("metrics", countEvent)
+	SubscribeAsync("audit", auditEvent, AsyncOptions{Buffer: 256, Policy: Block}, auditTypes()...)
+	SubscribeAsync("trace", traceEvent, AsyncOptions{Buffer: 256, Policy: DropNewest}, SecurityTypes...)
+}
+
+func countEvent(ev Event) {
+	metrics.SecurityEvents.WithLabelValues(string(ev.Type)).Inc()
+}
+
+// handlerAudited are security events whose handlers write a richer audit row
+// (outcome, request id, subject) themselves
+var handlerAudited = map[Type]bool{
+	LoginFailed:            true,
+	PasswordResetRequested: true,
+	PasswordReset:          true,
+}
+
+func auditTypes() []Type {
+	var types []Type
+	for _, t := range SecurityTypes {
+		if !handlerAudited[t] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func auditEvent(ev Event) {
+	username := ev.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	err := sqlite.InsertAuditEvent(context.Background(), &models.AuditEvent{
+		Username:  username,
+		EventType: string(ev.Type),
+		IPAddress: ev.IP,
+		UserAgent: ev.UserAgent,
+		Reason:    ev.Reason,
+	})
+	if err != nil {
+		log.Printf("⚠️ Audit subscriber failed for %s: %v", ev.Type, err)
+	}
+}
+
+// traceEvent records the event in the trace engine. Lockdowns triggered by the
+// severity threshold are scoped to the offending IP, never the whole server. Usernames on
+// these events come from unauthenticated request bodies, so they never pick the target:
+// otherwise anyone could lock any account by failing its logins.
+func traceEvent(ev Event) {
+	severity := ev.Severity
+	if severity == "" {
+		severity = defaultSeverity[ev.Type]
+	}
+
+	actor := "USER"
+	if ev.Type == TamperDetected || ev.Type == RateLimited || ev.Type == UserLockedOut {
+		actor = "HACKER"
+	}
+
+	var scope, target string
+	if ev.IP != "" {
+		scope, target = lockdown.ScopeIP, ev.IP
+	}
+
+	message := ev.Reason
+	if ev.Username != "" {
+		message = ev.Username + ": " + message
+	}
+
+	if _, err := trace.Record(context.Background(), trace.Event{
+		Actor:     actor,
+		Event:     string(ev.Type),
+		Severity:  severity,
+		Scope:     scope,
+		Target:    target,
+		Message:   message,
+		Timestamp: ev.Time,
+	}); err != nil {
+		log.Printf("⚠️ Trace subscriber failed for %s: %v", ev.Type, err)
+	}
+}
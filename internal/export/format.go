@@ -0,0 +1,196 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatCEF   = "cef"
+)
+
+const (
+	cefVendor  = "PeithoSecure"
+	cefProduct = "Peitho Lite"
+	cefVersion = "1.0.0"
+)
+
+// Writer encodes records onto an output stream
+type Writer interface {
+	Write(Record) error
+	Flush() error
+}
+
+// ContentType returns the MIME type served for a format
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// NewWriter returns a writer for format ("jsonl", "csv" or "cef")
+func NewWriter(format string, out io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		bw := bufio.NewWriter(out)
+		return &jsonlWriter{buf: bw, enc: json.NewEncoder(bw)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(out)}, nil
+	case FormatCEF:
+		return &cefWriter{buf: bufio.NewWriter(out)}, nil
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(r Record) error { return j.enc.Encode(r) }
+func (j *jsonlWriter) Flush() error         { return j.buf.Flush() }
+
+var csvHeader = []string{"source", "id", "time", "type", "username", "actor", "ip", "user_agent", "severity", "message", "hash", "outcome", "reason", "request_id", "subject"}
+
+type csvWriter struct {
+	w      *csv.Writer
+	headed bool
+}
+
+func (c *csvWriter) Write(r Record) error {
+	if !c.headed {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headed = true
+	}
+	return c.w.Write([]string{
+		r.Source,
+		strconv.FormatInt(r.ID, 10),
+		r.Time.UTC().Format(time.RFC3339),
+		csvSafe(r.Type),
+		csvSafe(r.Username),
+		csvSafe(r.Actor),
+		csvSafe(r.IP),
+		csvSafe(r.UserAgent),
+		r.Severity,
+		csvSafe(r.Message),
+		r.Hash,
+		r.Outcome,
+		csvSafe(r.Reason),
+		csvSafe(r.RequestID),
+		csvSafe(r.Subject),
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.headed {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headed = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvSafe neutralises cells a spreadsheet would evaluate as a formula
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+type cefWriter struct {
+	buf *bufio.Writer
+}
+
+func (c *cefWriter) Write(r Record) error {
+	if _, err := c.buf.WriteString(CEFLine(r)); err != nil {
+		return err
+	}
+	return c.buf.WriteByte('\n')
+}
+
+func (c *cefWriter) Flush() error { return c.buf.Flush() }
+
+// CEFLine renders a record as a single ArcSight CEF:0 line
+func CEFLine(r Record) string {
+	ext := []string{
+		"rt=" + strconv.FormatInt(r.Time.UnixMilli(), 10),
+		"externalId=" + strconv.FormatInt(r.ID, 10),
+		"cat=" + cefValue(r.Source),
+	}
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefValue(value))
+		}
+	}
+	add("suser", r.Username)
+	add("suid", r.Subject)
+	add("src", r.IP)
+	add("requestClientApplication", r.UserAgent)
+	add("msg", r.Message)
+	add("outcome", r.Outcome)
+	add("reason", r.Reason)
+	if r.Actor != "" {
+		add("cs1Label", "actor")
+		add("cs1", r.Actor)
+	}
+	if r.Hash != "" {
+		add("cs2Label", "chainHash")
+		add("cs2", r.Hash)
+	}
+	if r.RequestID != "" {
+		add("cs3Label", "requestId")
+		add("cs3", r.RequestID)
+	}
+
+	return strings.Join([]string{
+		"CEF:0",
+		cefHeader(cefVendor),
+		cefHeader(cefProduct),
+		cefHeader(cefVersion),
+		cefHeader(r.Type),
+		cefHeader(strings.ReplaceAll(r.Type, "_", " ")),
+		strconv.Itoa(cefSeverity(r.Severity)),
+		strings.Join(ext, " "),
+	}, "|")
+}
+
+// cefSeverity maps trace severities onto CEF's 0-10 scale; audit rows carry none and map to 3
+func cefSeverity(s string) int {
+	switch s {
+	case "critical":
+		return 10
+	case "high":
+		return 8
+	case "medium":
+		return 5
+	default:
+		return 3
+	}
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+func cefHeader(v string) string { return cefHeaderEscaper.Replace(v) }
+func cefValue(v string) string  { return cefValueEscaper.Replace(v) }
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCEFLine(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		record Record
+		header []string // the seven pipe-delimited header fields after CEF:0
+		ext    []string // extension entries that must appear
+	}{
+		{
+			name:   "plain audit row",
+			record: Record{Source: SourceAudit, ID: 7, Time: at, Type: "login_success", Username: "alice", Outcome: "success"},
+			header: []string{"PeithoSecure", "Peitho Lite", "1.0.0", "login_success", "login success", "3"},
+			ext:    []string{"externalId=7", "cat=audit", "suser=alice", "outcome=success"},
+		},
+		{
+			name:   "pipes and backslashes in the header are escaped",
+			record: Record{Source: SourceTrace, ID: 1, Time: at, Type: `a|b\c`, Severity: "critical"},
+			header: []string{"PeithoSecure", "Peitho Lite", "1.0.0", `a\|b\\c`, `a\|b\\c`, "10"},
+		},
+		{
+			name:   "newlines in the header become spaces",
+			record: Record{Source: SourceTrace, ID: 1, Time: at, Type: "x\r\ny", Severity: "high"},
+			header: []string{"PeithoSecure", "Peitho Lite", "1.0.0", "x  y", "x  y", "8"},
+		},
+		{
+			name:   "equals, backslashes and newlines in values are escaped",
+			record: Record{Source: SourceAudit, ID: 2, Time: at, Type: "t", Username: `a=b\c`, Reason: "one\ntwo\rthree"},
+			ext:    []string{`suser=a\=b\\c`, `reason=one\ntwo\rthree`},
+		},
+		{
+			name:   "forged extension keys stay inside the value",
+			record: Record{Source: SourceAudit, ID: 3, Time: at, Type: "t", UserAgent: "x suser=root"},
+			ext:    []string{`requestClientApplication=x suser\=root`},
+		},
+		{
+			name:   "labelled custom strings",
+			record: Record{Source: SourceAudit, ID: 4, Time: at, Type: "t", Actor: "admin", Hash: "abc", RequestID: "rid"},
+			ext:    []string{"cs1Label=actor cs1=admin", "cs2Label=chainHash cs2=abc", "cs3Label=requestId cs3=rid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := CEFLine(tt.record)
+			if strings.ContainsAny(line, "\r\n") {
+				t.Fatalf("line contains a raw newline: %q", line)
+			}
+			if !strings.HasPrefix(line, "CEF:0|") {
+				t.Fatalf("line = %q, want CEF:0 prefix", line)
+			}
+			if tt.header != nil {
+				want := "CEF:0|" + strings.Join(tt.header, "|") + "|"
+				if !strings.HasPrefix(line, want) {
+					t.Errorf("line = %q\nwant prefix %q", line, want)
+				}
+			}
+			for _, e := range tt.ext {
+				if !strings.Contains(line, e) {
+					t.Errorf("line = %q\nwant extension %q", line, e)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVWriterGuardsFormulas(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"alice", "alice"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var out bytes.Buffer
+			w, err := NewWriter(FormatCSV, &out)
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			rec := Record{Source: SourceAudit, ID: 1, Type: tt.value, Username: tt.value, UserAgent: tt.value, Message: tt.value, Reason: tt.value}
+			if err := w.Write(rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			rows, err := csv.NewReader(&out).ReadAll()
+			if err != nil {
+				t.Fatalf("reading back: %v", err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("got %d rows, want header and one record", len(rows))
+			}
+			for i, col := range csvHeader {
+				switch col {
+				case "type", "username", "user_agent", "message", "reason":
+					if got := rows[1][i]; got != tt.want {
+						t.Errorf("%s = %q, want %q", col, got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCSVWriterEmptyExportHasHeader(t *testing.T) {
+	var out bytes.Buffer
+	w, _ := NewWriter(FormatCSV, &out)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, want := out.String(), strings.Join(csvHeader, ",")+"\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+// Sources that can be exported
+const (
+	SourceAudit = "audit"
+	SourceTrace = "trace"
+)
+
+const pageSize = 500
+
+var (
+	ErrInvalidSource = errors.New("source must be audit or trace")
+	ErrInvalidFormat = errors.New("format must be jsonl, csv or cef")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Record is one exported event, normalised across audit and trace sources
+type Record struct {
+	Source    string    `json:"source"`
+	ID        int64     `json:"id"` // audit id or trace sequence; pass the last one back as the cursor to resume
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	Username  string    `json:"username,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Outcome   string    `json:"outcome,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Subject   string    `json:"subject,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+}
+
+// Filter selects what to export. Records are always emitted oldest first.
+type Filter struct {
+	Source string
+	Since  time.Time
+	Until  time.Time
+	After  int64 // resume cursor: only records with a larger ID
+	Limit  int   // 0 exports everything that matches
+}
+
+// ParseCursor validates a cursor string as returned by a previous export
+func ParseCursor(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// Stream writes every matching record to w in pages and returns the cursor of the last one written
+// (f.After when nothing matched) and the number of records written.
+func Stream(ctx context.Context, f Filter, w Writer) (int64, int, error) {
+	if f.Source != SourceAudit && f.Source != SourceTrace {
+		return f.After, 0, ErrInvalidSource
+	}
+
+	cursor, written := f.After, 0
+	for {
+		size := pageSize
+		if f.Limit > 0 && f.Limit-written < size {
+			size = f.Limit - written
+		}
+		if size == 0 {
+			break
+		}
+
+		page, err := fetch(ctx, f, cursor, size)
+		if err != nil {
+			return cursor, written, err
+		}
+		for _, rec := range page {
+			if err := w.Write(rec); err != nil {
+				return cursor, written, err
+			}
+			cursor = rec.ID
+			written++
+		}
+		if len(page) < size {
+			break
+		}
+	}
+	return cursor, written, w.Flush()
+}
+
+func fetch(ctx context.Context, f Filter, after int64, limit int) ([]Record, error) {
+	if f.Source == SourceTrace {
+		traces, err := sqlite.QueryTraceEvents(ctx, sqlite.TraceFilter{
+			Since: f.Since, Until: f.Until, AfterSeq: after, Ascending: true, Limit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records := make([]Record, 0, len(traces))
+		for _, t := range traces {
+			username := ""
+			if t.Scope == "user" {
+				username = t.Target
+			}
+			ip := ""
+			if t.Scope == "ip" {
+				ip = t.Target
+			}
+			records = append(records, Record{
+				Source: SourceTrace, ID: t.Seq, Time: t.CreatedAt, Type: t.Event,
+				Username: username, Actor: t.Actor, IP: ip, Severity: t.Severity, Message: t.Message,
+			})
+		}
+		return records, nil
+	}
+
+	events, err := sqlite.QueryAuditEvents(ctx, sqlite.AuditFilter{
+		Since: f.Since, Until: f.Until, AfterID: after, Ascending: true, Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(events))
+	for _, e := range events {
+		records = append(records, Record{
+			Source: SourceAudit, ID: int64(e.ID), Time: e.CreatedAt, Type: e.EventType,
+			Username: e.Username, IP: e.IPAddress, UserAgent: e.UserAgent,
+			Outcome: e.Outcome, Reason: e.Reason, RequestID: e.RequestID, Subject: e.Subject, Hash: e.Hash,
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,262 @@
+package export
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+const (
+	syslogPollInterval = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+	syslogAppName      = "peitho"
+	syslogFacility     = 13 // log audit
+	syslogSDID         = "peitho@32473"
+)
+
+// Forwarder tails audit and trace events and ships them to a syslog receiver as
+// RFC 5424 messages with a CEF payload. TCP and TLS use octet-counted framing (RFC 6587).
+type Forwarder struct {
+	network  string
+	addr     string
+	tlsConf  *tls.Config
+	hostname string
+
+	conn net.Conn
+	stop context.CancelFunc
+	done sync.WaitGroup
+}
+
+var forwarder *Forwarder
+
+// StartSyslog launches the forwarder when PEITHO_SYSLOG_ADDR is set. A fresh forwarder
+// starts at the current head; history can be shipped with `peithoctl audit export`.
+func StartSyslog(cfg *config.Config) error {
+	if cfg.SyslogAddr == "" {
+		return nil
+	}
+	f, err := NewForwarder(cfg.SyslogNetwork, cfg.SyslogAddr, cfg.SyslogCAFile)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, source := range []string{SourceAudit, SourceTrace} {
+		if _, found, err := sqlite.GetExportCursor(ctx, cursorName(source)); err != nil {
+			return err
+		} else if !found {
+			head, err := headCursor(ctx, source)
+			if err != nil {
+				return err
+			}
+			if err := sqlite.SetExportCursor(ctx, cursorName(source), head); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	f.stop = cancel
+	f.done.Add(1)
+	go f.run(ctx)
+	forwarder = f
+
+	log.Printf("📡 Syslog forwarder shipping audit and trace events to %s://%s", f.network, f.addr)
+	return nil
+}
+
+// StopSyslog halts the forwarder; it resumes from its saved cursors on next start
+func StopSyslog() {
+	if forwarder != nil && forwarder.stop != nil {
+		forwarder.stop()
+		forwarder.done.Wait()
+		forwarder.close()
+	}
+}
+
+// NewForwarder validates the transport settings. network is udp, tcp or tls.
+func NewForwarder(network, addr, caFile string) (*Forwarder, error) {
+	if network == "" {
+		network = "udp"
+	}
+	f := &Forwarder{network: network, addr: addr, hostname: "-"}
+	if h, err := os.Hostname(); err == nil && h != "" {
+		f.hostname = h
+	}
+
+	switch network {
+	case "udp", "tcp":
+	case "tls":
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("syslog address: %w", err)
+		}
+		f.tlsConf = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("syslog CA: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("syslog CA: no certificates found")
+			}
+			f.tlsConf.RootCAs = pool
+		}
+	default:
+		return nil, fmt.Errorf("syslog network must be udp, tcp or tls, got %q", network)
+	}
+	return f, nil
+}
+
+func (f *Forwarder) run(ctx context.Context) {
+	defer f.done.Done()
+	ticker := time.NewTicker(syslogPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, source := range []string{SourceAudit, SourceTrace} {
+			if err := f.drain(ctx, source); err != nil {
+				log.Printf("⚠️ Syslog forwarder (%s): %v — retrying in %s", source, err, syslogPollInterval)
+				f.close()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain forwards page after page until the backlog is shipped, so a burst larger than one
+// page is not spread over several poll intervals
+func (f *Forwarder) drain(ctx context.Context, source string) error {
+	for ctx.Err() == nil {
+		n, err := f.forward(ctx, source)
+		if err != nil || n < pageSize {
+			return err
+		}
+	}
+	return nil
+}
+
+// forward ships up to one page after the saved cursor, saving progress even on partial failure
+func (f *Forwarder) forward(ctx context.Context, source string) (int, error) {
+	name := cursorName(source)
+	after, _, err := sqlite.GetExportCursor(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, n, streamErr := Stream(ctx, Filter{Source: source, After: after, Limit: pageSize}, f)
+	if n > 0 {
+		if err := sqlite.SetExportCursor(ctx, name, cursor); err != nil {
+			return n, err
+		}
+	}
+	return n, streamErr
+}
+
+// Write sends one record, dialing on demand
+func (f *Forwarder) Write(r Record) error {
+	if f.conn == nil {
+		if err := f.dial(); err != nil {
+			return err
+		}
+	}
+
+	msg := FormatSyslog(r, f.hostname)
+	if f.network != "udp" {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	_ = f.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	_, err := f.conn.Write([]byte(msg))
+	return err
+}
+
+func (f *Forwarder) Flush() error { return nil }
+
+func (f *Forwarder) dial() error {
+	dialer := &net.Dialer{Timeout: syslogWriteTimeout}
+	var err error
+	if f.tlsConf != nil {
+		f.conn, err = tls.DialWithDialer(dialer, "tcp", f.addr, f.tlsConf)
+	} else {
+		f.conn, err = dialer.Dial(f.network, f.addr)
+	}
+	return err
+}
+
+func (f *Forwarder) close() {
+	if f.conn != nil {
+		_ = f.conn.Close()
+		f.conn = nil
+	}
+}
+
+// FormatSyslog renders a record as an RFC 5424 message whose MSG is the CEF line
+func FormatSyslog(r Record, hostname string) string {
+	msgID := r.Type
+	if msgID == "" {
+		msgID = "-"
+	} else if len(msgID) > 32 {
+		msgID = msgID[:32]
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s [%s source=\"%s\" id=\"%d\"] %s",
+		syslogFacility*8+syslogSeverity(r.Severity),
+		r.Time.UTC().Format(time.RFC3339),
+		hostname,
+		syslogAppName,
+		strings.ReplaceAll(msgID, " ", "_"),
+		syslogSDID,
+		sdEscaper.Replace(r.Source),
+		r.ID,
+		CEFLine(r),
+	)
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogSeverity maps trace severities onto RFC 5424 severities; audit rows are informational
+func syslogSeverity(s string) int {
+	switch s {
+	case "critical":
+		return 2
+	case "high":
+		return 3
+	case "medium":
+		return 4
+	case "low":
+		return 5
+	default:
+		return 6
+	}
+}
+
+func cursorName(source string) string { return "syslog:" + source }
+
+func headCursor(ctx context.Context, source string) (int64, error) {
+	if source == SourceTrace {
+		latest, err := sqlite.QueryTraceEvents(ctx, sqlite.TraceFilter{Limit: 1})
+		if err != nil || len(latest) == 0 {
+			return 0, err
+		}
+		return latest[0].Seq, nil
+	}
+	id, _, err := sqlite.LatestAuditLink(ctx)
+	return id, err
+}
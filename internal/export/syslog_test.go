@@ -0,0 +1,230 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+// readFrame reads one RFC 6587 octet-counted frame
+func readFrame(r *bufio.Reader) (string, error) {
+	prefix, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return "", err
+	}
+	return string(msg), nil
+}
+
+func testRecords() []Record {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []Record{
+		{Source: SourceAudit, ID: 1, Time: at, Type: "login_success", Username: "alice"},
+		// Multi-byte characters and an embedded newline: the frame length counts octets, and a
+		// newline inside the message must not split it
+		{Source: SourceTrace, ID: 2, Time: at, Type: "brute force", Severity: "high", Message: "zwölf\nversuche"},
+		{Source: SourceAudit, ID: 3, Time: at, Type: "logout", Reason: strings.Repeat("x", 4000)},
+	}
+}
+
+func TestForwarderTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	frames := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			frames <- nil
+			return
+		}
+		defer conn.Close()
+		var got []string
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := readFrame(r)
+			if err != nil {
+				break
+			}
+			got = append(got, msg)
+		}
+		frames <- got
+	}()
+
+	f, err := NewForwarder("tcp", ln.Addr().String(), "")
+	if err != nil {
+		t.Fatalf("NewForwarder: %v", err)
+	}
+	records := testRecords()
+	for _, r := range records {
+		if err := f.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	f.close()
+
+	got := <-frames
+	if len(got) != len(records) {
+		t.Fatalf("got %d frames, want %d", len(got), len(records))
+	}
+	for i, r := range records {
+		if want := FormatSyslog(r, f.hostname); got[i] != want {
+			t.Errorf("frame %d = %q, want %q", i, got[i], want)
+		}
+	}
+}
+
+func TestForwarderUDPDatagrams(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	f, err := NewForwarder("udp", pc.LocalAddr().String(), "")
+	if err != nil {
+		t.Fatalf("NewForwarder: %v", err)
+	}
+	defer f.close()
+
+	buf := make([]byte, 64*1024)
+	for i, r := range testRecords() {
+		if err := f.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("datagram %d: %v", i, err)
+		}
+		// One message per datagram, without the octet count
+		if got, want := string(buf[:n]), FormatSyslog(r, f.hostname); got != want {
+			t.Errorf("datagram %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestFormatSyslog(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name   string
+		record Record
+		prefix string
+	}{
+		{
+			name:   "audit row is informational",
+			record: Record{Source: SourceAudit, ID: 9, Time: at, Type: "login_success"},
+			prefix: `<110>1 2026-01-02T02:04:05Z host peitho - login_success [peitho@32473 source="audit" id="9"] CEF:0|`,
+		},
+		{
+			name:   "critical trace",
+			record: Record{Source: SourceTrace, ID: 1, Time: at, Type: "x", Severity: "critical"},
+			prefix: `<106>1 2026-01-02T02:04:05Z host peitho - x [peitho@32473 source="trace" id="1"] CEF:0|`,
+		},
+		{
+			name:   "empty type becomes the nil msgid",
+			record: Record{Source: SourceAudit, ID: 1, Time: at},
+			prefix: `<110>1 2026-01-02T02:04:05Z host peitho - - [`,
+		},
+		{
+			name:   "msgid drops spaces and is capped at 32 characters",
+			record: Record{Source: SourceAudit, ID: 1, Time: at, Type: "a b " + strings.Repeat("c", 40)},
+			prefix: `<110>1 2026-01-02T02:04:05Z host peitho - a_b_` + strings.Repeat("c", 28) + ` [`,
+		},
+		{
+			name:   "structured data values are escaped",
+			record: Record{Source: `a"]\b`, ID: 1, Time: at, Type: "t"},
+			prefix: `<110>1 2026-01-02T02:04:05Z host peitho - t [peitho@32473 source="a\"\]\\b" id="1"] CEF:0|`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatSyslog(tt.record, "host")
+			if !strings.HasPrefix(got, tt.prefix) {
+				t.Errorf("FormatSyslog = %q\nwant prefix %q", got, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestForwarderDrainsBacklog(t *testing.T) {
+	sqlite.InitDB(filepath.Join(t.TempDir(), "export.db"))
+	t.Cleanup(func() { _ = sqlite.Close() })
+	ctx := context.Background()
+
+	// More than two pages, so a single poll must loop to ship them all
+	total := 2*pageSize + 3
+	for i := 0; i < total; i++ {
+		if err := sqlite.InsertAuditEvent(ctx, &models.AuditEvent{
+			Username: "alice", EventType: "login", Outcome: models.OutcomeSuccess, CreatedAt: time.Now().UTC(),
+		}); err != nil {
+			t.Fatalf("InsertAuditEvent: %v", err)
+		}
+	}
+	if err := sqlite.SetExportCursor(ctx, cursorName(SourceAudit), 0); err != nil {
+		t.Fatalf("SetExportCursor: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	count := make(chan int, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			count <- 0
+			return
+		}
+		defer conn.Close()
+		n, r := 0, bufio.NewReader(conn)
+		for {
+			if _, err := readFrame(r); err != nil {
+				break
+			}
+			n++
+		}
+		count <- n
+	}()
+
+	f, err := NewForwarder("tcp", ln.Addr().String(), "")
+	if err != nil {
+		t.Fatalf("NewForwarder: %v", err)
+	}
+	if err := f.drain(ctx, SourceAudit); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	f.close()
+
+	if got := <-count; got != total {
+		t.Errorf("shipped %d records, want %d", got, total)
+	}
+	head, err := headCursor(ctx, SourceAudit)
+	if err != nil {
+		t.Fatalf("headCursor: %v", err)
+	}
+	if cursor, _, _ := sqlite.GetExportCursor(ctx, cursorName(SourceAudit)); cursor != head {
+		t.Errorf("cursor = %d, want head %d", cursor, head)
+	}
+}
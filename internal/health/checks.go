@@ -0,0 +1,89 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HTTP reports url reachable when it answers with any status below 500. Endpoints such as
+// a token URL reject an unauthenticated GET, which still proves the service is up.
+func HTTP(url string) CheckFunc {
+	return func(ctx context.Context) error {
+		resp, err := get(ctx, url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// JWKS checks that url serves a key set with at least one key, so tokens can be verified
+func JWKS(url string) CheckFunc {
+	return func(ctx context.Context) error {
+		resp, err := get(ctx, url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		var set struct {
+			Keys []json.RawMessage `json:"keys"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+			return fmt.Errorf("decode key set: %w", err)
+		}
+		if len(set.Keys) == 0 {
+			return errors.New("key set is empty")
+		}
+		return nil
+	}
+}
+
+// SMTP connects to the address addr returns and waits for the 220 greeting. addr is called
+// on every run so the check follows reloaded mail settings; "" fails the check.
+func SMTP(addr func() string) CheckFunc {
+	return func(ctx context.Context) error {
+		target := addr()
+		if target == "" {
+			return errors.New("SMTP is not configured")
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", target)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		greeting, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read greeting: %w", err)
+		}
+		if !strings.HasPrefix(greeting, "220") {
+			return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(greeting))
+		}
+		_, _ = conn.Write([]byte("QUIT\r\n"))
+		return nil
+	}
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
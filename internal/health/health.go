@@ -0,0 +1,145 @@
+// Package health runs the dependency checks behind the readiness probe. Components register
+// a check once at startup; each run is bounded by a timeout and its result is reused for a
+// short time, so frequent probes from several load balancers do not hammer dependencies.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/buildinfo"
+)
+
+// Statuses of a check or a whole report
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // a non-critical check failed; the server stays ready
+	StatusFail     = "fail"
+)
+
+// CheckFunc probes one dependency. It must return once ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// Options tunes one check
+type Options struct {
+	// Critical checks take the server out of rotation when they fail
+	Critical bool
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name       string    `json:"name" example:"sqlite"`
+	Status     string    `json:"status" example:"ok"`
+	Critical   bool      `json:"critical" example:"true"`
+	Error      string    `json:"error,omitempty" example:"dial tcp 10.0.0.5:8080: connect: connection refused"`
+	DurationMS int64     `json:"duration_ms" example:"3"`
+	CheckedAt  time.Time `json:"checked_at" example:"2025-06-01T12:00:00Z"`
+}
+
+// Report combines every check. Checks is omitted unless the caller asked for detail.
+type Report struct {
+	Status string         `json:"status" example:"ok"`
+	Checks []Result       `json:"checks,omitempty"`
+	Build  buildinfo.Info `json:"build"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+	opts Options
+
+	mu   sync.Mutex // held while probing, so concurrent callers share one run
+	last Result
+}
+
+var (
+	mu      sync.RWMutex
+	checks  []*check
+	timeout = 2 * time.Second
+	ttl     = 10 * time.Second
+)
+
+// Configure sets the per-check timeout and how long results are reused
+func Configure(checkTimeout, cacheTTL time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	timeout, ttl = checkTimeout, cacheTTL
+}
+
+// Register adds a named check
+func Register(name string, fn CheckFunc, opts Options) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks = append(checks, &check{name: name, fn: fn, opts: opts})
+}
+
+// Run executes every check concurrently, reusing fresh cached results
+func Run(ctx context.Context) Report {
+	mu.RLock()
+	list := append([]*check(nil), checks...)
+	limit, maxAge := timeout, ttl
+	mu.RUnlock()
+
+	results := make([]Result, len(list))
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, limit, maxAge)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results, Build: buildinfo.Get()}
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// Find returns the result of the named check in r
+func (r Report) Find(name string) (Result, bool) {
+	for _, res := range r.Checks {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+func (c *check) run(ctx context.Context, limit, maxAge time.Duration) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < maxAge {
+		return c.last
+	}
+
+	// the result is shared with every caller for maxAge, so it must not depend on this
+	// caller's deadline or disconnect: only the check timeout bounds the probe
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), limit)
+	defer cancel()
+
+	started := time.Now()
+	err := c.fn(ctx)
+	res := Result{
+		Name:       c.name,
+		Status:     StatusOK,
+		Critical:   c.opts.Critical,
+		DurationMS: time.Since(started).Milliseconds(),
+		CheckedAt:  started.UTC(),
+	}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	c.last = res
+	return res
+}
@@ -0,0 +1,134 @@
+// Package lifecycle coordinates peitho-server startup and shutdown. On SIGTERM or SIGINT the
+// server first reports itself not ready, so load balancers stop routing to it, then drains
+// HTTP requests, waits for background work started with Go, and finally runs the shutdown
+// hooks, ending with the database.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Options tunes Serve
+type Options struct {
+	DrainDelay time.Duration // time between reporting not ready and closing listeners
+	Timeout    time.Duration // deadline for draining requests, background work and hooks
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	ready   atomic.Bool
+	pending atomic.Int64
+	tasks   sync.WaitGroup
+
+	mu    sync.Mutex
+	hooks []hook
+)
+
+// Ready reports whether the server is accepting traffic: true once Serve has its bound
+// listener, false again as soon as shutdown begins
+func Ready() bool { return ready.Load() }
+
+// Go runs fn in the background and makes shutdown wait for it. Use it for work that
+// outlives its request, such as sending mail or writing the license file.
+func Go(fn func()) {
+	tasks.Add(1)
+	pending.Add(1)
+	go func() {
+		defer tasks.Done()
+		defer pending.Add(-1)
+		fn()
+	}()
+}
+
+// OnShutdown registers a cleanup step. Steps run after HTTP has drained, in reverse order
+// of registration, so register each component right after starting it.
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, hook{name: name, fn: fn})
+}
+
+// Serve runs serve on ln, typically srv.ServeTLS, until it fails or a SIGTERM or SIGINT
+// arrives, then shuts everything down. A second signal during shutdown exits immediately.
+// ln must already be bound, so the server reports itself ready only once connections can
+// reach it. Serve returns serve's error, if any; a clean shutdown returns nil.
+func Serve(srv *http.Server, ln net.Listener, serve func(net.Listener) error, opts Options) error {
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sig)
+
+	errc := make(chan error, 1)
+	go func() { errc <- serve(ln) }()
+	ready.Store(true)
+
+	var serveErr error
+	select {
+	case serveErr = <-errc:
+		slog.Error("💣 Server stopped unexpectedly; shutting down", "error", serveErr)
+	case s := <-sig:
+		slog.Info("🛑 Shutdown requested", "signal", s.String())
+		go func() {
+			s := <-sig
+			slog.Error("💥 Second signal received; exiting without finishing shutdown", "signal", s.String())
+			os.Exit(1)
+		}()
+	}
+
+	shutdown(srv, opts, serveErr == nil)
+	return serveErr
+}
+
+func shutdown(srv *http.Server, opts Options, drain bool) {
+	ready.Store(false)
+	if drain && opts.DrainDelay > 0 {
+		slog.Info("🚦 Reporting not ready; waiting for load balancers to stop routing", "delay", opts.DrainDelay)
+		time.Sleep(opts.DrainDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("⚠️ HTTP drain did not finish; closing remaining connections", "error", err)
+		_ = srv.Close()
+	} else {
+		slog.Info("🔌 HTTP server drained")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("⚠️ Abandoning background tasks at shutdown deadline", "pending", pending.Load())
+	}
+
+	mu.Lock()
+	steps := append([]hook(nil), hooks...)
+	mu.Unlock()
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := steps[i].fn(ctx); err != nil {
+			slog.Error("❌ Shutdown step failed", "step", steps[i].name, "error", err)
+			continue
+		}
+		slog.Debug("✅ Shutdown step done", "step", steps[i].name)
+	}
+	slog.Info("👋 Shutdown complete")
+}
@@ -0,0 +1,98 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServeReadyOnlyWhileServing(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})}
+
+	var steps []string
+	mu.Lock()
+	prev := hooks
+	hooks = nil
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		hooks = prev
+		mu.Unlock()
+	})
+	OnShutdown("first", func(context.Context) error { steps = append(steps, "first"); return nil })
+	OnShutdown("second", func(context.Context) error { steps = append(steps, "second"); return errors.New("ignored") })
+
+	if Ready() {
+		t.Fatal("Ready before Serve")
+	}
+	done := make(chan error, 1)
+	go func() { done <- Serve(srv, ln, srv.Serve, Options{Timeout: time.Second}) }()
+	waitFor(t, "readiness", Ready)
+
+	// Ready implies the socket accepts connections
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("request while ready: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("signal: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve = %v, want nil after a clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after SIGTERM")
+	}
+	if Ready() {
+		t.Error("still ready after shutdown")
+	}
+	// Hooks run in reverse order, and a failing one does not stop the rest
+	if len(steps) != 2 || steps[0] != "second" || steps[1] != "first" {
+		t.Errorf("shutdown steps = %v, want [second first]", steps)
+	}
+}
+
+func TestServeReturnsServeError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	srv := &http.Server{}
+	failed := errors.New("serve failed")
+
+	err = Serve(srv, ln, func(net.Listener) error { return failed }, Options{Timeout: time.Second})
+	if !errors.Is(err, failed) {
+		t.Errorf("Serve = %v, want %v", err, failed)
+	}
+	if Ready() {
+		t.Error("still ready after serve failed")
+	}
+}
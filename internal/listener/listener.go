@@ -0,0 +1,172 @@
+// Package listener sets up how peitho-server accepts connections. PEITHO_LISTEN_MODE picks
+// plain HTTP (behind a TLS-terminating proxy), TLS, or TLS with client certificates (mtls)
+// for service-to-service callers. In the TLS modes the certificate is read again whenever
+// its files change, so renewed certificates are served without a restart. An optional
+// internal listener on PEITHO_INTERNAL_ADDR serves metrics and probes over plain HTTP.
+package listener
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/lifecycle"
+	"github.com/peithosecure/peitho-backend/internal/scheduler"
+)
+
+// Listen modes
+const (
+	ModeHTTP = "http"
+	ModeTLS  = "tls"
+	ModeMTLS = "mtls"
+)
+
+// MinTLSVersion is the oldest TLS version the listener negotiates in the TLS modes
+const MinTLSVersion = tls.VersionTLS13
+
+// active is the TLS configuration the public listener was built with
+var active atomic.Pointer[tls.Config]
+
+// Active returns the TLS configuration last built by TLSConfig, or nil when this process
+// has not built one (http mode, or a process that does not serve)
+func Active() *tls.Config {
+	return active.Load()
+}
+
+// TLSConfig returns the TLS settings for cfg's listen mode, or nil in http mode. The
+// certificate files are polled every PEITHO_CONFIG_WATCH_INTERVAL on the scheduler, so
+// TLSConfig must run before scheduler.Start.
+func TLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.ListenMode == ModeHTTP {
+		return nil, nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, fmt.Errorf("PEITHO_LISTEN_MODE=%s requires TLS_CERT_FILE and TLS_KEY_FILE (use http behind a TLS-terminating proxy)", cfg.ListenMode)
+	}
+
+	kp := &keyPair{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile}
+	if err := kp.load(); err != nil {
+		return nil, err
+	}
+	scheduler.Every("tls-cert-watch", cfg.ConfigWatch, kp.watch)
+
+	conf := &tls.Config{
+		MinVersion:     MinTLSVersion,
+		GetCertificate: kp.get,
+	}
+	if cfg.ListenMode == ModeMTLS {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA: no certificates found")
+		}
+		conf.ClientCAs = pool
+		// optional lets browsers connect without a certificate while services present one;
+		// handlers see only verified certificates either way
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+		if cfg.TLSClientAuth == "require" {
+			conf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	active.Store(conf)
+	return conf, nil
+}
+
+// Listen binds srv.Addr, so a taken port fails startup before the server reports ready
+func Listen(srv *http.Server) (net.Listener, error) {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Serve returns the function that runs srv on a bound listener in cfg's listen mode, for
+// lifecycle.Serve. In the TLS modes srv.TLSConfig must come from TLSConfig.
+func Serve(srv *http.Server, cfg *config.Config) func(net.Listener) error {
+	if cfg.ListenMode == ModeHTTP {
+		return srv.Serve
+	}
+	return func(ln net.Listener) error { return srv.ServeTLS(ln, "", "") }
+}
+
+// StartInternal serves handler over plain HTTP on addr until shutdown. Binding happens
+// before it returns, so a taken port fails startup instead of surfacing later. The
+// listener closes after the public one has drained, so probes keep answering meanwhile.
+func StartInternal(addr string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("💣 Internal listener stopped", "addr", addr, "error", err)
+		}
+	}()
+	lifecycle.OnShutdown("internal-listener", srv.Shutdown)
+	slog.Info("🔧 Internal listener serving metrics and probes", "addr", ln.Addr().String())
+	return nil
+}
+
+// keyPair serves the current certificate and swaps in a new one when its files change
+type keyPair struct {
+	certFile, keyFile string
+
+	mu    sync.RWMutex
+	cert  *tls.Certificate
+	stamp string
+}
+
+func (kp *keyPair) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.cert, nil
+}
+
+func (kp *keyPair) load() error {
+	stamp := config.Fingerprint(kp.certFile, kp.keyFile)
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		return fmt.Errorf("TLS certificate: %w", err)
+	}
+	kp.mu.Lock()
+	kp.cert, kp.stamp = &cert, stamp
+	kp.mu.Unlock()
+	return nil
+}
+
+// watch reloads the certificate when either file changed. A broken pair, for instance one
+// caught halfway through a renewal, is logged and the previous certificate kept; the next
+// tick tries again.
+func (kp *keyPair) watch(context.Context) error {
+	kp.mu.RLock()
+	changed := config.Fingerprint(kp.certFile, kp.keyFile) != kp.stamp
+	kp.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	if err := kp.load(); err != nil {
+		slog.Error("🔐 TLS certificate reload failed; keeping the current certificate", "error", err)
+		return nil
+	}
+	slog.Info("🔐 TLS certificate reloaded", "cert", kp.certFile)
+	return nil
+}
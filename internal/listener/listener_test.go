@@ -0,0 +1,273 @@
+package listener
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+)
+
+// testCA issues certificates for the tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a leaf certificate and key in PEM, for 127.0.0.1 or a client
+func (ca *testCA) issue(t *testing.T, name string, client bool) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		tmpl.IPAddresses = nil
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Distinct modification times, so a rewrite is noticed even within the clock's resolution
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS serves 200 OK with conf on a loopback port and returns its address
+func serveTLS(t *testing.T, conf *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return ln.Addr().String()
+}
+
+// get makes a request over a fresh connection and returns the server certificate's common name
+func get(addr string, conf *tls.Config) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{TLSClientConfig: conf}}
+	resp, err := client.Get("https://" + addr)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestKeyPairWatchSwapsCertificate(t *testing.T) {
+	ca := newCA(t, "server CA")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	start := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, "first", false)
+	writeFile(t, certFile, certPEM, start)
+	writeFile(t, keyFile, keyPEM, start)
+	kp := &keyPair{certFile: certFile, keyFile: keyFile}
+	if err := kp.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	addr := serveTLS(t, &tls.Config{MinVersion: MinTLSVersion, GetCertificate: kp.get})
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots}
+
+	steps := []struct {
+		name  string
+		write func(at time.Time)
+		want  string
+	}{
+		{"unchanged files keep the certificate", func(time.Time) {}, "first"},
+		{"renewed pair is served", func(at time.Time) {
+			certPEM, keyPEM := ca.issue(t, "second", false)
+			writeFile(t, certFile, certPEM, at)
+			writeFile(t, keyFile, keyPEM, at)
+		}, "second"},
+		{"certificate without its new key keeps the previous pair", func(at time.Time) {
+			certPEM, _ := ca.issue(t, "third", false)
+			writeFile(t, certFile, certPEM, at)
+		}, "second"},
+		{"unreadable key keeps the previous pair", func(at time.Time) {
+			writeFile(t, keyFile, []byte("not a key"), at)
+		}, "second"},
+		{"completed renewal is served", func(at time.Time) {
+			certPEM, keyPEM := ca.issue(t, "fourth", false)
+			writeFile(t, certFile, certPEM, at)
+			writeFile(t, keyFile, keyPEM, at)
+		}, "fourth"},
+	}
+	for i, step := range steps {
+		step.write(start.Add(time.Duration(i+1) * time.Second))
+		if err := kp.watch(context.Background()); err != nil {
+			t.Fatalf("%s: watch: %v", step.name, err)
+		}
+		got, err := get(addr, client)
+		if err != nil {
+			t.Fatalf("%s: request: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: served %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestTLSConfigClientAuth(t *testing.T) {
+	serverCA, clientCA, rogueCA := newCA(t, "server CA"), newCA(t, "client CA"), newCA(t, "rogue CA")
+	dir := t.TempDir()
+	certPEM, keyPEM := serverCA.issue(t, "server", false)
+	files := map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "client-ca.crt": clientCA.pem}
+	for name, data := range files {
+		writeFile(t, filepath.Join(dir, name), data, time.Now())
+	}
+
+	clientCert := func(ca *testCA) *tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, "service", true)
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &pair
+	}
+	trusted, rogue := clientCert(clientCA), clientCert(rogueCA)
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+
+	tests := []struct {
+		mode       string
+		clientAuth string
+		wantAuth   tls.ClientAuthType
+		accepted   map[string]bool // by client: none, trusted, rogue
+	}{
+		{ModeTLS, "require", tls.NoClientCert, map[string]bool{"none": true, "trusted": true, "rogue": true}},
+		{ModeMTLS, "optional", tls.VerifyClientCertIfGiven, map[string]bool{"none": true, "trusted": true, "rogue": false}},
+		{ModeMTLS, "require", tls.RequireAndVerifyClientCert, map[string]bool{"none": false, "trusted": true, "rogue": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.clientAuth, func(t *testing.T) {
+			conf, err := TLSConfig(&config.Config{
+				ListenMode:      tt.mode,
+				TLSCertFile:     filepath.Join(dir, "tls.crt"),
+				TLSKeyFile:      filepath.Join(dir, "tls.key"),
+				TLSClientCAFile: filepath.Join(dir, "client-ca.crt"),
+				TLSClientAuth:   tt.clientAuth,
+			})
+			if err != nil {
+				t.Fatalf("TLSConfig: %v", err)
+			}
+			if conf.ClientAuth != tt.wantAuth {
+				t.Errorf("ClientAuth = %v, want %v", conf.ClientAuth, tt.wantAuth)
+			}
+			if conf.MinVersion != MinTLSVersion || Active() != conf {
+				t.Errorf("MinVersion = %x, Active() = %p; want %x and %p", conf.MinVersion, Active(), MinTLSVersion, conf)
+			}
+
+			addr := serveTLS(t, conf)
+			for client, cert := range map[string]*tls.Certificate{"none": {}, "trusted": trusted, "rogue": rogue} {
+				// Presented unconditionally: tls.Config.Certificates would hold back a
+				// certificate the server's CA list does not name
+				_, err := get(addr, &tls.Config{
+					RootCAs:              roots,
+					GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return cert, nil },
+				})
+				if accepted := err == nil; accepted != tt.accepted[client] {
+					t.Errorf("client with %s certificate: accepted = %v (err %v), want %v", client, accepted, err, tt.accepted[client])
+				}
+			}
+		})
+	}
+}
+
+func TestTLSConfigRejectsBadClientCA(t *testing.T) {
+	ca := newCA(t, "server CA")
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, "server", false)
+	writeFile(t, filepath.Join(dir, "tls.crt"), certPEM, time.Now())
+	writeFile(t, filepath.Join(dir, "tls.key"), keyPEM, time.Now())
+	writeFile(t, filepath.Join(dir, "empty.crt"), []byte("no certificates here"), time.Now())
+
+	for name, caFile := range map[string]string{"missing": filepath.Join(dir, "missing.crt"), "empty": filepath.Join(dir, "empty.crt")} {
+		t.Run(name, func(t *testing.T) {
+			_, err := TLSConfig(&config.Config{
+				ListenMode:      ModeMTLS,
+				TLSCertFile:     filepath.Join(dir, "tls.crt"),
+				TLSKeyFile:      filepath.Join(dir, "tls.key"),
+				TLSClientCAFile: caFile,
+			})
+			if err == nil {
+				t.Fatal("TLSConfig accepted an unusable client CA")
+			}
+		})
+	}
+}
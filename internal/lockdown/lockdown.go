@@ -0,0 +1,267 @@
+package lockdown
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/trace"
+)
+
+// Lockdown scopes
+const (
+	ScopeServer = "server"
+	ScopeUser   = "user"
+	ScopeIP     = "ip"
+)
+
+var (
+	ErrInvalidScope  = errors.New("invalid lockdown scope")
+	ErrMissingTarget = errors.New("lockdown target required for user and ip scope")
+	ErrNotActive     = errors.New("lockdown not found or already lifted")
+)
+
+// Actor identifies who caused a lockdown transition, for the audit trail
+type Actor struct {
+	Name      string
+	Subject   string // Keycloak user id, when the actor signed in with a token
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// systemActor is used for transitions the server performs on its own
+var systemActor = Actor{Name: "system"}
+
+type manager struct {
+	// engage serialises Engage, so two concurrent requests for the same target cannot both
+	// miss the active set and insert duplicate rows. It is separate from mu so lookups on
+	// the request path never wait on the insert.
+	engage sync.Mutex
+
+	mu        sync.RWMutex
+	active    map[string]models.Lockdown
+	threshold int // minimum trace severity rank that triggers a lockdown; -1 disables
+	ttl       time.Duration
+}
+
+var m = &manager{active: map[string]models.Lockdown{}, threshold: -1}
+
+// Init loads active lockdowns from the database and starts listening to the trace engine
+func Init(cfg *config.Config) error {
+	threshold := -1
+	if cfg.LockdownSeverity != "" {
+		severity, err := trace.NormalizeSeverity(cfg.LockdownSeverity)
+		if err != nil {
+			return fmt.Errorf("lockdown severity threshold: %w", err)
+		}
+		threshold = trace.SeverityRank(severity)
+	}
+
+	rows, err := sqlite.ListLockdowns(context.Background(), false, -1)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.threshold = threshold
+	m.ttl = cfg.LockdownTTL
+	m.active = make(map[string]models.Lockdown, len(rows))
+	for _, l := range rows {
+		m.active[key(l.Scope, l.Target)] = l
+	}
+	m.mu.Unlock()
+
+	trace.OnRecord(onTrace)
+	log.Printf("🚨 Lockdown engine armed: %d active lockdown(s) restored", len(rows))
+	return nil
+}
+
+// NormalizeScope lowercases and validates a scope and its target
+func NormalizeScope(scope, target string) (string, string, error) {
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	target = strings.TrimSpace(target)
+	switch scope {
+	case ScopeServer:
+		return scope, "", nil
+	case ScopeUser, ScopeIP:
+		if target == "" {
+			return "", "", ErrMissingTarget
+		}
+		return scope, target, nil
+	default:
+		return "", "", ErrInvalidScope
+	}
+}
+
+// Engage places a lockdown. Engaging an already active lockdown returns the existing one.
+func Engage(ctx context.Context, scope, target, reason string, by Actor) (*models.Lockdown, error) {
+	scope, target, err := NormalizeScope(scope, target)
+	if err != nil {
+		return nil, err
+	}
+
+	m.engage.Lock()
+	defer m.engage.Unlock()
+
+	if existing, ok := Check(ctx, scope, target); ok {
+		return existing, nil
+	}
+
+	now := time.Now().UTC()
+	l := models.Lockdown{
+		Scope:     scope,
+		Target:    target,
+		Reason:    reason,
+		Source:    by.Name,
+		CreatedAt: now,
+	}
+	m.mu.RLock()
+	ttl := m.ttl
+	m.mu.RUnlock()
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		l.ExpiresAt = &expires
+	}
+
+	if err := sqlite.InsertLockdown(ctx, &l); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.active[key(scope, target)] = l
+	m.mu.Unlock()
+
+	audit(ctx, by, "lockdown_engaged", l)
+	log.Printf("🔒 Lockdown engaged: scope=%s target=%q reason=%q by=%s", scope, target, reason, by.Name)
+	return &l, nil
+}
+
+// Lift ends an active lockdown
+func Lift(ctx context.Context, id int64, by Actor) error {
+	if err := sqlite.LiftLockdown(ctx, id, by.Name, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotActive
+		}
+		return err
+	}
+
+	lifted := models.Lockdown{ID: id}
+	m.mu.Lock()
+	for k, l := range m.active {
+		if l.ID == id {
+			lifted = l
+			delete(m.active, k)
+		}
+	}
+	m.mu.Unlock()
+
+	audit(ctx, by, "lockdown_lifted", lifted)
+	log.Printf("🔓 Lockdown %d lifted by %s", id, by.Name)
+	return nil
+}
+
+// Check reports whether scope/target is currently locked down. Expired lockdowns are lifted on the way.
+func Check(ctx context.Context, scope, target string) (*models.Lockdown, bool) {
+	m.mu.RLock()
+	l, ok := m.active[key(scope, target)]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	if l.ExpiresAt != nil && time.Now().After(*l.ExpiresAt) {
+		if err := sqlite.LiftLockdown(ctx, l.ID, "expired", *l.ExpiresAt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("⚠️ Failed to expire lockdown %d: %v", l.ID, err)
+			return &l, true
+		}
+		m.mu.Lock()
+		delete(m.active, key(scope, target))
+		m.mu.Unlock()
+		audit(ctx, systemActor, "lockdown_expired", l)
+		return nil, false
+	}
+	return &l, true
+}
+
+// Active returns all lockdowns currently in force
+func Active(ctx context.Context) []models.Lockdown {
+	m.mu.RLock()
+	keys := make([][2]string, 0, len(m.active))
+	for _, l := range m.active {
+		keys = append(keys, [2]string{l.Scope, l.Target})
+	}
+	m.mu.RUnlock()
+
+	out := make([]models.Lockdown, 0, len(keys))
+	for _, k := range keys {
+		if l, ok := Check(ctx, k[0], k[1]); ok {
+			out = append(out, *l)
+		}
+	}
+	return out
+}
+
+// onTrace engages a lockdown for trace events flagged with lock or at/above the severity threshold
+func onTrace(ev trace.Event) {
+	m.mu.RLock()
+	threshold := m.threshold
+	m.mu.RUnlock()
+
+	triggered := ev.Lock || (threshold >= 0 && trace.SeverityRank(ev.Severity) >= threshold)
+	if !triggered {
+		return
+	}
+
+	// Only an explicit lock flag may take the whole server down; threshold
+	// triggers need a user or IP to aim at.
+	scope := ev.Scope
+	if scope == "" {
+		if !ev.Lock {
+			return
+		}
+		scope = ScopeServer
+	}
+	reason := fmt.Sprintf("trace %s (%s): %s", ev.Event, ev.Severity, ev.Message)
+	if _, err := Engage(context.Background(), scope, ev.Target, reason, Actor{Name: "trace:" + strings.ToLower(ev.Actor)}); err != nil {
+		log.Printf("⚠️ Trace %s requested lockdown but it failed: %v", ev.ID, err)
+	}
+}
+
+// audit records a transition of l. Reason names the lockdown so the trail shows what was
+// locked; for lifts of lockdowns no longer cached only the id is known.
+func audit(ctx context.Context, by Actor, eventType string, l models.Lockdown) {
+	reason := fmt.Sprintf("lockdown=%d scope=%s", l.ID, l.Scope)
+	if l.Target != "" {
+		reason += " target=" + l.Target
+	}
+	if l.Reason != "" {
+		reason += " reason=" + strconv.Quote(l.Reason)
+	}
+	err := sqlite.InsertAuditEvent(ctx, &models.AuditEvent{
+		Username:  by.Name,
+		Subject:   by.Subject,
+		EventType: eventType,
+		IPAddress: by.IP,
+		UserAgent: by.UserAgent,
+		Outcome:   models.OutcomeSuccess,
+		Reason:    reason,
+		RequestID: by.RequestID,
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to audit %s: %v", eventType, err)
+	}
+}
+
+func key(scope, target string) string {
+	return scope + "|" + target
+}
@@ -0,0 +1,53 @@
+package lockdown
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+func TestEngageConcurrentSameTarget(t *testing.T) {
+	sqlite.InitDB(filepath.Join(t.TempDir(), "lockdown.db"))
+	t.Cleanup(func() { _ = sqlite.Close() })
+	m.mu.Lock()
+	m.active = map[string]models.Lockdown{}
+	m.mu.Unlock()
+	ctx := context.Background()
+
+	const callers = 20
+	ids := make([]int64, callers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			l, err := Engage(ctx, ScopeIP, "203.0.113.7", "test", systemActor)
+			if err != nil {
+				t.Errorf("Engage: %v", err)
+				return
+			}
+			ids[i] = l.ID
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("caller %d got lockdown %d, caller 0 got %d", i, id, ids[0])
+		}
+	}
+	rows, err := sqlite.ListLockdowns(ctx, false, -1)
+	if err != nil {
+		t.Fatalf("ListLockdowns: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("%d active lockdown rows, want 1", len(rows))
+	}
+}
@@ -0,0 +1,104 @@
+// Package logging configures the process-wide log/slog logger: output format, level,
+// request-id and trace-id enrichment and redaction of credentials and email addresses.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is shared by the installed handler so SetLevel takes effect without rebuilding it
+var level slog.LevelVar
+
+// Init installs the configured handler as the slog default. The standard log package is
+// routed through it too, so existing log.Printf calls gain level, time and redaction.
+func Init(cfg *config.Config) error {
+	if err := SetLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: &level, ReplaceAttr: redactAttr}
+
+	var h slog.Handler
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", FormatText:
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("PEITHO_LOG_FORMAT must be text or json, got %q", cfg.LogFormat)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// SetLevel changes the minimum level of the installed logger
+func SetLevel(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// ParseLevel accepts debug, info, warn or error; empty means info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("PEITHO_LOG_LEVEL must be debug, info, warn or error, got %q", s)
+	}
+	return level, nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying the request correlation id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the correlation id stored by WithRequestID, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds request_id, and trace_id when the request is traced, to every record
+// logged with a request context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretSuffixes mark attribute keys whose values are never logged. Matching is on the
+// lower-cased key suffix so "refresh_token" and "LicenseToken" match but "token_type" does not.
+var secretSuffixes = []string{"password", "passwd", "secret", "token", "key", "authorization", "cookie"}
+
+var (
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer|basic)\s+[A-Za-z0-9._~+/=\-]+`)
+)
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if isSecretKey(a.Key) {
+		if a.Value.Kind() == slog.KindString && a.Value.String() == "" {
+			return a // unset stays visibly unset
+		}
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	}
+	return a
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretSuffixes {
+		if strings.HasSuffix(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub masks email addresses (keeping the first character and the domain) and removes
+// JWTs and Authorization credentials from free text
+func Scrub(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+redacted)
+	return emailPattern.ReplaceAllString(s, "$1***@$2")
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Login results
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+var (
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_login_attempts_total",
+		Help: "Login attempts, by result (success, failure) and failure reason",
+	}, []string{"result", "reason"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_rate_limit_rejections_total",
+		Help: "Requests rejected with 429 by a rate limiter, by limiter",
+	}, []string{"limiter"})
+
+	KeycloakRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "peitho_keycloak_request_duration_seconds",
+		Help:    "Keycloak call latency, by operation and outcome (2xx, 4xx, 5xx, error)",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation", "outcome"})
+)
+
+// ObserveKeycloak records a Keycloak call that started at start. status is the HTTP status,
+// or 0 when the request failed before a response arrived.
+func ObserveKeycloak(operation string, start time.Time, status int) {
+	outcome := "error"
+	if status > 0 {
+		outcome = strconv.Itoa(status/100) + "xx"
+	}
+	KeycloakRequestDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+func RegisterAuthMetrics() {
+	register(LoginAttempts)
+	register(RateLimitRejections)
+	register(KeycloakRequestDuration)
+}
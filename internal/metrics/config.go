@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_config_reloads_total",
+		Help: "Configuration reload attempts, by trigger (signal, file) and result (applied, unchanged, invalid)",
+	}, []string{"trigger", "result"})
+
+	ConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_config_last_reload_success_timestamp_seconds",
+		Help: "Unix time of the last reload that loaded a valid configuration",
+	})
+
+	ConfigRestartRequired = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_config_restart_required",
+		Help: "1 when the loaded configuration changes settings that only apply after a restart",
+	})
+)
+
+func RegisterConfigMetrics() {
+	register(ConfigReloads)
+	register(ConfigLastReloadSuccess)
+	register(ConfigRestartRequired)
+}
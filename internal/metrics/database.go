@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "peitho_db_query_duration_seconds",
+	Help:    "SQLite query latency, by query",
+	Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"query"})
+
+// ObserveQuery starts timing query; call the returned func when it completes
+func ObserveQuery(query string) func() {
+	start := time.Now()
+	return func() { DBQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds()) }
+}
+
+func RegisterDBMetrics() {
+	register(DBQueryDuration)
+}
@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var EmailsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "peitho_emails_sent_total",
+	Help: "Emails handed to the SMTP server, by kind (verify, reset) and outcome (sent, failed)",
+}, []string{"kind", "outcome"})
+
+func RegisterEmailMetrics() {
+	register(EmailsSent)
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_http_requests_total",
+		Help: "HTTP requests served, by method, route template and status code",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "peitho_http_request_duration_seconds",
+		Help:    "HTTP request latency, by method, route template and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_http_requests_in_flight",
+		Help: "HTTP requests currently being served",
+	})
+)
+
+func RegisterHTTPMetrics() {
+	register(HTTPRequests)
+	register(HTTPRequestDuration)
+	register(HTTPInFlight)
+}
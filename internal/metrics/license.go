@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	LicenseValid = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_license_valid",
+		Help: "1 when unlock.lic is present and its signature verifies, as of the last check",
+	})
+
+	LicenseIssued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_license_issued_timestamp_seconds",
+		Help: "Unix time the current license was issued, 0 when unknown",
+	})
+
+	LicenseLastCheck = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "peitho_license_last_check_timestamp_seconds",
+		Help: "Unix time the license was last checked",
+	})
+)
+
+func RegisterLicenseMetrics() {
+	register(LicenseValid)
+	register(LicenseIssued)
+	register(LicenseLastCheck)
+}
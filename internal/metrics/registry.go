@@ -0,0 +1,40 @@
+// Package metrics defines the Prometheus metrics peitho-server exports. Everything is
+// registered on Registry rather than the client library's global registry, so registering
+// twice, as tests and repeated Init calls do, is harmless instead of a panic.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every peitho metric plus the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	register(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// register adds collectors to Registry, skipping any already registered
+func register(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := Registry.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				panic(err)
+			}
+		}
+	}
+}
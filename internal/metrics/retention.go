@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	RetentionRowsPruned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_retention_rows_pruned_total",
+		Help: "Rows deleted by retention policies, by table",
+	}, []string{"table"})
+
+	RetentionRowsArchived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_retention_rows_archived_total",
+		Help: "Rows written to archive files before being pruned, by table",
+	}, []string{"table"})
+
+	SchedulerJobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_scheduler_job_runs_total",
+		Help: "Background job runs, by job and outcome (success, error, panic)",
+	}, []string{"job", "outcome"})
+)
+
+func RegisterRetentionMetrics() {
+	register(RetentionRowsPruned)
+	register(RetentionRowsArchived)
+	register(SchedulerJobRuns)
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	SecurityEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_security_events_total",
+		Help: "Security events published on the event bus, by type",
+	}, []string{"type"})
+
+	EventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "peitho_event_bus_dropped_total",
+		Help: "Events dropped because an async subscriber queue was full, by subscriber",
+	}, []string{"subscriber"})
+)
+
+func RegisterEventMetrics() {
+	register(SecurityEvents)
+	register(EventsDropped)
+}
@@ -22,7 +22,7 @@ var (
 )
 
 func RegisterTokenMetrics() {
-	prometheus.MustRegister(TokensIssued)
-	prometheus.MustRegister(TokensRefreshed)
-	prometheus.MustRegister(TokensRevoked)
+	register(TokensIssued)
+	register(TokensRefreshed)
+	register(TokensRevoked)
 }
@@ -4,6 +4,8 @@ import (
 	"sync/atomic"
 )
 
+// Running totals behind /api/v1/metrics/tokens. The Inc functions also feed the
+// peitho_tokens_* counters, so the JSON endpoint and Prometheus always agree.
 var (
 	IssuedTokens    atomic.Int64
 	RefreshedTokens atomic.Int64
@@ -13,12 +15,15 @@ var (
 
 func IncIssued() {
 	IssuedTokens.Add(1)
+	TokensIssued.Inc()
 }
 
 func IncRefreshed() {
 	RefreshedTokens.Add(1)
+	TokensRefreshed.Inc()
 }
 
 func IncRevoked() {
 	RevokedTokens.Add(1)
+	TokensRevoked.Inc()
 }
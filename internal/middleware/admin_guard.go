@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
+)
+
+// Admin authentication methods, as listed in PEITHO_ADMIN_AUTH
+const (
+	AdminAuthBearer = "bearer"
+	AdminAuthBasic  = "basic"
+	AdminAuthNone   = "none"
+)
+
+const adminContextKey contextKey = "admin"
+
+const adminRealm = "peitho-admin"
+
+// AdminPolicy decides who AdminGuard lets through, from the PEITHO_ADMIN_* settings
+type AdminPolicy struct {
+	// Methods holds bearer and/or basic; none switches the admin endpoints off
+	Methods      []string
+	Role         string // realm role bearer tokens must carry
+	Username     string
+	PasswordHash string   // argon2id in PHC format, as produced by passwordpolicy.Hash
+	AllowedIPs   []string // CIDRs or bare addresses; empty allows any caller
+}
+
+// AdminIdentity is the caller AdminGuard admitted and how they authenticated
+type AdminIdentity struct {
+	Name   string
+	Method string
+}
+
+// adminRules is an AdminPolicy prepared for checking. It is swapped whole on config reload,
+// which also forgets the cached basic-auth credentials.
+type adminRules struct {
+	bearer, basic bool
+	role          string
+	userDigest    [sha256.Size]byte
+	passwordHash  string
+	allowed       []netip.Prefix
+
+	// digest of the last basic-auth credentials that passed argon2id verification
+	verified atomic.Pointer[[sha256.Size]byte]
+}
+
+var adminPolicy atomic.Pointer[adminRules]
+
+// adminVerifySlots bounds concurrent argon2id verifications, each of which holds 19 MiB, so a
+// burst of bad basic-auth attempts cannot exhaust memory or CPU. Callers beyond it get 429.
+var adminVerifySlots = make(chan struct{}, 4)
+
+var errAdminBusy = errors.New("admin credential verification busy")
+
+func init() {
+	_ = SetAdminPolicy(AdminPolicy{Methods: []string{AdminAuthBearer}, Role: "admin"})
+}
+
+// SetAdminPolicy replaces the policy AdminGuard enforces. A policy that names no method, or
+// a method without the settings it needs, is refused and the current one kept, so admin
+// endpoints never end up accepting empty credentials.
+func SetAdminPolicy(p AdminPolicy) error {
+	apply, err := PrepareAdminPolicy(p)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// PrepareAdminPolicy checks p as SetAdminPolicy does and returns the function that installs
+// it, so a reload can validate every component before changing any of them
+func PrepareAdminPolicy(p AdminPolicy) (apply func(), err error) {
+	rules, err := compileAdminPolicy(p)
+	if err != nil {
+		return nil, err
+	}
+	return func() { adminPolicy.Store(rules) }, nil
+}
+
+func compileAdminPolicy(p AdminPolicy) (*adminRules, error) {
+	rules := &adminRules{role: p.Role, passwordHash: p.PasswordHash}
+	disabled := false
+	for _, m := range p.Methods {
+		switch m {
+		case AdminAuthBearer:
+			rules.bearer = true
+		case AdminAuthBasic:
+			rules.basic = true
+		case AdminAuthNone:
+			disabled = true
+		default:
+			return nil, fmt.Errorf("unknown admin auth method %q", m)
+		}
+	}
+
+	switch {
+	case disabled && (rules.bearer || rules.basic):
+		return nil, errors.New("admin auth method none cannot be combined with others")
+	case disabled:
+		return &adminRules{}, nil
+	case !rules.bearer && !rules.basic:
+		return nil, errors.New("no admin auth method configured")
+	case rules.bearer && p.Role == "":
+		return nil, errors.New("bearer admin auth requires a role")
+	case rules.basic && (p.Username == "" || p.PasswordHash == ""):
+		return nil, errors.New("basic admin auth requires a username and a password hash")
+	}
+	if rules.basic {
+		if _, err := passwordpolicy.VerifyHash(p.PasswordHash, ""); err != nil {
+			return nil, fmt.Errorf("admin password hash: %w", err)
+		}
+		rules.userDigest = sha256.Sum256([]byte(p.Username))
+	}
+
+	allowed, err := parsePrefixes("admin allowed IP", p.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	rules.allowed = allowed
+	return rules, nil
+}
+
+// AdminEnabled reports whether the admin endpoints accept any caller at all
+func AdminEnabled() bool {
+	a := adminPolicy.Load()
+	return a.bearer || a.basic
+}
+
+// AdminGuard protects the admin endpoints. Callers must come from an allowed address and
+// present either a Keycloak bearer token carrying the admin role or the static basic-auth
+// credentials, depending on the configured methods. Disabled admin endpoints answer 404.
+func AdminGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := adminPolicy.Load()
+		if !a.bearer && !a.basic {
+			problem.Respond(w, r, "admin_disabled", http.StatusNotFound)
+			return
+		}
+		if len(a.allowed) > 0 {
+			ip, err := netip.ParseAddr(ClientIP(r))
+			if err != nil || !prefixesContain(a.allowed, ip) {
+				slog.Warn("🛡️ Admin request from outside the allowlist", "ip", ClientIP(r), "path", r.URL.Path)
+				problem.Respond(w, r, "admin_ip_forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
+		authHeader := r.Header.Get("Authorization")
+		scheme, token, _ := strings.Cut(authHeader, " ")
+		switch {
+		case authHeader == "":
+			a.challenge(w)
+			problem.Respond(w, r, "missing_auth_header", http.StatusUnauthorized)
+		case a.bearer && strings.EqualFold(scheme, "bearer"):
+			a.serveBearer(w, r, next, token)
+		case a.basic && strings.EqualFold(scheme, "basic"):
+			a.serveBasic(w, r, next)
+		default:
+			a.challenge(w)
+			problem.RespondWithDetail(w, r, "invalid_auth_format", http.StatusUnauthorized,
+				"Admin endpoints accept "+a.schemes())
+		}
+	})
+}
+
+func (a *adminRules) serveBearer(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	claims, err := validateJWT(token)
+	if err != nil {
+		problem.Respond(w, r, "invalid_token", tokenErrorStatus(err))
+		return
+	}
+	if !hasRealmRole(claims, a.role) {
+		problem.Respond(w, r, "insufficient_role", http.StatusForbidden)
+		return
+	}
+	name, _ := claims["preferred_username"].(string)
+	ctx := context.WithValue(r.Context(), UserContextKey, claims)
+	ctx = context.WithValue(ctx, adminContextKey, AdminIdentity{Name: name, Method: AdminAuthBearer})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (a *adminRules) serveBasic(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	username, password, ok := r.BasicAuth()
+	if ok {
+		var err error
+		ok, err = a.checkBasic(username, password)
+		if errors.Is(err, errAdminBusy) {
+			w.Header().Set("Retry-After", "1")
+			problem.Respond(w, r, "admin_auth_busy", http.StatusTooManyRequests)
+			return
+		}
+	}
+	if !ok {
+		slog.Warn("🛡️ Admin basic auth rejected", "ip", ClientIP(r), "path", r.URL.Path)
+		a.challenge(w)
+		problem.Respond(w, r, "invalid_admin_credentials", http.StatusUnauthorized)
+		return
+	}
+	ctx := context.WithValue(r.Context(), adminContextKey, AdminIdentity{Name: username, Method: AdminAuthBasic})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// checkBasic compares the username in constant time and runs argon2id only when it matches.
+// Skipping the hash lets timing reveal whether the username was right; that is the price of
+// not letting anonymous callers make the server hash at will, and the password stays
+// protected either way. Verifications are capped by adminVerifySlots. The last accepted
+// credentials are remembered as a digest, sparing a scraper polling every few seconds the
+// argon2id cost.
+func (a *adminRules) checkBasic(username, password string) (bool, error) {
+	presented := sha256.Sum256([]byte(username + "\x00" + password))
+	if last := a.verified.Load(); last != nil && subtle.ConstantTimeCompare(last[:], presented[:]) == 1 {
+		return true, nil
+	}
+
+	userDigest := sha256.Sum256([]byte(username))
+	if subtle.ConstantTimeCompare(userDigest[:], a.userDigest[:]) != 1 {
+		return false, nil
+	}
+	select {
+	case adminVerifySlots <- struct{}{}:
+		defer func() { <-adminVerifySlots }()
+	default:
+		return false, errAdminBusy
+	}
+	ok, err := passwordpolicy.VerifyHash(a.passwordHash, password)
+	if err != nil || !ok {
+		return false, nil
+	}
+	a.verified.Store(&presented)
+	return true, nil
+}
+
+// challenge tells the client which schemes it may answer with
+func (a *adminRules) challenge(w http.ResponseWriter) {
+	if a.bearer {
+		w.Header().Add("WWW-Authenticate", `Bearer realm="`+adminRealm+`"`)
+	}
+	if a.basic {
+		w.Header().Add("WWW-Authenticate", `Basic realm="`+adminRealm+`", charset="UTF-8"`)
+	}
+}
+
+func (a *adminRules) schemes() string {
+	switch {
+	case a.bearer && a.basic:
+		return "Bearer or Basic authorization"
+	case a.basic:
+		return "Basic authorization only"
+	default:
+		return "Bearer tokens only"
+	}
+}
+
+// AdminFromContext returns the caller AdminGuard admitted, if the request went through it
+func AdminFromContext(ctx context.Context) (AdminIdentity, bool) {
+	id, ok := ctx.Value(adminContextKey).(AdminIdentity)
+	return id, ok
+}
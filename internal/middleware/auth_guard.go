@@ -8,7 +8,7 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 )
 
 // Context key for passing claims
@@ -21,29 +21,28 @@ var (
 	issuer  = "http://keycloak:8080/realms/peitho"
 )
 
+// JWKSURL returns the key set endpoint tokens are verified against
+func JWKSURL() string { return jwksURL }
+
 // AuthGuard validates Bearer JWT properly
 func AuthGuard(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			corestub.RespondWithTraceError(w, "missing_auth_header", http.StatusUnauthorized)
+			problem.Respond(w, r, "missing_auth_header", http.StatusUnauthorized)
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			corestub.RespondWithTraceError(w, "invalid_auth_format", http.StatusUnauthorized)
+			problem.Respond(w, r, "invalid_auth_format", http.StatusUnauthorized)
 			return
 		}
 
 		tokenString := parts[1]
 		claims, err := validateJWT(tokenString)
 		if err != nil {
-			code := http.StatusUnauthorized
-			if strings.Contains(err.Error(), "no key found") || strings.Contains(err.Error(), "issuer") {
-				code = http.StatusForbidden
-			}
-			corestub.RespondWithTraceError(w, "invalid_token", code)
+			problem.Respond(w, r, "invalid_token", tokenErrorStatus(err))
 			return
 		}
 
@@ -53,6 +52,14 @@ func AuthGuard(next http.Handler) http.Handler {
 	})
 }
 
+// tokenErrorStatus maps a validateJWT failure to 401, or 403 for tokens from another issuer
+func tokenErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "no key found") || strings.Contains(err.Error(), "issuer") {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
 func validateJWT(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Enforce RSA signing method
@@ -96,10 +103,14 @@ func validateJWT(tokenString string) (jwt.MapClaims, error) {
 	return claims, nil
 }
 
-// ExtractUsernameFromContext gets the username from JWT claims
+// ExtractUsernameFromContext gets the username from JWT claims, or for admins who signed in
+// with basic auth the configured admin username
 func ExtractUsernameFromContext(ctx context.Context) (string, error) {
 	claims, ok := ctx.Value(UserContextKey).(jwt.MapClaims)
 	if !ok {
+		if admin, ok := AdminFromContext(ctx); ok && admin.Name != "" {
+			return admin.Name, nil
+		}
 		return "", errors.New("no user claims in context")
 	}
 	username, ok := claims["preferred_username"].(string)
@@ -108,3 +119,46 @@ func ExtractUsernameFromContext(ctx context.Context) (string, error) {
 	}
 	return username, nil
 }
+
+// ExtractSubjectFromContext gets the Keycloak user id (sub) from JWT claims, or "" when unauthenticated
+func ExtractSubjectFromContext(ctx context.Context) string {
+	claims, _ := ctx.Value(UserContextKey).(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// RequireRole rejects requests whose JWT claims lack the given Keycloak realm role.
+// It must run after AuthGuard.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(UserContextKey).(jwt.MapClaims)
+			if !ok {
+				problem.Respond(w, r, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !hasRealmRole(claims, role) {
+				problem.Respond(w, r, "insufficient_role", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasRealmRole(claims jwt.MapClaims, role string) bool {
+	access, ok := claims["realm_access"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	roles, ok := access["roles"].([]interface{})
+	if !ok {
+		return false
+	}
+	for _, r := range roles {
+		if name, ok := r.(string); ok && name == role {
+			return true
+		}
+	}
+	return false
+}
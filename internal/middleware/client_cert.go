@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+const clientIdentityKey contextKey = "client_identity"
+
+// ClientIdentity describes the verified certificate an mTLS caller presented
+type ClientIdentity struct {
+	Subject     string   `json:"subject"`             // certificate subject common name
+	DNSNames    []string `json:"dns_names,omitempty"` // DNS subject alternative names
+	URIs        []string `json:"uris,omitempty"`      // URI SANs, e.g. SPIFFE ids
+	Serial      string   `json:"serial"`              // serial number in hex
+	Fingerprint string   `json:"fingerprint"`         // SHA-256 of the DER certificate, hex
+	Issuer      string   `json:"issuer"`              // issuer common name
+}
+
+// ClientCert records the identity of a verified client certificate in the request context.
+// Only certificates that chained to TLS_CLIENT_CA_FILE count; without one, and in the http
+// and tls listen modes, the request passes through unchanged.
+func ClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cert := r.TLS.VerifiedChains[0][0]
+		sum := sha256.Sum256(cert.Raw)
+		id := ClientIdentity{
+			Subject:     cert.Subject.CommonName,
+			DNSNames:    cert.DNSNames,
+			Serial:      cert.SerialNumber.Text(16),
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Issuer:      cert.Issuer.CommonName,
+		}
+		for _, u := range cert.URIs {
+			id.URIs = append(id.URIs, u.String())
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIdentityKey, id)))
+	})
+}
+
+// ClientIdentityFromContext returns the caller's verified certificate identity, if any
+func ClientIdentityFromContext(ctx context.Context) (ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey).(ClientIdentity)
+	return id, ok
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+// SetTrustedProxies replaces the proxies whose X-Forwarded-For / X-Real-IP headers are believed.
+// Entries are CIDRs ("10.0.0.0/8") or bare addresses. An empty list trusts no one.
+func SetTrustedProxies(entries []string) error {
+	prefixes, err := parsePrefixes("trusted proxy", entries)
+	if err != nil {
+		return err
+	}
+	trustedProxies.Store(&prefixes)
+	return nil
+}
+
+// parsePrefixes reads CIDRs and bare addresses, the latter as single-address prefixes. kind
+// names the setting in errors.
+func parsePrefixes(kind string, entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		if strings.Contains(e, "/") {
+			p, err := netip.ParsePrefix(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %w", kind, e, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(e)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", kind, e, err)
+		}
+		a = a.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func isTrustedProxy(a netip.Addr) bool {
+	p := trustedProxies.Load()
+	if p == nil {
+		return false
+	}
+	return prefixesContain(*p, a)
+}
+
+func prefixesContain(prefixes []netip.Prefix, a netip.Addr) bool {
+	a = a.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the caller's IP address without the port. When the direct peer is a
+// trusted proxy, X-Forwarded-For is walked right to left and the first hop that is not
+// itself a trusted proxy wins; X-Real-IP is used when there is no forwarded chain.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !isTrustedProxy(peer) {
+		return host
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break // garbage in the chain; stop at the last hop we could vouch for
+			}
+			if !isTrustedProxy(hop) {
+				return hop.Unmap().String()
+			}
+			peer = hop
+		}
+		return peer.Unmap().String()
+	}
+
+	if real, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return real.Unmap().String()
+	}
+	return host
+}
@@ -2,18 +2,153 @@ package middleware
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
-// CORS middleware for web support
+// CORSPolicy is the cross-origin policy CorsMiddleware enforces, from the CORS_* settings
+type CORSPolicy struct {
+	// Origins holds exact origins ("https://app.example.com"), wildcard subdomains
+	// ("https://*.example.com") or "*" for any origin
+	Origins          []string
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration // how long browsers may cache a preflight
+}
+
+// corsRules is a CORSPolicy prepared for matching. It is swapped whole on config reload.
+type corsRules struct {
+	anyOrigin   bool
+	exact       map[string]bool
+	wildcards   []wildcardOrigin
+	credentials bool
+	methods     string
+	headers     string
+	exposed     string
+	maxAge      string
+}
+
+// wildcardOrigin matches scheme://<one or more labels>.suffix[:port]
+type wildcardOrigin struct {
+	scheme, suffix, port string
+}
+
+var corsPolicy atomic.Pointer[corsRules]
+
+func init() {
+	SetCORSPolicy(CORSPolicy{
+		Origins:        []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", RequestIDHeader},
+		ExposedHeaders: []string{RequestIDHeader, "X-Next-Cursor"},
+		MaxAge:         10 * time.Minute,
+	})
+}
+
+// SetCORSPolicy replaces the policy CorsMiddleware enforces. Origins are expected to be
+// validated already, as config.Load does; malformed ones never match.
+func SetCORSPolicy(p CORSPolicy) {
+	rules := &corsRules{
+		exact:       map[string]bool{},
+		credentials: p.AllowCredentials,
+		methods:     strings.Join(p.AllowedMethods, ", "),
+		headers:     strings.Join(p.AllowedHeaders, ", "),
+		exposed:     strings.Join(p.ExposedHeaders, ", "),
+		maxAge:      strconv.Itoa(int(p.MaxAge.Seconds())),
+	}
+	for _, o := range p.Origins {
+		if o == "*" {
+			rules.anyOrigin = true
+			continue
+		}
+		u, err := url.Parse(strings.ToLower(o))
+		if err != nil {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(u.Hostname(), "*."); ok {
+			rules.wildcards = append(rules.wildcards, wildcardOrigin{scheme: u.Scheme, suffix: "." + suffix, port: u.Port()})
+			continue
+		}
+		rules.exact[u.Scheme+"://"+u.Host] = true
+	}
+	corsPolicy.Store(rules)
+}
+
+// allowed reports whether origin may make cross-origin requests
+func (c *corsRules) allowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.exact[origin] {
+		return true
+	}
+	if len(c.wildcards) == 0 {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, w := range c.wildcards {
+		if u.Scheme == w.scheme && u.Port() == w.port && strings.HasSuffix(u.Hostname(), w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CorsMiddleware answers preflight requests and adds CORS headers for allowed origins. With
+// "*" and no credentials every response carries Access-Control-Allow-Origin: *; otherwise
+// the request's Origin is echoed back only when it is allowed, and caches are told the
+// response varies by Origin.
 func CorsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow all origins for now (only for dev)
-		w.Header().Set("Access-Control-Allow-Origin", "*") // TODO: restrict for prod
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c := corsPolicy.Load()
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		h := w.Header()
+		wildcard := c.anyOrigin && !c.credentials
+		if !wildcard {
+			h.Add("Vary", "Origin")
+		}
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		switch {
+		case wildcard:
+			h.Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && c.allowed(origin):
+			h.Set("Access-Control-Allow-Origin", origin)
+			if c.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		default:
+			origin = "" // not allowed: no CORS headers, so the browser blocks the response
+		}
+
+		if wildcard || origin != "" {
+			if preflight {
+				h.Set("Access-Control-Allow-Methods", c.methods)
+				if c.headers != "" {
+					h.Set("Access-Control-Allow-Headers", c.headers)
+				}
+				h.Set("Access-Control-Max-Age", c.maxAge)
+			} else if c.exposed != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposed)
+			}
+		}
 
 		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
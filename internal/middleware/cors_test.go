@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// withCORSPolicy installs p for the rest of the test
+func withCORSPolicy(t *testing.T, p CORSPolicy) {
+	t.Helper()
+	prev := corsPolicy.Load()
+	t.Cleanup(func() { corsPolicy.Store(prev) })
+	SetCORSPolicy(p)
+}
+
+func TestCORSOriginMatching(t *testing.T) {
+	withCORSPolicy(t, CORSPolicy{Origins: []string{
+		"https://app.example.com",
+		"http://localhost:3000",
+		"https://*.example.org",
+		"https://*.staging.example.net:8443",
+	}})
+	rules := corsPolicy.Load()
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://App.Example.com", true},
+		{"http://app.example.com", false},          // scheme differs
+		{"https://app.example.com:8443", false},    // port differs
+		{"https://evil.example.com", false},        // not listed
+		{"https://app.example.com.evil.io", false}, // suffix of an exact origin
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true}, // wildcards cover nested labels
+		{"https://example.org", false},    // the bare domain is not a subdomain
+		{"https://badexample.org", false}, // label boundary
+		{"http://a.example.org", false},
+		{"https://a.example.org:444", false},
+		{"https://x.staging.example.net:8443", true},
+		{"https://x.staging.example.net", false},
+		{"null", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.origin); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCorsMiddleware(t *testing.T) {
+	base := CORSPolicy{
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{RequestIDHeader},
+		MaxAge:         5 * time.Minute,
+	}
+	policy := func(credentials bool, origins ...string) CORSPolicy {
+		p := base
+		p.Origins, p.AllowCredentials = origins, credentials
+		return p
+	}
+
+	tests := []struct {
+		name        string
+		policy      CORSPolicy
+		method      string
+		origin      string
+		preflight   bool
+		wantOrigin  string
+		wantCreds   bool
+		wantVary    []string
+		wantStatus  int
+		wantHandler bool
+	}{
+		{
+			name: "wildcard without credentials", policy: policy(false, "*"),
+			method: http.MethodGet, origin: "https://any.io",
+			wantOrigin: "*", wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "wildcard with credentials echoes origin", policy: policy(true, "*"),
+			method: http.MethodGet, origin: "https://any.io",
+			wantOrigin: "https://any.io", wantCreds: true, wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "exact origin allowed", policy: policy(false, "https://app.example.com"),
+			method: http.MethodPost, origin: "https://app.example.com",
+			wantOrigin: "https://app.example.com", wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "exact origin with credentials", policy: policy(true, "https://app.example.com"),
+			method: http.MethodGet, origin: "https://app.example.com",
+			wantOrigin: "https://app.example.com", wantCreds: true, wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "origin not allowed still reaches handler without CORS headers", policy: policy(true, "https://app.example.com"),
+			method: http.MethodGet, origin: "https://evil.io",
+			wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "wildcard subdomain", policy: policy(false, "https://*.example.com"),
+			method: http.MethodGet, origin: "https://tenant.example.com",
+			wantOrigin: "https://tenant.example.com", wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "same-origin request without Origin", policy: policy(false, "https://app.example.com"),
+			method:   http.MethodGet,
+			wantVary: []string{"Origin"}, wantStatus: http.StatusOK, wantHandler: true,
+		},
+		{
+			name: "preflight allowed", policy: policy(true, "https://app.example.com"),
+			method: http.MethodOptions, origin: "https://app.example.com", preflight: true,
+			wantOrigin: "https://app.example.com", wantCreds: true,
+			wantVary:   []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "preflight refused", policy: policy(false, "https://app.example.com"),
+			method: http.MethodOptions, origin: "https://evil.io", preflight: true,
+			wantVary:   []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCORSPolicy(t, tt.policy)
+			called := false
+			h := CorsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+			req := httptest.NewRequest(tt.method, "/api/v1/ping", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			got := rec.Header()
+
+			if rec.Code != tt.wantStatus || called != tt.wantHandler {
+				t.Fatalf("status %d, handler called %v; want %d, %v", rec.Code, called, tt.wantStatus, tt.wantHandler)
+			}
+			if o := got.Get("Access-Control-Allow-Origin"); o != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", o, tt.wantOrigin)
+			}
+			if c := got.Get("Access-Control-Allow-Credentials") == "true"; c != tt.wantCreds {
+				t.Errorf("Access-Control-Allow-Credentials set = %v, want %v", c, tt.wantCreds)
+			}
+			if v := got.Values("Vary"); !slices.Equal(v, tt.wantVary) {
+				t.Errorf("Vary = %v, want %v", v, tt.wantVary)
+			}
+
+			allowed := tt.wantOrigin != ""
+			if tt.preflight {
+				if m := got.Get("Access-Control-Allow-Methods"); (m == "GET, POST") != allowed {
+					t.Errorf("Access-Control-Allow-Methods = %q", m)
+				}
+				if a := got.Get("Access-Control-Max-Age"); (a == "300") != allowed {
+					t.Errorf("Access-Control-Max-Age = %q", a)
+				}
+			} else if e := got.Get("Access-Control-Expose-Headers"); (e == RequestIDHeader) != allowed {
+				t.Errorf("Access-Control-Expose-Headers = %q", e)
+			}
+		})
+	}
+}
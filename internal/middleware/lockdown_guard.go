@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/lockdown"
+)
+
+// LockdownGuard rejects requests while the server, the caller's IP or the authenticated user is locked down
+func LockdownGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, locked := lockdown.Check(r.Context(), lockdown.ScopeServer, ""); locked {
+			problem.Respond(w, r, "server_lockdown", http.StatusServiceUnavailable)
+			return
+		}
+
+		if _, locked := lockdown.Check(r.Context(), lockdown.ScopeIP, ClientIP(r)); locked {
+			problem.Respond(w, r, "ip_lockdown", http.StatusForbidden)
+			return
+		}
+
+		if username, err := ExtractUsernameFromContext(r.Context()); err == nil {
+			if _, locked := lockdown.Check(r.Context(), lockdown.ScopeUser, username); locked {
+				problem.Respond(w, r, "user_lockdown", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,20 +1,40 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
-// LoggerMiddleware logs incoming HTTP requests in a simple, structured way.
+// LoggerMiddleware writes one structured record per request once the response is complete.
+// The query string is left out because verification and reset links carry tokens in it.
+// 5xx responses log at error level and 4xx at warn.
 func LoggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		rec := NewResponseRecorder(w)
 
-		// Serve next handler
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rec, r)
 
-		duration := time.Since(start)
-		log.Printf("[%s] %s %s %s in %v", r.Method, r.RequestURI, r.RemoteAddr, r.UserAgent(), duration)
+		level := slog.LevelInfo
+		switch status := rec.Status(); {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status()),
+			slog.Int64("bytes", rec.BytesWritten()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", ClientIP(r)),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		if id, ok := ClientIdentityFromContext(r.Context()); ok {
+			attrs = append(attrs, slog.String("client_cert", id.Subject))
+		}
+		slog.LogAttrs(r.Context(), level, "http request", attrs...)
 	})
 }
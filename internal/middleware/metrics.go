@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+)
+
+// unmatchedRoute labels requests no route matched, so scanners probing random paths
+// cannot blow up the label set
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels request methods outside the standard set, which clients choose freely
+const otherMethod = "OTHER"
+
+// HTTPMetrics counts and times requests by method, route template and status. Install it
+// with Router.Use, where the matched route is known; requests that match nothing reach the
+// router's NotFound and MethodNotAllowed handlers instead, which should be wrapped too.
+func HTTPMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		rec := NewResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		labels := []string{methodLabel(r.Method), routeTemplate(r), strconv.Itoa(rec.Status())}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeTemplate returns the path template of the route r matched, such as
+// "/api/v1/admin/webhooks/{id:[0-9]+}", or unmatchedRoute
+func routeTemplate(r *http.Request) string {
+	if cur := mux.CurrentRoute(r); cur != nil {
+		if tpl, err := cur.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return unmatchedRoute
+}
+
+// methodLabel returns method if it is one of the methods net/http defines, else otherMethod
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
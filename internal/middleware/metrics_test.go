@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestHTTPMetricsMethodLabel(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{http.MethodGet, "GET"},
+		{http.MethodPost, "POST"},
+		{http.MethodOptions, "OPTIONS"},
+		{http.MethodTrace, "TRACE"},
+		{"get", otherMethod},
+		{"PROPFIND", otherMethod},
+		{"X-RANDOM-1234", otherMethod},
+	}
+
+	r := mux.NewRouter()
+	r.Use(HTTPMetrics)
+	r.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tt.want, "/probe", "200")
+			before := counterValue(t, counter)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/probe", nil))
+
+			if got := counterValue(t, counter) - before; got != 1 {
+				t.Errorf("%s request counted %v times under method=%q, want 1", tt.method, got, tt.want)
+			}
+		})
+	}
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("reading counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
@@ -10,14 +10,18 @@ import (
 	"sync"
 	"time"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
 )
 
-const (
-	maxAttempts     = 3
-	windowDuration  = 5 * time.Minute
-	lockoutDuration = 30 * time.Minute
-)
+// LoginPolicy bounds failed logins per username: MaxAttempts failures within Window lock
+// the account out for Lockout
+type LoginPolicy struct {
+	MaxAttempts int
+	Window      time.Duration
+	Lockout     time.Duration
+}
 
 type loginAttempt struct {
 	Count          int
@@ -27,9 +31,18 @@ type loginAttempt struct {
 
 var (
 	loginAttempts = make(map[string]*loginAttempt)
+	loginPolicy   = LoginPolicy{MaxAttempts: 3, Window: 5 * time.Minute, Lockout: 30 * time.Minute}
 	mu            sync.Mutex
 )
 
+// SetLoginPolicy replaces the login rate-limit policy. Lockouts already in force keep
+// their original expiry.
+func SetLoginPolicy(p LoginPolicy) {
+	mu.Lock()
+	defer mu.Unlock()
+	loginPolicy = p
+}
+
 // RateLimitLoginMiddleware enforces login attempt rate-limiting
 func RateLimitLoginMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -39,7 +52,7 @@ func RateLimitLoginMiddleware(next http.Handler) http.Handler {
 
 		username := extractUsername(bodyBytes)
 		if username == "" {
-			corestub.RespondWithTraceError(w, "missing_username", http.StatusBadRequest)
+			problem.Respond(w, r, "missing_username", http.StatusBadRequest)
 			return
 		}
 
@@ -53,7 +66,7 @@ func RateLimitLoginMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Reset window if expired
-		if now.Sub(attempt.FirstAttemptAt) > windowDuration {
+		if now.Sub(attempt.FirstAttemptAt) > loginPolicy.Window {
 			attempt.Count = 0
 			attempt.FirstAttemptAt = now
 			attempt.LockedUntil = time.Time{}
@@ -63,8 +76,16 @@ func RateLimitLoginMiddleware(next http.Handler) http.Handler {
 		if now.Before(attempt.LockedUntil) {
 			retryAfter := int(attempt.LockedUntil.Sub(now).Seconds())
 			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
-			corestub.RespondWithTraceError(w, "login_rate_limited", http.StatusTooManyRequests)
+			problem.Respond(w, r, "login_rate_limited", http.StatusTooManyRequests)
 			mu.Unlock()
+			metrics.RateLimitRejections.WithLabelValues("login").Inc()
+			events.Publish(events.Event{
+				Type:      events.RateLimited,
+				Username:  username,
+				IP:        ClientIP(r),
+				UserAgent: r.UserAgent(),
+				Reason:    "login attempt during lockout",
+			})
 			return
 		}
 
@@ -84,24 +105,31 @@ func extractUsername(body []byte) string {
 	return req.Username
 }
 
-func IncrementLoginFailure(username string) {
+// IncrementLoginFailure counts a failed login for username from r, locking the account out
+// once the policy's limit is reached
+func IncrementLoginFailure(r *http.Request, username string) {
 	mu.Lock()
-	defer mu.Unlock()
-
 	now := time.Now()
+	locked := false
 	attempt, exists := loginAttempts[username]
 	if !exists {
 		loginAttempts[username] = &loginAttempt{
 			Count:          1,
 			FirstAttemptAt: now,
 		}
-		return
+	} else {
+		attempt.Count++
+		if attempt.Count >= loginPolicy.MaxAttempts {
+			attempt.LockedUntil = now.Add(loginPolicy.Lockout)
+			locked = true
+		}
 	}
+	mu.Unlock()
 
-	attempt.Count++
-	if attempt.Count >= maxAttempts {
-		attempt.LockedUntil = now.Add(lockoutDuration)
-		logRateLimitTrigger(username)
+	// Published outside mu: a full audit queue blocks the publisher, and must not stall
+	// every other login meanwhile
+	if locked {
+		logRateLimitTrigger(r, username)
 	}
 }
 
@@ -133,6 +161,13 @@ func GetRetryAfterSeconds(username string) string {
 	return strconv.Itoa(int(attempt.LockedUntil.Sub(time.Now()).Seconds()))
 }
 
-func logRateLimitTrigger(username string) {
-	log.Printf("[🔥] Rate limit triggered for user: %s — roast tracking is disabled in public stub", username)
+func logRateLimitTrigger(r *http.Request, username string) {
+	log.Printf("[🔥] Rate limit triggered for user: %s", username)
+	events.Publish(events.Event{
+		Type:      events.UserLockedOut,
+		Username:  username,
+		IP:        ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Reason:    "too many failed logins",
+	})
 }
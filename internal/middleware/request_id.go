@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/logging"
+)
+
+// RequestIDHeader carries the correlation id in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID tags every request with a correlation id, reusing a well-formed incoming
+// X-Request-ID and generating one otherwise. The id is echoed on the response, attached
+// to every slog record logged with the request context and included in error bodies.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestIDFromContext returns the id set by RequestID, or "" outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	return logging.RequestID(ctx)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts up to 64 characters of [A-Za-z0-9._-] so client ids cannot inject into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import "net/http"
+
+// ResponseRecorder wraps a ResponseWriter to capture the status code and body size.
+// Flush and Unwrap pass through so streaming handlers and http.ResponseController keep working.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewResponseRecorder wraps w
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+func (rw *ResponseRecorder) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *ResponseRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *ResponseRecorder) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *ResponseRecorder) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
+
+// Status is the code sent to the client; 200 when the handler wrote nothing explicit
+func (rw *ResponseRecorder) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// BytesWritten is the number of body bytes written
+func (rw *ResponseRecorder) BytesWritten() int64 { return rw.bytes }
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSecurityPolicy installs p for the rest of the test
+func withSecurityPolicy(t *testing.T, p SecurityPolicy) {
+	t.Helper()
+	prev := securityPolicy.Load()
+	t.Cleanup(func() { securityPolicy.Store(prev) })
+	SetSecurityPolicy(p)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       SecurityPolicy
+		wantHSTS     string
+		wantReferrer string
+		wantFrame    string
+		wantCSPFrame string
+	}{
+		{
+			name:         "defaults",
+			policy:       SecurityPolicy{HSTSMaxAge: 365 * 24 * time.Hour, HSTSIncludeSubdomains: true, ReferrerPolicy: "no-referrer", FrameAncestors: []string{"'none'"}},
+			wantHSTS:     "max-age=31536000; includeSubDomains",
+			wantReferrer: "no-referrer",
+			wantFrame:    "DENY",
+			wantCSPFrame: "frame-ancestors 'none'",
+		},
+		{
+			name:         "preload",
+			policy:       SecurityPolicy{HSTSMaxAge: 2 * 365 * 24 * time.Hour, HSTSIncludeSubdomains: true, HSTSPreload: true, FrameAncestors: []string{"'self'"}},
+			wantHSTS:     "max-age=63072000; includeSubDomains; preload",
+			wantFrame:    "SAMEORIGIN",
+			wantCSPFrame: "frame-ancestors 'self'",
+		},
+		{
+			name:         "hsts disabled",
+			policy:       SecurityPolicy{ReferrerPolicy: "strict-origin", FrameAncestors: []string{"'none'"}},
+			wantReferrer: "strict-origin",
+			wantFrame:    "DENY",
+			wantCSPFrame: "frame-ancestors 'none'",
+		},
+		{
+			name:         "framing by listed origins has no X-Frame-Options equivalent",
+			policy:       SecurityPolicy{HSTSMaxAge: time.Hour, FrameAncestors: []string{"'self'", "https://portal.example.com"}},
+			wantHSTS:     "max-age=3600",
+			wantCSPFrame: "frame-ancestors 'self' https://portal.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSecurityPolicy(t, tt.policy)
+			rec := httptest.NewRecorder()
+			SecurityHeaders(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+				ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			h := rec.Header()
+
+			if got := h.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q", got)
+			}
+			if got := h.Get("Strict-Transport-Security"); got != tt.wantHSTS {
+				t.Errorf("Strict-Transport-Security = %q, want %q", got, tt.wantHSTS)
+			}
+			if got := h.Get("Referrer-Policy"); got != tt.wantReferrer {
+				t.Errorf("Referrer-Policy = %q, want %q", got, tt.wantReferrer)
+			}
+			if got := h.Get("X-Frame-Options"); got != tt.wantFrame {
+				t.Errorf("X-Frame-Options = %q, want %q", got, tt.wantFrame)
+			}
+			csp := h.Get("Content-Security-Policy")
+			if !strings.HasPrefix(csp, "default-src 'none';") || !strings.HasSuffix(csp, tt.wantCSPFrame) {
+				t.Errorf("Content-Security-Policy = %q, want default-src 'none' ... %s", csp, tt.wantCSPFrame)
+			}
+		})
+	}
+}
+
+func TestHTMLPageCSP(t *testing.T) {
+	withSecurityPolicy(t, SecurityPolicy{FrameAncestors: []string{"'none'"}})
+	rec := httptest.NewRecorder()
+	nonce := HTMLPageCSP(rec)
+	again := HTMLPageCSP(httptest.NewRecorder())
+
+	if nonce == "" || nonce == again {
+		t.Fatalf("nonces %q and %q must be non-empty and differ", nonce, again)
+	}
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'nonce-"+nonce+"'") || !strings.HasSuffix(csp, "frame-ancestors 'none'") {
+		t.Fatalf("Content-Security-Policy = %q", csp)
+	}
+}
+
+func TestDocsCSPOverridesDefault(t *testing.T) {
+	withSecurityPolicy(t, SecurityPolicy{FrameAncestors: []string{"'self'"}})
+	rec := httptest.NewRecorder()
+	SecurityHeaders(DocsCSP(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.HasPrefix(csp, "default-src 'self';") || !strings.HasSuffix(csp, "frame-ancestors 'self'") {
+		t.Fatalf("Content-Security-Policy = %q", csp)
+	}
+}
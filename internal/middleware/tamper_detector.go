@@ -4,7 +4,8 @@ import (
 	"net/http"
 	"strings"
 
-	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
+	"github.com/peithosecure/peitho-backend/internal/events"
 )
 
 // TamperDetectorMiddleware blocks requests with shady User-Agent or branding violations
@@ -18,8 +19,13 @@ func TamperDetectorMiddleware(next http.Handler) http.Handler {
 			strings.Contains(uaLower, "fiddler") ||
 			strings.Contains(uaLower, "httpclient") {
 
-			corestub.TrackEvent("tamper_detected")
-			corestub.RespondWithTraceError(w, "tamper_detected", http.StatusForbidden)
+			events.Publish(events.Event{
+				Type:      events.TamperDetected,
+				IP:        ClientIP(r),
+				UserAgent: ua,
+				Reason:    "suspicious user agent",
+			})
+			problem.Respond(w, r, "tamper_detected", http.StatusForbidden)
 			return
 		}
 
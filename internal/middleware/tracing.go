@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for each request, continuing the caller's trace when it sends
+// a traceparent header. The span is named after the route template, so like HTTPMetrics it
+// belongs on Router.Use; wrap the NotFound and MethodNotAllowed handlers too. Handlers pass
+// r.Context() on to Keycloak and SQLite, whose calls become child spans.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		name := r.Method
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("client.address", ClientIP(r)),
+			attribute.String("user_agent.original", r.UserAgent()),
+		}
+		if route != unmatchedRoute {
+			name += " " + route
+			attrs = append(attrs, attribute.String("http.route", route))
+		}
+		if id := RequestIDFromContext(r.Context()); id != "" {
+			attrs = append(attrs, attribute.String("peitho.request_id", id))
+		}
+
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		rec := NewResponseRecorder(w)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/peithosecure/peitho-backend/internal/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	const remoteTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const remoteSpan = "00f067aa0ba902b7"
+
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantRoute   string
+		wantStatus  int
+		wantError   bool
+	}{
+		{name: "named after route template", path: "/api/v1/users/alice", wantName: "GET /api/v1/users/{name}", wantRoute: "/api/v1/users/{name}", wantStatus: http.StatusOK},
+		{name: "continues caller's trace", path: "/api/v1/users/bob", traceparent: "00-" + remoteTrace + "-" + remoteSpan + "-01", wantName: "GET /api/v1/users/{name}", wantRoute: "/api/v1/users/{name}", wantStatus: http.StatusOK},
+		{name: "server error fails the span", path: "/api/v1/boom", wantName: "GET /api/v1/boom", wantRoute: "/api/v1/boom", wantStatus: http.StatusInternalServerError, wantError: true},
+		{name: "unmatched route keeps raw path out of the name", path: "/nope/123", wantName: "GET", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracingtest.RecordSpans(t)
+
+			var handlerSpan trace.SpanContext
+			r := mux.NewRouter()
+			r.Use(Tracing)
+			r.NotFoundHandler = Tracing(http.NotFoundHandler())
+			r.HandleFunc("/api/v1/users/{name}", func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = trace.SpanContextFromContext(r.Context())
+			})
+			r.HandleFunc("/api/v1/boom", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name != tt.wantName || span.SpanKind != trace.SpanKindServer {
+				t.Fatalf("span = %q (%v), want %q (server)", span.Name, span.SpanKind, tt.wantName)
+			}
+			if route, ok := tracingtest.Attr(span, "http.route"); route.AsString() != tt.wantRoute || ok != (tt.wantRoute != "") {
+				t.Fatalf("http.route = %q (set %v), want %q", route.AsString(), ok, tt.wantRoute)
+			}
+			if status, _ := tracingtest.Attr(span, "http.response.status_code"); status.AsInt64() != int64(tt.wantStatus) {
+				t.Fatalf("http.response.status_code = %d, want %d", status.AsInt64(), tt.wantStatus)
+			}
+			if (span.Status.Code == codes.Error) != tt.wantError {
+				t.Fatalf("span status = %v, want error %v", span.Status.Code, tt.wantError)
+			}
+			if tt.traceparent != "" {
+				if span.SpanContext.TraceID().String() != remoteTrace || span.Parent.SpanID().String() != remoteSpan || !span.Parent.IsRemote() {
+					t.Fatalf("span %s/%s does not continue the remote parent", span.SpanContext.TraceID(), span.Parent.SpanID())
+				}
+			}
+			if handlerSpan.IsValid() && handlerSpan.SpanID() != span.SpanContext.SpanID() {
+				t.Fatal("handler context does not carry the server span")
+			}
+		})
+	}
+}
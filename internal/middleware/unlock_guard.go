@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/peithosecure/peitho-backend/internal/api/problem"
 	corestub "github.com/peithosecure/peitho-backend/internal/corestub"
 )
 
@@ -12,7 +13,7 @@ func UnlockGuardMiddleware(next http.Handler) http.Handler {
 		unlocked, _ := corestub.UnlockStatus()
 		if !unlocked {
 			// Stubbed event tracking and roast trigger
-			corestub.RespondWithTraceError(w, "pqc_lock_enforced", http.StatusForbidden)
+			problem.Respond(w, r, "pqc_lock_enforced", http.StatusForbidden)
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -0,0 +1,76 @@
+package passwordpolicy
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters for new history hashes (the OWASP baseline). They are encoded in
+// each hash, so raising them later keeps older entries verifiable.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024 // KiB
+	argonThreads = 1
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// Bounds on parameters read back from stored hashes. argon2 panics on zero time or threads,
+// and the memory figure is allocated as given, so a mistyped hash must not reach it.
+const (
+	maxArgonMemory = 256 * 1024 // KiB
+	maxArgonTime   = 64
+)
+
+var errMalformedHash = errors.New("malformed argon2id hash")
+
+// Hash returns a salted argon2id hash of password in PHC string format:
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func Hash(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyHash reports whether password matches a hash produced by Hash
+func VerifyHash(encoded, password string) (bool, error) {
+	var (
+		version, memory, iterations int
+		threads                     uint8
+	)
+	// $argon2id$v=19$m=..,t=..,p=..$salt$key splits into 6 parts with an empty first one
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, errMalformedHash
+	}
+	if iterations < 1 || iterations > maxArgonTime || threads < 1 || memory < 8*int(threads) || memory > maxArgonMemory {
+		return false, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errMalformedHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, errMalformedHash
+	}
+
+	got := argon2.IDKey([]byte(password), salt, uint32(iterations), uint32(memory), threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
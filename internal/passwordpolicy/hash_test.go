@@ -0,0 +1,51 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifyHash(t *testing.T) {
+	good, err := Hash("Quartz-Lantern-Orbit-58")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	parts := strings.Split(good, "$")
+	// withParams swaps the m=,t=,p= section of good
+	withParams := func(params string) string {
+		return strings.Join([]string{"", parts[1], parts[2], params, parts[4], parts[5]}, "$")
+	}
+
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		want     bool
+		wantErr  error
+	}{
+		{name: "match", encoded: good, password: "Quartz-Lantern-Orbit-58", want: true},
+		{name: "mismatch", encoded: good, password: "quartz-lantern-orbit-58"},
+		{name: "zero iterations", encoded: withParams("m=19456,t=0,p=1"), wantErr: errMalformedHash},
+		{name: "zero threads", encoded: withParams("m=19456,t=2,p=0"), wantErr: errMalformedHash},
+		{name: "negative memory", encoded: withParams("m=-1,t=2,p=1"), wantErr: errMalformedHash},
+		{name: "memory below 8 per thread", encoded: withParams("m=15,t=2,p=2"), wantErr: errMalformedHash},
+		{name: "memory above cap", encoded: withParams("m=4194304,t=2,p=1"), wantErr: errMalformedHash},
+		{name: "iterations above cap", encoded: withParams("m=19456,t=1000000,p=1"), wantErr: errMalformedHash},
+		{name: "threads overflow", encoded: withParams("m=19456,t=2,p=300"), wantErr: errMalformedHash},
+		{name: "missing params", encoded: withParams("m=19456"), wantErr: errMalformedHash},
+		{name: "wrong algorithm", encoded: strings.Replace(good, "argon2id", "argon2i", 1), wantErr: errMalformedHash},
+		{name: "wrong version", encoded: strings.Replace(good, "v=19", "v=16", 1), wantErr: errMalformedHash},
+		{name: "bad salt", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], "!!", parts[5]}, "$"), wantErr: errMalformedHash},
+		{name: "empty key", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], ""}, "$"), wantErr: errMalformedHash},
+		{name: "not a hash", encoded: "hunter2", wantErr: errMalformedHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyHash(tt.encoded, tt.password)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("VerifyHash() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
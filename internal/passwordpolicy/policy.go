@@ -0,0 +1,319 @@
+// Package passwordpolicy decides whether a candidate password is acceptable. Evaluation needs
+// no database, network or identity provider — at most a local breach corpus file — so a
+// policy can be checked offline.
+package passwordpolicy
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/peithosecure/peitho-backend/internal/breach"
+	"github.com/peithosecure/peitho-backend/internal/config"
+)
+
+// Rule identifiers reported in violations. They are part of the API contract.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleRequireUpper     = "require_upper"
+	RuleRequireLower     = "require_lower"
+	RuleRequireDigit     = "require_digit"
+	RuleRequireSymbol    = "require_symbol"
+	RuleMaxRepeat        = "max_repeat"
+	RuleContainsIdentity = "contains_identity"
+	RuleMinStrength      = "min_strength"
+	RuleBreached         = "breached"
+	RuleReused           = "reused"
+)
+
+// MaxStrength is the highest score Estimate returns
+const MaxStrength = 4
+
+// MaxHistory bounds PEITHO_PASSWORD_HISTORY; every entry costs an argon2id verification
+const MaxHistory = 24
+
+// Policy lists the rules a password must satisfy. Zero values disable the numeric rules.
+type Policy struct {
+	MinLength     int   `json:"min_length" example:"12"`
+	MaxLength     int   `json:"max_length" example:"128"`
+	RequireUpper  bool  `json:"require_upper" example:"true"`
+	RequireLower  bool  `json:"require_lower" example:"true"`
+	RequireDigit  bool  `json:"require_digit" example:"true"`
+	RequireSymbol bool  `json:"require_symbol" example:"false"`
+	MaxRepeat     int   `json:"max_repeat" example:"3"`            // Longest allowed run of one character
+	MinStrength   int   `json:"min_strength" example:"3"`          // Minimum estimated strength, 0-4
+	BreachCheck   bool  `json:"breach_check" example:"true"`       // Whether passwords are checked against a breach corpus
+	History       int   `json:"history" example:"5"`               // Number of previous passwords that may not be reused
+	MaxAgeSeconds int64 `json:"max_age_seconds" example:"7776000"` // Password lifetime before login reports it expired; 0 never expires
+
+	corpus Corpus
+}
+
+// Corpus reports whether a password is known from a data breach
+type Corpus interface {
+	Contains(password string) (bool, error)
+}
+
+// WithCorpus returns a copy of p that also rejects passwords found in c
+func (p Policy) WithCorpus(c Corpus) Policy {
+	p.corpus, p.BreachCheck = c, c != nil
+	return p
+}
+
+// Violation is one failed rule
+type Violation struct {
+	Rule    string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"must be at least 12 characters"`
+}
+
+// Result is the outcome of checking one password
+type Result struct {
+	OK         bool        `json:"ok" example:"false"`
+	Strength   Strength    `json:"strength"`
+	Violations []Violation `json:"violations"`
+}
+
+// DefaultPolicy is used until Init runs
+var DefaultPolicy = Policy{
+	MinLength:    12,
+	MaxLength:    128,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	MaxRepeat:    3,
+	MinStrength:  3,
+	History:      5,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultPolicy
+)
+
+// FromConfig builds a policy from cfg and rejects contradictory settings
+func FromConfig(cfg *config.Config) (Policy, error) {
+	p := Policy{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     cfg.PasswordMaxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		MaxRepeat:     cfg.PasswordMaxRepeat,
+		MinStrength:   cfg.PasswordMinStrength,
+		History:       cfg.PasswordHistory,
+		MaxAgeSeconds: int64(cfg.PasswordMaxAge / time.Second),
+	}
+	if p.MinLength < 0 || p.MaxLength < 0 || p.MaxRepeat < 0 {
+		return p, fmt.Errorf("password length and repeat limits must not be negative")
+	}
+	if p.MaxLength > 0 && p.MinLength > p.MaxLength {
+		return p, fmt.Errorf("PEITHO_PASSWORD_MIN_LENGTH (%d) exceeds PEITHO_PASSWORD_MAX_LENGTH (%d)", p.MinLength, p.MaxLength)
+	}
+	if p.History < 0 || p.History > MaxHistory {
+		return p, fmt.Errorf("PEITHO_PASSWORD_HISTORY must be between 0 and %d, got %d", MaxHistory, p.History)
+	}
+	if p.MaxAgeSeconds < 0 {
+		return p, fmt.Errorf("PEITHO_PASSWORD_MAX_AGE must not be negative")
+	}
+	if p.MinStrength < 0 || p.MinStrength > MaxStrength {
+		return p, fmt.Errorf("PEITHO_PASSWORD_MIN_STRENGTH must be between 0 and %d, got %d", MaxStrength, p.MinStrength)
+	}
+	return p, nil
+}
+
+// Load builds the policy from cfg and opens the breach corpus when PEITHO_BREACH_INDEX is set
+func Load(cfg *config.Config) (Policy, error) {
+	p, err := FromConfig(cfg)
+	if err != nil || cfg.BreachIndexPath == "" {
+		return p, err
+	}
+	ix, err := breach.Open(cfg.BreachIndexPath)
+	if err != nil {
+		return p, err
+	}
+	return p.WithCorpus(ix), nil
+}
+
+// Init installs the policy configured in cfg
+func Init(cfg *config.Config) error {
+	p, err := Load(cfg)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	current = p
+	mu.Unlock()
+	return nil
+}
+
+// Current returns the active policy
+func Current() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// CheckHistory adds a violation to res when password matches one of the previous
+// password hashes, which are ordered newest first
+func (p Policy) CheckHistory(res *Result, password string, hashes []string) error {
+	if p.History == 0 {
+		return nil
+	}
+	for _, h := range hashes[:min(len(hashes), p.History)] {
+		match, err := VerifyHash(h, password)
+		if err != nil {
+			return err
+		}
+		if match {
+			res.Violations = append(res.Violations, Violation{
+				Rule:    RuleReused,
+				Message: fmt.Sprintf("must not match any of your last %d passwords", p.History),
+			})
+			res.OK = false
+			return nil
+		}
+	}
+	return nil
+}
+
+// Expired reports whether a password last changed at changedAt has outlived MaxAgeSeconds.
+// Accounts that never set a password here are not expired.
+func (p Policy) Expired(changedAt *time.Time, now time.Time) bool {
+	if p.MaxAgeSeconds == 0 || changedAt == nil {
+		return false
+	}
+	return now.Sub(*changedAt) > time.Duration(p.MaxAgeSeconds)*time.Second
+}
+
+// Breached reports whether res failed because the password is in the breach corpus
+func (res Result) Breached() bool { return res.Failed(RuleBreached) }
+
+// Failed reports whether res includes a violation of rule
+func (res Result) Failed(rule string) bool {
+	for _, v := range res.Violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Check evaluates password against the active policy. identities are the account's
+// username, email and similar values the password must not contain.
+func Check(password string, identities ...string) Result {
+	return Current().Evaluate(password, identities...)
+}
+
+// Evaluate checks password against p and reports every rule it fails
+func (p Policy) Evaluate(password string, identities ...string) Result {
+	res := Result{Strength: Estimate(password, identities...), Violations: []Violation{}}
+	fail := func(rule, format string, args ...any) {
+		res.Violations = append(res.Violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		fail(RuleMinLength, "must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail(RuleMaxLength, "must be at most %d characters", p.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		fail(RuleRequireUpper, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		fail(RuleRequireLower, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		fail(RuleRequireDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		fail(RuleRequireSymbol, "must contain a symbol")
+	}
+
+	if p.MaxRepeat > 0 && longestRepeat(password) > p.MaxRepeat {
+		fail(RuleMaxRepeat, "must not repeat a character more than %d times in a row", p.MaxRepeat)
+	}
+
+	lowered := strings.ToLower(password)
+	normalized := leet.Replace(lowered)
+	for _, id := range identityTokens(identities) {
+		if strings.Contains(lowered, id) || strings.Contains(normalized, leet.Replace(id)) {
+			fail(RuleContainsIdentity, "must not contain your username or email address")
+			break
+		}
+	}
+
+	if p.corpus != nil {
+		// A corpus that cannot be read must not lock everyone out of setting a password
+		if found, err := p.corpus.Contains(password); err != nil {
+			slog.Warn("⚠️ Breach corpus lookup failed; skipping breach check", "error", err)
+		} else if found {
+			fail(RuleBreached, "appears in a known data breach")
+		}
+	}
+
+	if res.Strength.Score < p.MinStrength {
+		msg := fmt.Sprintf("is too easy to guess (strength %d of %d, %d required)", res.Strength.Score, MaxStrength, p.MinStrength)
+		if res.Strength.Warning != "" {
+			msg += ": " + res.Strength.Warning
+		}
+		fail(RuleMinStrength, "%s", msg)
+	}
+
+	res.OK = len(res.Violations) == 0
+	return res
+}
+
+func longestRepeat(s string) int {
+	longest, run := 0, 0
+	var prev rune = -1
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
+
+// identityTokens lowercases identities and adds the local part of email addresses.
+// Tokens shorter than three characters are ignored to avoid false positives.
+func identityTokens(identities []string) []string {
+	var out []string
+	add := func(s string) {
+		if s = strings.ToLower(strings.TrimSpace(s)); utf8.RuneCountInString(s) >= 3 {
+			out = append(out, s)
+		}
+	}
+	for _, id := range identities {
+		add(id)
+		if local, _, ok := strings.Cut(id, "@"); ok {
+			add(local)
+		}
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// strongPassword passes DefaultPolicy
+const strongPassword = "Quartz-Lantern-Orbit-58"
+
+type fakeCorpus struct {
+	breached []string
+	err      error
+}
+
+func (c fakeCorpus) Contains(password string) (bool, error) {
+	return slices.Contains(c.breached, password), c.err
+}
+
+func rules(res Result) []string {
+	var out []string
+	for _, v := range res.Violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     Policy
+		password   string
+		identities []string
+		want       []string
+	}{
+		{name: "default policy accepts strong password", policy: DefaultPolicy, password: strongPassword},
+		{name: "too short", policy: Policy{MinLength: 12}, password: "Ab1-short", want: []string{RuleMinLength}},
+		{name: "length counts runes, not bytes", policy: Policy{MinLength: 4, MaxLength: 4}, password: "ñøåé"},
+		{name: "too long", policy: Policy{MaxLength: 8}, password: "123456789", want: []string{RuleMaxLength}},
+		{name: "zero limits are disabled", policy: Policy{}, password: ""},
+		{name: "missing upper", policy: Policy{RequireUpper: true}, password: "lower-only-1", want: []string{RuleRequireUpper}},
+		{name: "missing lower", policy: Policy{RequireLower: true}, password: "UPPER-ONLY-1", want: []string{RuleRequireLower}},
+		{name: "missing digit", policy: Policy{RequireDigit: true}, password: "NoDigits-Here", want: []string{RuleRequireDigit}},
+		{name: "missing symbol", policy: Policy{RequireSymbol: true}, password: "NoSymbols123", want: []string{RuleRequireSymbol}},
+		{name: "every class present", policy: Policy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}, password: "Aa1!"},
+		{name: "repeat at limit", policy: Policy{MaxRepeat: 3}, password: "baaab"},
+		{name: "repeat over limit", policy: Policy{MaxRepeat: 3}, password: "baaaab", want: []string{RuleMaxRepeat}},
+		{name: "contains username", policy: Policy{}, password: "xx-Alice-2024", identities: []string{"alice"}, want: []string{RuleContainsIdentity}},
+		{name: "contains email local part", policy: Policy{}, password: "bob.smith!99", identities: []string{"bob.smith@example.com"}, want: []string{RuleContainsIdentity}},
+		{name: "contains leet username", policy: Policy{}, password: "4l1c3-rules", identities: []string{"alice"}, want: []string{RuleContainsIdentity}},
+		{name: "short identities ignored", policy: Policy{}, password: "jo-was-here", identities: []string{"jo"}},
+		{name: "too weak", policy: Policy{MinStrength: 3}, password: "password1", want: []string{RuleMinStrength}},
+		{name: "several rules at once", policy: DefaultPolicy, password: "aaaa", want: []string{RuleMinLength, RuleRequireUpper, RuleRequireDigit, RuleMaxRepeat, RuleMinStrength}},
+		{name: "breached", policy: Policy{}.WithCorpus(fakeCorpus{breached: []string{"Hunter2-Hunter2"}}), password: "Hunter2-Hunter2", want: []string{RuleBreached}},
+		{name: "not breached", policy: Policy{}.WithCorpus(fakeCorpus{breached: []string{"Hunter2-Hunter2"}}), password: strongPassword},
+		{name: "unreadable corpus does not block", policy: Policy{}.WithCorpus(fakeCorpus{err: errors.New("disk gone")}), password: strongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.policy.Evaluate(tt.password, tt.identities...)
+			if got := rules(res); !slices.Equal(got, tt.want) {
+				t.Fatalf("violations = %v, want %v", got, tt.want)
+			}
+			if res.OK != (len(tt.want) == 0) {
+				t.Fatalf("OK = %v with violations %v", res.OK, rules(res))
+			}
+			if res.Violations == nil {
+				t.Fatal("Violations is nil; the API promises an empty list")
+			}
+		})
+	}
+}
+
+func TestResultBreached(t *testing.T) {
+	res := Policy{}.WithCorpus(fakeCorpus{breached: []string{"x"}}).Evaluate("x")
+	if !res.Breached() || !res.Failed(RuleBreached) || res.Failed(RuleMinLength) {
+		t.Fatalf("Breached/Failed disagree with violations %v", rules(res))
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	var hashes []string // newest first
+	for _, pw := range []string{"Newest-Pass-1", "Middle-Pass-2", "Oldest-Pass-3"} {
+		h, err := Hash(pw)
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		hashes = append(hashes, h)
+	}
+
+	tests := []struct {
+		name     string
+		history  int
+		password string
+		hashes   []string
+		reused   bool
+		wantErr  bool
+	}{
+		{name: "matches newest", history: 3, password: "Newest-Pass-1", hashes: hashes, reused: true},
+		{name: "matches oldest in window", history: 3, password: "Oldest-Pass-3", hashes: hashes, reused: true},
+		{name: "older than window", history: 2, password: "Oldest-Pass-3", hashes: hashes},
+		{name: "window larger than history", history: 10, password: "Middle-Pass-2", hashes: hashes, reused: true},
+		{name: "new password", history: 3, password: strongPassword, hashes: hashes},
+		{name: "history disabled", history: 0, password: "Newest-Pass-1", hashes: hashes},
+		{name: "no previous passwords", history: 3, password: "Newest-Pass-1"},
+		{name: "malformed hash", history: 3, password: "Newest-Pass-1", hashes: []string{"$argon2id$bogus"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Result{OK: true, Violations: []Violation{}}
+			err := Policy{History: tt.history}.CheckHistory(&res, tt.password, tt.hashes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := res.Failed(RuleReused); got != tt.reused {
+				t.Fatalf("reused = %v, want %v", got, tt.reused)
+			}
+			if res.OK == tt.reused {
+				t.Fatalf("OK = %v, want %v", res.OK, !tt.reused)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	changed := func(ago time.Duration) *time.Time { ts := now.Add(-ago); return &ts }
+	tests := []struct {
+		name    string
+		maxAge  time.Duration
+		changed *time.Time
+		want    bool
+	}{
+		{"never expires", 0, changed(10 * 365 * 24 * time.Hour), false},
+		{"never set here", time.Hour, nil, false},
+		{"within lifetime", 90 * 24 * time.Hour, changed(89 * 24 * time.Hour), false},
+		{"past lifetime", 90 * 24 * time.Hour, changed(91 * 24 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		p := Policy{MaxAgeSeconds: int64(tt.maxAge / time.Second)}
+		if got := p.Expired(tt.changed, now); got != tt.want {
+			t.Errorf("%s: Expired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package passwordpolicy
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength is a zxcvbn-style estimate of how many guesses an attacker needs
+type Strength struct {
+	Score        int     `json:"score" example:"3"`            // 0 (trivial) to 4 (very strong)
+	GuessesLog10 float64 `json:"guesses_log10" example:"9.42"` // log10 of the estimated guess count
+	Warning      string  `json:"warning,omitempty" example:"contains a keyboard pattern"`
+}
+
+// Score thresholds on log10(guesses), as used by zxcvbn
+var scoreThresholds = [MaxStrength]float64{3, 6, 8, 10}
+
+// bruteForceCost is log10 of the guesses charged per character that matches no pattern.
+// Like zxcvbn it assumes ten candidates per character rather than the full alphabet, since
+// attackers try likely characters first.
+const bruteForceCost = 1.0
+
+// keyboardRows are scanned for adjacent-key walks such as "qwerty" or "asdf"
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leet maps common substitutions back to letters before dictionary lookups
+var leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+// commonPasswords holds frequently breached passwords and words they are built from
+var commonPasswords = map[string]struct{}{}
+
+func init() {
+	for _, p := range strings.Fields(`
+		123456 1234567 12345678 123456789 1234567890 111111 000000 123123 654321 666666
+		password passw0rd passwort motdepasse contrasena qwerty qwertyuiop azerty asdfgh
+		abc123 letmein welcome welcome1 admin administrator root toor login guest
+		iloveyou monkey dragon football baseball basketball soccer hockey sunshine
+		princess trustno1 master shadow superman batman michael jennifer jordan
+		hunter killer freedom whatever starwars pokemon charlie donald mustang
+		secret changeme default access flower summer winter spring autumn
+		hello hello123 lovely loveme ninja mypass pass1234 zaq12wsx 1q2w3e4r
+		peitho peithosecure secure security keycloak`) {
+		commonPasswords[p] = struct{}{}
+	}
+}
+
+// Estimate scores password the way zxcvbn does, in spirit: it finds the cheapest way to
+// split the password into parts, charging far less for repeats, sequences, keyboard walks,
+// common passwords and fragments of the account's own identity than for unpredictable
+// characters, and sums the guessing cost of those parts.
+func Estimate(password string, identities ...string) Strength {
+	if password == "" {
+		return Strength{Warning: "is empty"}
+	}
+
+	lower := strings.ToLower(password)
+	if log10, ok := commonGuesses(lower); ok {
+		return newStrength(log10, "is a common password or a slight variation of one")
+	}
+
+	runes := []rune(lower)
+	spans := fragmentSpans(runes, identityTokens(identities))
+
+	// best[i] is the cheapest cost of runes[i:]; next[i] the part chosen at i
+	best := make([]float64, len(runes)+1)
+	next := make([]span, len(runes))
+	for i := len(runes) - 1; i >= 0; i-- {
+		best[i], next[i] = bruteForceCost+best[i+1], span{length: 1, cost: bruteForceCost}
+		consider := func(s span) {
+			if c := s.cost + best[i+s.length]; c < best[i] {
+				best[i], next[i] = c, s
+			}
+		}
+		if n, kind := patternRun(runes, i); n >= 3 {
+			consider(span{length: n, cost: math.Log10(float64(classSize(runes[i])) * float64(n)), warning: kind})
+		}
+		for _, s := range spans[i] {
+			consider(s)
+		}
+	}
+
+	var warning string
+	for i := 0; i < len(runes); i += next[i].length {
+		if next[i].warning != "" && warning == "" {
+			warning = next[i].warning
+		}
+	}
+	return newStrength(best[0], warning)
+}
+
+func newStrength(log10 float64, warning string) Strength {
+	s := Strength{GuessesLog10: math.Round(log10*100) / 100}
+	for s.Score < MaxStrength && log10 >= scoreThresholds[s.Score] {
+		s.Score++
+	}
+	if s.Score == MaxStrength {
+		warning = ""
+	}
+	s.Warning = warning
+	return s
+}
+
+// commonGuesses reports whether password is a common password, optionally with l33t
+// substitutions and a short digit/symbol suffix such as a year ("P@ssw0rd2024!")
+func commonGuesses(lower string) (float64, bool) {
+	if _, ok := commonPasswords[lower]; ok {
+		return 1, true
+	}
+	if _, ok := commonPasswords[leet.Replace(lower)]; ok {
+		return 2, true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	suffix := len(lower) - len(base)
+	if suffix == 0 || suffix > 6 || len(base) < 4 {
+		return 0, false
+	}
+	if _, ok := commonPasswords[leet.Replace(base)]; ok {
+		return 2 + float64(suffix), true
+	}
+	return 0, false
+}
+
+type span struct {
+	length  int
+	cost    float64
+	warning string
+}
+
+// fragmentSpans lists, per starting position, the identity tokens and common words found
+// in runes, with or without l33t substitutions. Identity fragments are nearly free to guess
+// for a targeted attacker; common words only cheap.
+func fragmentSpans(runes []rune, identities []string) map[int][]span {
+	spans := map[int][]span{}
+	plain := string(runes)
+	// leet maps one character to one character, so rune positions line up
+	texts := []string{plain, leet.Replace(plain)}
+	mark := func(word string, s span) {
+		for _, text := range texts {
+			for off := 0; ; {
+				idx := strings.Index(text[off:], word)
+				if idx < 0 {
+					break
+				}
+				pos := len([]rune(text[:off+idx]))
+				spans[pos] = append(spans[pos], s)
+				off += idx + len(word)
+			}
+		}
+	}
+	for word := range commonPasswords {
+		if len(word) >= 5 {
+			mark(word, span{length: len([]rune(word)), cost: 2, warning: "contains a common password"})
+		}
+	}
+	for _, id := range identities {
+		mark(id, span{length: len([]rune(id)), cost: 1, warning: "contains your username or email address"})
+		mark(leet.Replace(id), span{length: len([]rune(id)), cost: 1, warning: "contains your username or email address"})
+	}
+	return spans
+}
+
+// patternRun returns the length of the longest repeat, sequence or keyboard walk starting at i
+func patternRun(runes []rune, i int) (int, string) {
+	best, kind := 1, ""
+	try := func(n int, k string) {
+		if n > best {
+			best, kind = n, k
+		}
+	}
+
+	n := 1
+	for i+n < len(runes) && runes[i+n] == runes[i] {
+		n++
+	}
+	try(n, "contains repeated characters")
+
+	if i+1 < len(runes) {
+		if d := runes[i+1] - runes[i]; d == 1 || d == -1 {
+			n = 2
+			for i+n < len(runes) && runes[i+n]-runes[i+n-1] == d {
+				n++
+			}
+			try(n, "contains a sequence such as abc or 123")
+		}
+	}
+
+	for _, row := range keyboardRows {
+		if strings.IndexRune(row, runes[i]) < 0 {
+			continue
+		}
+		n = 1
+		for i+n < len(runes) && adjacent(row, runes[i+n-1], runes[i+n]) {
+			n++
+		}
+		try(n, "contains a keyboard pattern")
+	}
+	return best, kind
+}
+
+func adjacent(row string, a, b rune) bool {
+	ia, ib := strings.IndexRune(row, a), strings.IndexRune(row, b)
+	return ia >= 0 && ib >= 0 && (ia-ib == 1 || ib-ia == 1)
+}
+
+func classSize(r rune) int {
+	switch {
+	case r > unicode.MaxASCII:
+		return 100
+	case unicode.IsLetter(r):
+		return 26
+	case unicode.IsDigit(r):
+		return 10
+	default:
+		return 33
+	}
+}
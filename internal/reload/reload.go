@@ -0,0 +1,168 @@
+// Package reload applies configuration changes to a running server. On SIGHUP, or when the
+// config file or a mounted secret file changes, the configuration is loaded and validated
+// again. Reloadable settings are handed to the registered components; other changes are
+// reported as needing a restart. Environment variables cannot change under a running
+// process, so in practice a reload picks up edits to files.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/scheduler"
+)
+
+// Reload triggers
+const (
+	TriggerSignal = "signal"
+	TriggerFile   = "file"
+)
+
+// Reload results, as counted in peitho_config_reloads_total
+const (
+	ResultApplied   = "applied"
+	ResultUnchanged = "unchanged"
+	ResultInvalid   = "invalid"
+)
+
+// Hook checks a reloaded configuration for one component and returns the function that
+// applies it. It must not change any running state itself: Reload calls apply only after
+// every registered hook has accepted the configuration, so a rejection leaves all
+// components as they were.
+type Hook func(cfg *config.Config) (apply func(), err error)
+
+type hook struct {
+	name    string
+	prepare Hook
+}
+
+var (
+	mu      sync.Mutex
+	path    string
+	current *config.Config
+	stamp   string
+	hooks   []hook
+
+	// reloading serialises Reload. Hooks run under it rather than mu, so they may call
+	// Current.
+	reloading sync.Mutex
+)
+
+// Register adds a component that consumes reloadable settings. It is not called for the
+// configuration given to Start.
+func Register(name string, prepare Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, hook{name: name, prepare: prepare})
+}
+
+// Current returns the running configuration: the one passed to Start with every accepted
+// reload merged in. Before Start it returns nil.
+func Current() *config.Config {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Start records cfg, loaded from file, as the running configuration and reloads on SIGHUP.
+// The config and secret files are also polled every PEITHO_CONFIG_WATCH_INTERVAL on the
+// scheduler, so Start must run before scheduler.Start.
+func Start(cfg *config.Config, file string) {
+	mu.Lock()
+	path, current, stamp = file, cfg, config.Fingerprint(cfg.Files()...)
+	mu.Unlock()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			_ = Reload(TriggerSignal)
+		}
+	}()
+
+	if len(cfg.Files()) > 0 {
+		scheduler.Every("config-watch", cfg.ConfigWatch, watch)
+	}
+}
+
+// watch reloads when a loaded file's size or modification time changed. Reload logs its
+// own failures, so they are not reported to the scheduler again.
+func watch(ctx context.Context) error {
+	mu.Lock()
+	changed := config.Fingerprint(current.Files()...) != stamp
+	mu.Unlock()
+	if changed {
+		_ = Reload(TriggerFile)
+	}
+	return nil
+}
+
+// Reload loads the configuration again and, if it is valid and every registered component
+// accepts it, applies the reloadable settings. Otherwise the running configuration stays in
+// place untouched.
+func Reload(trigger string) error {
+	reloading.Lock()
+	defer reloading.Unlock()
+
+	mu.Lock()
+	file, running, registered := path, current, append([]hook(nil), hooks...)
+	// Remember what was seen even if it is rejected, so the watcher does not retry every tick
+	stamp = config.Fingerprint(running.Files()...)
+	mu.Unlock()
+
+	next, err := config.Load(file)
+	if err != nil {
+		return reject(trigger, err)
+	}
+	merged, applied, restart := running.Merge(next)
+
+	var pending []func()
+	if len(applied) > 0 {
+		for _, h := range registered {
+			apply, err := h.prepare(merged)
+			if err != nil {
+				return reject(trigger, fmt.Errorf("%s: %w", h.name, err))
+			}
+			if apply != nil {
+				pending = append(pending, apply)
+			}
+		}
+	}
+
+	mu.Lock()
+	current, stamp = merged, config.Fingerprint(merged.Files()...)
+	mu.Unlock()
+	for _, apply := range pending {
+		apply()
+	}
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+
+	if len(restart) > 0 {
+		metrics.ConfigRestartRequired.Set(1)
+		slog.Warn("♻️ Config changes need a restart to take effect", "trigger", trigger, "settings", restart)
+	} else {
+		metrics.ConfigRestartRequired.Set(0)
+	}
+
+	if len(applied) == 0 {
+		metrics.ConfigReloads.WithLabelValues(trigger, ResultUnchanged).Inc()
+		slog.Info("♻️ Config reloaded; no reloadable settings changed", "trigger", trigger)
+		return nil
+	}
+	metrics.ConfigReloads.WithLabelValues(trigger, ResultApplied).Inc()
+	slog.Info("♻️ Config reloaded", "trigger", trigger, "applied", applied)
+	return nil
+}
+
+func reject(trigger string, err error) error {
+	metrics.ConfigReloads.WithLabelValues(trigger, ResultInvalid).Inc()
+	slog.Error("♻️ Config reload rejected; keeping the running configuration", "trigger", trigger, "error", err)
+	return err
+}
@@ -0,0 +1,125 @@
+package reload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+)
+
+// setup loads a config file with the given log level as the running configuration and
+// clears the registered hooks
+func setup(t *testing.T, level string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "peitho.yaml")
+	writeLevel(t, file, level)
+	cfg, err := config.Load(file)
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+
+	mu.Lock()
+	prevPath, prevCurrent, prevStamp, prevHooks := path, current, stamp, hooks
+	path, current, stamp, hooks = file, cfg, config.Fingerprint(cfg.Files()...), nil
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		path, current, stamp, hooks = prevPath, prevCurrent, prevStamp, prevHooks
+		mu.Unlock()
+	})
+	return file
+}
+
+func writeLevel(t *testing.T, file, level string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte("log:\n  level: "+level+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadAppliesOnlyWhenEveryHookAccepts(t *testing.T) {
+	errRejected := errors.New("rejected")
+	tests := []struct {
+		name        string
+		rejectLevel string // the second hook rejects configurations with this level
+		wantErr     bool
+		wantLevel   string
+		wantApplied []string
+	}{
+		{name: "all accept", wantLevel: "warn", wantApplied: []string{"first", "second", "third"}},
+		{name: "one rejects", rejectLevel: "warn", wantErr: true, wantLevel: "info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := setup(t, "info")
+			var applied []string
+			for _, name := range []string{"first", "second", "third"} {
+				Register(name, func(cfg *config.Config) (func(), error) {
+					if name == "second" && cfg.LogLevel == tt.rejectLevel {
+						return nil, errRejected
+					}
+					return func() { applied = append(applied, name) }, nil
+				})
+			}
+
+			writeLevel(t, file, "warn")
+			err := Reload(TriggerSignal)
+			if tt.wantErr {
+				if !errors.Is(err, errRejected) || !strings.Contains(err.Error(), "second") {
+					t.Errorf("Reload err = %v, want the second hook's rejection", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Reload: %v", err)
+			}
+
+			if got := Current().LogLevel; got != tt.wantLevel {
+				t.Errorf("Current().LogLevel = %q, want %q", got, tt.wantLevel)
+			}
+			if strings.Join(applied, ",") != strings.Join(tt.wantApplied, ",") {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestReloadInvalidFileSkipsHooks(t *testing.T) {
+	file := setup(t, "info")
+	called := false
+	Register("any", func(*config.Config) (func(), error) {
+		called = true
+		return nil, nil
+	})
+
+	writeLevel(t, file, "loud")
+	if err := Reload(TriggerFile); err == nil {
+		t.Fatal("Reload accepted an invalid log level")
+	}
+	if called {
+		t.Error("hook was consulted for a configuration that failed validation")
+	}
+	if got := Current().LogLevel; got != "info" {
+		t.Errorf("Current().LogLevel = %q, want info", got)
+	}
+}
+
+func TestReloadHooksMayReadCurrent(t *testing.T) {
+	file := setup(t, "info")
+	var seen []string
+	Register("reader", func(cfg *config.Config) (func(), error) {
+		seen = append(seen, Current().LogLevel)
+		return func() { seen = append(seen, Current().LogLevel) }, nil
+	})
+
+	writeLevel(t, file, "error")
+	if err := Reload(TriggerSignal); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	// While checking, the old configuration is still running; once applying, the new one is
+	if strings.Join(seen, ",") != "info,error" {
+		t.Errorf("Current() seen by the hook = %v, want [info error]", seen)
+	}
+}
@@ -0,0 +1,165 @@
+// Package retention prunes old rows from growing tables and keeps the SQLite file compact.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/audit"
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/scheduler"
+)
+
+// Vacuum modes
+const (
+	VacuumIncremental = "incremental"
+	VacuumFull        = "full"
+	VacuumOff         = "off"
+)
+
+// Policy bounds one table by age and row count. Zero values disable a rule.
+type Policy struct {
+	Table      string
+	IDColumn   string
+	TimeColumn string
+	MaxAge     time.Duration
+	MaxRows    int
+}
+
+func (p Policy) enabled() bool { return p.MaxAge > 0 || p.MaxRows > 0 }
+
+var (
+	policies   []Policy
+	archiveDir string
+	vacuumMode = VacuumIncremental
+)
+
+// Schedule builds the policies from cfg and registers the retention and vacuum jobs
+func Schedule(cfg *config.Config) error {
+	policies = []Policy{
+		{"audit_events", "id", "created_at", cfg.AuditRetentionMaxAge, cfg.AuditRetentionMaxRows},
+		{"trace_events", "seq", "created_at", cfg.TraceRetentionMaxAge, cfg.TraceRetentionMaxRows},
+		{"email_tokens", "id", "created_at", cfg.TokenRetentionMaxAge, cfg.TokenRetentionMaxRows},
+		{"roast_logs", "id", "created_at", cfg.RoastRetentionMaxAge, cfg.RoastRetentionMaxRows},
+	}
+	archiveDir = cfg.RetentionArchiveDir
+
+	switch cfg.VacuumMode {
+	case "":
+	case VacuumIncremental, VacuumFull, VacuumOff:
+		vacuumMode = cfg.VacuumMode
+	default:
+		return fmt.Errorf("PEITHO_VACUUM_MODE must be incremental, full or off, got %q", cfg.VacuumMode)
+	}
+	if archiveDir != "" {
+		if err := os.MkdirAll(archiveDir, 0o700); err != nil {
+			return fmt.Errorf("retention archive dir: %w", err)
+		}
+	}
+
+	for _, p := range policies {
+		if p.enabled() {
+			log.Printf("🧹 Retention: %s keeps max age %s, max rows %d", p.Table, p.MaxAge, p.MaxRows)
+		}
+	}
+
+	scheduler.Every("retention", cfg.RetentionInterval, Run)
+	if vacuumMode != VacuumOff {
+		scheduler.Every("vacuum", cfg.VacuumInterval, Vacuum)
+	}
+	return nil
+}
+
+// Run applies every enabled policy once
+func Run(ctx context.Context) error {
+	var firstErr error
+	for _, p := range policies {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !p.enabled() {
+			continue
+		}
+		if err := apply(ctx, p); err != nil {
+			log.Printf("⚠️ Retention: %s: %v", p.Table, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func apply(ctx context.Context, p Policy) error {
+	var cutoff time.Time
+	if p.MaxAge > 0 {
+		cutoff = time.Now().UTC().Add(-p.MaxAge)
+	}
+	upTo, err := sqlite.PruneBoundary(ctx, p.Table, p.IDColumn, p.TimeColumn, cutoff, p.MaxRows)
+	if err != nil || upTo == 0 {
+		return err
+	}
+
+	if archiveDir != "" {
+		n, err := archive(ctx, p, upTo)
+		if err != nil {
+			return fmt.Errorf("archive failed, nothing pruned: %w", err)
+		}
+		metrics.RetentionRowsArchived.WithLabelValues(p.Table).Add(float64(n))
+	}
+
+	var pruned int64
+	if p.Table == "audit_events" {
+		pruned, err = audit.PruneThrough(ctx, upTo)
+	} else {
+		pruned, err = sqlite.DeleteRowsUpTo(ctx, p.Table, p.IDColumn, upTo)
+	}
+	metrics.RetentionRowsPruned.WithLabelValues(p.Table).Add(float64(pruned))
+	if pruned > 0 {
+		log.Printf("🧹 Retention: pruned %d row(s) from %s (through %s %d)", pruned, p.Table, p.IDColumn, upTo)
+	}
+	return err
+}
+
+// archive writes the rows about to be pruned to <dir>/<table>-<timestamp>.jsonl and syncs it to disk
+func archive(ctx context.Context, p Policy, upTo int64) (int, error) {
+	name := filepath.Join(archiveDir, fmt.Sprintf("%s-%s.jsonl", p.Table, time.Now().UTC().Format("20060102T150405Z")))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := sqlite.ArchiveRows(ctx, p.Table, p.IDColumn, upTo, f)
+	if err != nil {
+		return n, err
+	}
+	if err := f.Sync(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// Vacuum returns free pages to the filesystem. In incremental mode the first run
+// converts the file with one full VACUUM; later runs are cheap.
+func Vacuum(ctx context.Context) error {
+	if vacuumMode == VacuumFull {
+		return sqlite.Vacuum(ctx)
+	}
+
+	mode, err := sqlite.AutoVacuumMode(ctx)
+	if err != nil {
+		return err
+	}
+	if mode != 2 {
+		log.Println("🧹 Vacuum: switching database to incremental auto-vacuum (one-time full VACUUM)")
+		return sqlite.EnableIncrementalVacuum(ctx)
+	}
+	return sqlite.IncrementalVacuum(ctx, 0)
+}
@@ -0,0 +1,123 @@
+// Package scheduler runs periodic background jobs inside peitho-server.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/metrics"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+)
+
+// Job is one unit of periodic work. It should return promptly once ctx is cancelled.
+type Job func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler runs each registered job on its own ticker. A job never overlaps with itself.
+type Scheduler struct {
+	mu      sync.Mutex
+	entries []entry
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+var defaultScheduler = New()
+
+// Every registers a job on the process-wide scheduler
+func Every(name string, interval time.Duration, job Job) { defaultScheduler.Every(name, interval, job) }
+
+// Start launches the process-wide scheduler
+func Start() { defaultScheduler.Start() }
+
+// Stop halts the process-wide scheduler and waits for running jobs
+func Stop() { defaultScheduler.Stop() }
+
+// Every registers job to run once at start and then every interval.
+// A non-positive interval disables the job. Jobs must be registered before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		log.Printf("⏸️ Scheduler: job %s disabled (interval %s)", name, interval)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry{name: name, interval: interval, job: job})
+}
+
+// Start launches one goroutine per registered job
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(ctx, e)
+	}
+	log.Printf("⏱️ Scheduler started with %d job(s)", len(s.entries))
+}
+
+// Stop cancels every job and waits for in-flight runs to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		run(ctx, e)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes one run of e in its own trace, so the queries a job makes are grouped under it
+func run(ctx context.Context, e entry) {
+	ctx, span := tracing.Start(ctx, "job "+e.name)
+	outcome := "success"
+	var err error
+	defer func() {
+		if rec := recover(); rec != nil {
+			outcome = "panic"
+			err = fmt.Errorf("panic: %v", rec)
+			log.Printf("💥 Scheduler: job %s panicked: %v", e.name, rec)
+		}
+		tracing.End(span, err)
+		metrics.SchedulerJobRuns.WithLabelValues(e.name, outcome).Inc()
+	}()
+
+	if err = e.job(ctx); err != nil {
+		outcome = "error"
+		log.Printf("⚠️ Scheduler: job %s failed: %v", e.name, err)
+	}
+}
@@ -0,0 +1,288 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/utils"
+)
+
+// DefaultCapacity is the ring size used when none is configured
+const DefaultCapacity = 256
+
+// Severity levels accepted by the trace engine, lowest first
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var severities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+var (
+	ErrInvalidSeverity = errors.New("invalid severity")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+)
+
+// Event is a single trace engine record
+type Event struct {
+	Seq       int64
+	ID        string
+	Actor     string
+	Event     string
+	Severity  string
+	Lock      bool
+	Scope     string // lockdown scope requested by the event, if any
+	Target    string // user or IP the lockdown applies to
+	Message   string
+	Timestamp time.Time
+}
+
+// Filter narrows a trace query. Cursor is the opaque value returned by a previous page.
+type Filter struct {
+	Actor    string
+	Severity string
+	Since    time.Time
+	Until    time.Time
+	Cursor   string
+	Limit    int
+}
+
+// Listener is notified after an event has been persisted
+type Listener func(Event)
+
+// Engine persists trace events to SQLite and keeps the newest ones in a bounded ring
+type Engine struct {
+	mu        sync.RWMutex
+	ring      []Event
+	head      int // index of the next write
+	size      int
+	listeners []Listener
+}
+
+var defaultEngine = NewEngine(DefaultCapacity)
+
+// NewEngine creates an engine whose hot ring holds at most capacity events
+func NewEngine(capacity int) *Engine {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Engine{ring: make([]Event, capacity)}
+}
+
+// Init replaces the default engine and warms its ring from the database.
+// Listeners registered on the previous default engine carry over.
+func Init(capacity int) error {
+	e := NewEngine(capacity)
+	if err := e.warm(); err != nil {
+		return err
+	}
+	defaultEngine.mu.RLock()
+	e.listeners = append(e.listeners, defaultEngine.listeners...)
+	defaultEngine.mu.RUnlock()
+	defaultEngine = e
+	return nil
+}
+
+// OnRecord registers a listener on the default engine
+func OnRecord(l Listener) { defaultEngine.OnRecord(l) }
+
+// Record persists an event through the default engine
+func Record(ctx context.Context, ev Event) (Event, error) { return defaultEngine.Record(ctx, ev) }
+
+// Recent returns the newest events from the default engine's ring
+func Recent(limit int) []Event { return defaultEngine.Recent(limit) }
+
+// Query pages through events of the default engine
+func Query(ctx context.Context, f Filter) ([]Event, string, error) {
+	return defaultEngine.Query(ctx, f)
+}
+
+// NormalizeSeverity lowercases s and validates it; an empty value maps to low
+func NormalizeSeverity(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return SeverityLow, nil
+	}
+	for _, known := range severities {
+		if s == known {
+			return s, nil
+		}
+	}
+	return "", ErrInvalidSeverity
+}
+
+// SeverityRank orders severities; unknown values rank below low
+func SeverityRank(s string) int {
+	for i, known := range severities {
+		if s == known {
+			return i
+		}
+	}
+	return -1
+}
+
+// Record fills defaults, writes the event to SQLite and pushes it onto the ring
+func (e *Engine) Record(ctx context.Context, ev Event) (Event, error) {
+	severity, err := NormalizeSeverity(ev.Severity)
+	if err != nil {
+		return Event{}, err
+	}
+	ev.Severity = severity
+	if ev.ID == "" {
+		// 128 random bits: trace_events.id is unique and every security event is traced,
+		// so shorter ids would collide within a busy server's lifetime
+		ev.ID = utils.GenerateSecureToken(16)
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now().UTC()
+	}
+
+	row := toModel(ev)
+	if err := sqlite.InsertTraceEvent(ctx, &row); err != nil {
+		return Event{}, err
+	}
+	ev.Seq = row.Seq
+
+	e.mu.Lock()
+	e.push(ev)
+	listeners := e.listeners
+	e.mu.Unlock()
+
+	for _, l := range listeners {
+		l(ev)
+	}
+	return ev, nil
+}
+
+// OnRecord registers a listener called synchronously after every Record
+func (e *Engine) OnRecord(l Listener) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, l)
+}
+
+// Recent returns up to limit events from the ring, newest first
+func (e *Engine) Recent(limit int) []Event {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if limit <= 0 || limit > e.size {
+		limit = e.size
+	}
+	out := make([]Event, 0, limit)
+	for i := 1; i <= limit; i++ {
+		idx := (e.head - i + len(e.ring)) % len(e.ring)
+		out = append(out, e.ring[idx])
+	}
+	return out
+}
+
+// Query returns one page of events, newest first, and the cursor for the next page.
+// Unfiltered first pages that fit in the ring are served from memory.
+func (e *Engine) Query(ctx context.Context, f Filter) ([]Event, string, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+
+	var before int64
+	if f.Cursor != "" {
+		seq, err := strconv.ParseInt(f.Cursor, 10, 64)
+		if err != nil || seq <= 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		before = seq
+	}
+
+	unfiltered := f.Actor == "" && f.Severity == "" && f.Since.IsZero() && f.Until.IsZero() && before == 0
+	if unfiltered {
+		e.mu.RLock()
+		hot := f.Limit < e.size
+		e.mu.RUnlock()
+		if hot {
+			events := e.Recent(f.Limit)
+			return events, cursorFor(events, f.Limit), nil
+		}
+	}
+
+	rows, err := sqlite.QueryTraceEvents(ctx, sqlite.TraceFilter{
+		Actor:     f.Actor,
+		Severity:  f.Severity,
+		Since:     f.Since,
+		Until:     f.Until,
+		BeforeSeq: before,
+		Limit:     f.Limit,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, fromModel(row))
+	}
+	return events, cursorFor(events, f.Limit), nil
+}
+
+func (e *Engine) warm() error {
+	rows, err := sqlite.QueryTraceEvents(context.Background(), sqlite.TraceFilter{Limit: len(e.ring)})
+	if err != nil {
+		return err
+	}
+	// rows are newest first; replay oldest first so the ring order matches
+	for i := len(rows) - 1; i >= 0; i-- {
+		e.push(fromModel(rows[i]))
+	}
+	return nil
+}
+
+func (e *Engine) push(ev Event) {
+	e.ring[e.head] = ev
+	e.head = (e.head + 1) % len(e.ring)
+	if e.size < len(e.ring) {
+		e.size++
+	}
+}
+
+func cursorFor(events []Event, limit int) string {
+	if len(events) < limit || len(events) == 0 {
+		return ""
+	}
+	return strconv.FormatInt(events[len(events)-1].Seq, 10)
+}
+
+func toModel(ev Event) models.TraceLog {
+	return models.TraceLog{
+		ID:        ev.ID,
+		Actor:     ev.Actor,
+		Event:     ev.Event,
+		Severity:  ev.Severity,
+		Lock:      ev.Lock,
+		Scope:     ev.Scope,
+		Target:    ev.Target,
+		Message:   ev.Message,
+		CreatedAt: ev.Timestamp,
+	}
+}
+
+func fromModel(row models.TraceLog) Event {
+	return Event{
+		Seq:       row.Seq,
+		ID:        row.ID,
+		Actor:     row.Actor,
+		Event:     row.Event,
+		Severity:  row.Severity,
+		Lock:      row.Lock,
+		Scope:     row.Scope,
+		Target:    row.Target,
+		Message:   row.Message,
+		Timestamp: row.CreatedAt,
+	}
+}
@@ -0,0 +1,165 @@
+// Package tracing sets up OpenTelemetry for peitho-server. Every HTTP request gets a server
+// span named after its route, with child spans for Keycloak calls and SQLite queries.
+// PEITHO_TRACING_EXPORTER sends spans to an OTLP collector, to stdout, or nowhere. Incoming
+// W3C traceparent headers are honoured and outgoing requests carry one, whichever exporter
+// is chosen, so a trace started upstream continues through this server.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/peithosecure/peitho-backend/internal/buildinfo"
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters selectable with PEITHO_TRACING_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ServiceName identifies peitho-server in traces unless OTEL_SERVICE_NAME overrides it
+const ServiceName = "peitho-server"
+
+const instrumentation = "github.com/peithosecure/peitho-backend"
+
+// Init installs the W3C trace-context propagator and, unless the exporter is none, a tracer
+// provider sending spans to it. The returned function flushes buffered spans and should run
+// at shutdown. With none, spans are never recorded but trace context still propagates.
+func Init(ctx context.Context, cfg *config.Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.TracingExporter == ExporterNone || cfg.TracingExporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exp, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter: %w", err)
+	}
+	// attributes first, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES can override them
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", ServiceName),
+			attribute.String("service.version", buildinfo.Get().Version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		// follow the caller's sampling decision; sample a share of the traces started here
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(cfg.TracingSamplePercent)/100))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	switch cfg.TracingExporter {
+	case ExporterStdout:
+		return stdouttrace.New()
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.TracingExporter)
+	}
+}
+
+// Tracer returns the tracer peitho-server's spans are created with
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start begins a span named name, a child of the span in ctx if there is one
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End marks span failed when err is set and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns ctx carrying the remote span context from an incoming request's headers
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// Inject adds the traceparent (and baggage) headers for the span in ctx to h
+func Inject(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Transport wraps base, or http.DefaultTransport when nil, so each outgoing request gets a
+// client span and carries its traceparent header
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := StartClient(req.Context(), "HTTP "+req.Method, req)
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	resp, err := t.base.RoundTrip(req)
+	EndClient(span, resp, err)
+	return resp, err
+}
+
+// StartClient begins a client span for req, which the caller must send with the returned
+// context's headers injected; see Transport
+func StartClient(ctx context.Context, name string, req *http.Request) (context.Context, trace.Span) {
+	return Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			// the query is left out: it may hold usernames or tokens
+			attribute.String("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		),
+	)
+}
+
+// EndClient records the outcome of a request started with StartClient. Server errors mark
+// the span failed; 4xx answers are the caller's to judge.
+func EndClient(span trace.Span, resp *http.Response, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	End(span, err)
+}
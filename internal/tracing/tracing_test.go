@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/peithosecure/peitho-backend/internal/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus codes.Code
+	}{
+		{name: "success", status: http.StatusOK, wantStatus: codes.Unset},
+		{name: "client error is left to the caller", status: http.StatusNotFound, wantStatus: codes.Unset},
+		{name: "server error fails the span", status: http.StatusBadGateway, wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracingtest.RecordSpans(t)
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			ctx, parent := Start(context.Background(), "parent")
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/realms/x?token=secret", nil)
+			resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			resp.Body.Close()
+			parent.End()
+
+			spans := exp.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want client and parent", len(spans))
+			}
+			client := spans[0]
+			if client.Name != "HTTP GET" || client.SpanKind != trace.SpanKindClient {
+				t.Fatalf("client span = %q (%v)", client.Name, client.SpanKind)
+			}
+			if client.Parent.SpanID() != parent.SpanContext().SpanID() || client.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+				t.Fatal("client span is not a child of the caller's span")
+			}
+			if want := "00-" + client.SpanContext.TraceID().String() + "-" + client.SpanContext.SpanID().String() + "-01"; traceparent != want {
+				t.Fatalf("traceparent = %q, want %q", traceparent, want)
+			}
+			if got, _ := tracingtest.Attr(client, "url.full"); got.AsString() != srv.URL+"/realms/x" {
+				t.Fatalf("url.full = %q; the query must be left out", got.AsString())
+			}
+			if got, _ := tracingtest.Attr(client, "http.response.status_code"); got.AsInt64() != int64(tt.status) {
+				t.Fatalf("http.response.status_code = %d, want %d", got.AsInt64(), tt.status)
+			}
+			if client.Status.Code != tt.wantStatus {
+				t.Fatalf("span status = %v, want %v", client.Status.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTransportConnectionError(t *testing.T) {
+	exp := tracingtest.RecordSpans(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // nothing listens any more
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+	if _, err := (&http.Client{Transport: Transport(nil)}).Do(req); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	spans := exp.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error || len(spans[0].Events) == 0 {
+		t.Fatalf("want one failed span with the error recorded, got %+v", spans)
+	}
+}
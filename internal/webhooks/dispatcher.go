@@ -0,0 +1,338 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+	"github.com/peithosecure/peitho-backend/internal/events"
+	"github.com/peithosecure/peitho-backend/internal/tracing"
+	"github.com/peithosecure/peitho-backend/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	batchSize    = 20
+	maxBackoff   = time.Hour
+)
+
+var (
+	ErrInvalidURL   = errors.New("webhook url must be an absolute http(s) url")
+	ErrInvalidEvent = errors.New("unknown webhook event type")
+)
+
+// Payload is the JSON body posted to subscribers
+type Payload struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Data       map[string]string `json:"data"`
+}
+
+type dispatcher struct {
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+
+	wake chan struct{}
+	stop context.CancelFunc
+	done sync.WaitGroup
+}
+
+var d = &dispatcher{
+	client:      &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+	maxAttempts: 6,
+	baseBackoff: 5 * time.Second,
+	wake:        make(chan struct{}, 1),
+}
+
+// Start subscribes to the event bus and launches the delivery worker
+func Start(cfg *config.Config) {
+	if cfg.WebhookMaxAttempts > 0 {
+		d.maxAttempts = cfg.WebhookMaxAttempts
+	}
+	if cfg.WebhookTimeout > 0 {
+		d.client.Timeout = cfg.WebhookTimeout
+	}
+
+	events.SubscribeAsync("webhooks", enqueueEvent, events.AsyncOptions{Buffer: 256, Policy: events.Block})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stop = cancel
+	d.done.Add(1)
+	go d.run(ctx)
+	log.Printf("🪝 Webhook dispatcher started (max %d attempts)", d.maxAttempts)
+}
+
+// Stop halts the delivery worker; pending deliveries resume on next start
+func Stop() {
+	if d.stop != nil {
+		d.stop()
+		d.done.Wait()
+	}
+}
+
+// CreateSubscription validates and stores a subscription, generating a secret when none is given
+func CreateSubscription(ctx context.Context, rawURL string, eventTypes []string, secret string) (*models.WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+	if len(eventTypes) == 0 {
+		eventTypes = []string{"*"}
+	}
+	for _, t := range eventTypes {
+		if t != "*" && !events.Known(events.Type(t)) {
+			return nil, ErrInvalidEvent
+		}
+	}
+	if secret == "" {
+		secret = "whsec_" + utils.GenerateSecureToken(24)
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:       u.String(),
+		Events:    eventTypes,
+		Secret:    secret,
+		Active:    true,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := sqlite.InsertWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Replay queues a fresh delivery carrying the same payload as an earlier one
+func Replay(ctx context.Context, deliveryID int64) (*models.WebhookDelivery, error) {
+	orig, err := sqlite.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil || orig == nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	replay := &models.WebhookDelivery{
+		SubscriptionID: orig.SubscriptionID,
+		EventType:      orig.EventType,
+		Payload:        orig.Payload,
+		Status:         StatusPending,
+		ReplayOf:       orig.ID,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	if err := sqlite.InsertWebhookDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+	d.nudge()
+	return replay, nil
+}
+
+// enqueueEvent records one pending delivery per matching active subscription
+func enqueueEvent(ev events.Event) {
+	ctx := context.Background()
+	subs, err := sqlite.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		log.Printf("⚠️ Webhooks: failed to load subscriptions: %v", err)
+		return
+	}
+
+	data := map[string]string{}
+	if ev.Username != "" {
+		data["username"] = ev.Username
+	}
+	if ev.IP != "" {
+		data["ip"] = ev.IP
+	}
+	if ev.Reason != "" {
+		data["reason"] = ev.Reason
+	}
+
+	queued := false
+	for _, sub := range subs {
+		if !sub.Active || !matches(sub.Events, string(ev.Type)) {
+			continue
+		}
+
+		body, err := json.Marshal(Payload{
+			ID:         utils.GenerateSecureToken(12),
+			Type:       string(ev.Type),
+			OccurredAt: ev.Time,
+			Data:       data,
+		})
+		if err != nil {
+			log.Printf("⚠️ Webhooks: failed to encode %s: %v", ev.Type, err)
+			return
+		}
+
+		now := time.Now().UTC()
+		if err := sqlite.InsertWebhookDelivery(ctx, &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventType:      string(ev.Type),
+			Payload:        string(body),
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}); err != nil {
+			log.Printf("⚠️ Webhooks: failed to queue %s for subscription %d: %v", ev.Type, sub.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		d.nudge()
+	}
+}
+
+func matches(filter []string, eventType string) bool {
+	for _, f := range filter {
+		if f == "*" || f == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *dispatcher) nudge() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *dispatcher) run(ctx context.Context) {
+	defer d.done.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := sqlite.DueWebhookDeliveries(ctx, time.Now(), batchSize)
+		if err != nil {
+			log.Printf("⚠️ Webhooks: failed to load due deliveries: %v", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+		for i := range due {
+			d.attempt(ctx, &due[i])
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+func (d *dispatcher) attempt(ctx context.Context, del *models.WebhookDelivery) {
+	ctx, span := tracing.Start(ctx, "webhook deliver", trace.WithAttributes(
+		attribute.Int64("webhook.delivery_id", del.ID),
+		attribute.String("webhook.event", del.EventType),
+	))
+	defer span.End()
+
+	sub, err := sqlite.GetWebhookSubscription(ctx, del.SubscriptionID)
+	if err != nil {
+		log.Printf("⚠️ Webhooks: failed to load subscription %d: %v", del.SubscriptionID, err)
+		return
+	}
+
+	del.Attempts++
+	if sub == nil {
+		del.Status = StatusFailed
+		del.LastError = "subscription deleted"
+	} else {
+		code, sendErr := d.send(ctx, sub, del)
+		del.ResponseCode = code
+		switch {
+		case sendErr == nil:
+			now := time.Now().UTC()
+			del.Status = StatusDelivered
+			del.LastError = ""
+			del.DeliveredAt = &now
+		case del.Attempts >= d.maxAttempts:
+			del.Status = StatusFailed
+			del.LastError = sendErr.Error()
+		default:
+			del.LastError = sendErr.Error()
+			del.NextAttemptAt = time.Now().UTC().Add(d.backoff(del.Attempts))
+		}
+	}
+
+	if err := sqlite.UpdateWebhookDelivery(ctx, del); err != nil {
+		log.Printf("⚠️ Webhooks: failed to record delivery %d: %v", del.ID, err)
+	}
+	if del.Status == StatusFailed {
+		log.Printf("🪝 Webhook delivery %d to subscription %d gave up after %d attempt(s): %s",
+			del.ID, del.SubscriptionID, del.Attempts, del.LastError)
+	}
+}
+
+func (d *dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, del *models.WebhookDelivery) (int, error) {
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PeithoSecure-Webhooks/1.0")
+	req.Header.Set("X-Peitho-Event", del.EventType)
+	req.Header.Set("X-Peitho-Delivery", strconv.FormatInt(del.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, capped at maxBackoff
+func (d *dispatcher) backoff(attempts int) time.Duration {
+	wait := d.baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/db/models"
+	"github.com/peithosecure/peitho-backend/internal/db/sqlite"
+)
+
+const testSecret = "whsec_test"
+
+// receiver answers each delivery with the next status in codes, repeating the last one,
+// and records whether every request carried a valid signature
+type receiver struct {
+	t     *testing.T
+	codes []int
+
+	mu       sync.Mutex
+	requests int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := Verify(testSecret, r.Header.Get(SignatureHeader), body, time.Minute, time.Now()); err != nil {
+		rc.t.Errorf("delivery signature: %v", err)
+	}
+	if got := r.Header.Get("X-Peitho-Event"); got != "login_failed" {
+		rc.t.Errorf("X-Peitho-Event = %q, want login_failed", got)
+	}
+
+	rc.mu.Lock()
+	code := rc.codes[min(rc.requests, len(rc.codes)-1)]
+	rc.requests++
+	rc.mu.Unlock()
+	w.WriteHeader(code)
+}
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	sqlite.InitDB(filepath.Join(t.TempDir(), "webhooks.db"))
+	t.Cleanup(func() { _ = sqlite.Close() })
+}
+
+func TestAttemptRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		maxAttempts  int
+		wantStatus   string
+		wantAttempts int
+		wantCode     int
+	}{
+		{name: "delivered first time", codes: []int{http.StatusOK}, maxAttempts: 3, wantStatus: StatusDelivered, wantAttempts: 1, wantCode: http.StatusOK},
+		{name: "delivered after retries", codes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}, maxAttempts: 3, wantStatus: StatusDelivered, wantAttempts: 3, wantCode: http.StatusNoContent},
+		{name: "gives up at max attempts", codes: []int{http.StatusServiceUnavailable}, maxAttempts: 4, wantStatus: StatusFailed, wantAttempts: 4, wantCode: http.StatusServiceUnavailable},
+		{name: "client errors are retried too", codes: []int{http.StatusGone}, maxAttempts: 2, wantStatus: StatusFailed, wantAttempts: 2, wantCode: http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			ctx := context.Background()
+			rc := &receiver{t: t, codes: tt.codes}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+
+			sub, err := CreateSubscription(ctx, srv.URL, nil, testSecret)
+			if err != nil {
+				t.Fatalf("CreateSubscription: %v", err)
+			}
+			now := time.Now().UTC()
+			del := &models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventType:      "login_failed",
+				Payload:        `{"type":"login_failed"}`,
+				Status:         StatusPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			}
+			if err := sqlite.InsertWebhookDelivery(ctx, del); err != nil {
+				t.Fatalf("InsertWebhookDelivery: %v", err)
+			}
+
+			disp := &dispatcher{client: srv.Client(), maxAttempts: tt.maxAttempts, baseBackoff: time.Second}
+			for i := 0; i < tt.maxAttempts+2 && del.Status == StatusPending; i++ {
+				before := time.Now()
+				disp.attempt(ctx, del)
+				if del.Status == StatusPending && del.NextAttemptAt.Before(before.Add(disp.backoff(del.Attempts))) {
+					t.Errorf("attempt %d rescheduled at %v, want at least %v later", del.Attempts, del.NextAttemptAt, disp.backoff(del.Attempts))
+				}
+			}
+
+			got, err := sqlite.GetWebhookDelivery(ctx, del.ID)
+			if err != nil || got == nil {
+				t.Fatalf("GetWebhookDelivery: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Attempts != tt.wantAttempts || got.ResponseCode != tt.wantCode {
+				t.Fatalf("stored delivery = %s after %d attempt(s) with %d, want %s after %d with %d",
+					got.Status, got.Attempts, got.ResponseCode, tt.wantStatus, tt.wantAttempts, tt.wantCode)
+			}
+			if rc.requests != tt.wantAttempts {
+				t.Fatalf("receiver saw %d request(s), want %d", rc.requests, tt.wantAttempts)
+			}
+			if tt.wantStatus == StatusDelivered && (got.DeliveredAt == nil || got.LastError != "") {
+				t.Fatalf("delivered row has delivered_at %v and last_error %q", got.DeliveredAt, got.LastError)
+			}
+			if tt.wantStatus == StatusFailed && got.LastError != "receiver responded "+strconv.Itoa(tt.wantCode)+" "+http.StatusText(tt.wantCode) {
+				t.Fatalf("last_error = %q", got.LastError)
+			}
+		})
+	}
+}
+
+func TestAttemptDeletedSubscription(t *testing.T) {
+	openTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	del := &models.WebhookDelivery{SubscriptionID: 999, EventType: "login_failed", Payload: "{}", Status: StatusPending, NextAttemptAt: now, CreatedAt: now}
+	if err := sqlite.InsertWebhookDelivery(ctx, del); err != nil {
+		t.Fatalf("InsertWebhookDelivery: %v", err)
+	}
+
+	(&dispatcher{client: http.DefaultClient, maxAttempts: 5, baseBackoff: time.Second}).attempt(ctx, del)
+	if del.Status != StatusFailed || del.LastError != "subscription deleted" {
+		t.Fatalf("delivery = %s (%q), want failed (subscription deleted)", del.Status, del.LastError)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	disp := &dispatcher{baseBackoff: 5 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{6, 160 * time.Second},
+		{11, maxBackoff}, // 5s·2^10 is past the hour cap
+		{50, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := disp.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		filter []string
+		event  string
+		want   bool
+	}{
+		{[]string{"*"}, "login_failed", true},
+		{[]string{"login_failed"}, "login_failed", true},
+		{[]string{"logout", "login_failed"}, "login_failed", true},
+		{[]string{"logout"}, "login_failed", false},
+		{nil, "login_failed", false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.filter, tt.event); got != tt.want {
+			t.Errorf("matches(%v, %q) = %v, want %v", tt.filter, tt.event, got, tt.want)
+		}
+	}
+}
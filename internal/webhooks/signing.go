@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>" on every delivery
+const SignatureHeader = "X-Peitho-Signature"
+
+// Sign returns the signature header value for body sent at ts.
+// The MAC covers "<unix seconds>.<body>" so a captured payload cannot be replayed with a new timestamp.
+func Sign(secret string, ts time.Time, body []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + unix + ",v1=" + mac(secret, unix, body)
+}
+
+// Verify checks a signature header against body, rejecting timestamps older than tolerance.
+// Receivers can use it as the reference implementation.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			unix = v
+		case "v1":
+			sig = v
+		}
+	}
+	if unix == "" || sig == "" {
+		return errors.New("malformed signature header")
+	}
+
+	sec, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return errors.New("malformed signature timestamp")
+	}
+	if tolerance > 0 && now.Sub(time.Unix(sec, 0)) > tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, unix, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret, unix string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(unix))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"type":"login_failed"}`)
+	sent := time.Unix(1_700_000_000, 0)
+	header := Sign(secret, sent, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		tolerance time.Duration
+		now       time.Time
+		wantErr   string
+	}{
+		{name: "valid", secret: secret, header: header, body: body, tolerance: 5 * time.Minute, now: sent.Add(time.Minute)},
+		{name: "tolerance disabled", secret: secret, header: header, body: body, now: sent.Add(24 * time.Hour)},
+		{name: "spaces around parts", secret: secret, header: strings.ReplaceAll(header, ",", ", "), body: body, now: sent},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"type":"logout"}`), now: sent, wantErr: "signature mismatch"},
+		{name: "wrong secret", secret: "whsec_other", header: header, body: body, now: sent, wantErr: "signature mismatch"},
+		{name: "replayed with new timestamp", secret: secret, header: strings.Replace(header, "t=1700000000", "t=1700000600", 1), body: body, now: sent.Add(10 * time.Minute), wantErr: "signature mismatch"},
+		{name: "too old", secret: secret, header: header, body: body, tolerance: 5 * time.Minute, now: sent.Add(6 * time.Minute), wantErr: "outside tolerance"},
+		{name: "missing signature", secret: secret, header: "t=1700000000", body: body, now: sent, wantErr: "malformed signature header"},
+		{name: "missing timestamp", secret: secret, header: header[strings.Index(header, "v1="):], body: body, now: sent, wantErr: "malformed signature header"},
+		{name: "bad timestamp", secret: secret, header: "t=soon,v1=00", body: body, now: sent, wantErr: "malformed signature timestamp"},
+		{name: "empty header", secret: secret, body: body, now: sent, wantErr: "malformed signature header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.tolerance, tt.now)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Verify() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Verify() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignFormat(t *testing.T) {
+	got := Sign("s", time.Unix(42, 0), []byte("{}"))
+	if !strings.HasPrefix(got, "t=42,v1=") {
+		t.Fatalf("Sign() = %q, want t=42,v1=<mac>", got)
+	}
+	if mac := strings.TrimPrefix(got, "t=42,v1="); len(mac) != 64 {
+		t.Fatalf("mac is %d hex chars, want 64", len(mac))
+	}
+}
@@ -2,9 +2,15 @@ package prowler
 
 import (
 	"log"
+
+	"github.com/peithosecure/peitho-backend/internal/events"
 )
 
 // TriggerAlert sends a simulated security alert
 func TriggerAlert(reason string) {
 	log.Printf("[Prowler] Security alert triggered: %s", reason)
+	events.Publish(events.Event{
+		Type:   events.SecurityAlert,
+		Reason: reason,
+	})
 }
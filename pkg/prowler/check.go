@@ -0,0 +1,126 @@
+// Package prowler runs security checks against the running configuration: TLS, CORS,
+// credentials, file permissions, the license and the services the server depends on. Checks
+// are registered once, a scan runs them all and scores the outcome, and the report renders
+// as JSON or as SARIF for code-scanning dashboards.
+package prowler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+)
+
+// Severity ranks how much a failing check matters
+type Severity string
+
+// Severities, lowest first
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// AtLeast reports whether s is as severe as o or more
+func (s Severity) AtLeast(o Severity) bool {
+	return s.weight() >= o.weight()
+}
+
+// weight is a severity's share of the score, which also orders severities
+func (s Severity) weight() int {
+	switch s {
+	case SeverityCritical:
+		return 10
+	case SeverityHigh:
+		return 5
+	case SeverityMedium:
+		return 3
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Status is the outcome of one check
+type Status string
+
+// Statuses
+const (
+	StatusPass  Status = "pass"
+	StatusFail  Status = "fail"
+	StatusSkip  Status = "skip"  // the check does not apply to this deployment
+	StatusError Status = "error" // the check could not run; counts as a failure in the score
+)
+
+// Check is one security check. Run inspects cfg and whatever files or services it names,
+// and must return once ctx is done.
+type Check interface {
+	ID() string // stable kebab-case identifier, also the SARIF rule id
+	Title() string
+	Severity() Severity
+	Remediation() string
+	Run(ctx context.Context, cfg *config.Config) Outcome
+}
+
+// Outcome is what a check found, with a human-readable detail
+type Outcome struct {
+	Status Status
+	Detail string
+}
+
+// Pass, Fail, Skip and Error build outcomes with a formatted detail
+func Pass(format string, args ...any) Outcome  { return outcome(StatusPass, format, args) }
+func Fail(format string, args ...any) Outcome  { return outcome(StatusFail, format, args) }
+func Skip(format string, args ...any) Outcome  { return outcome(StatusSkip, format, args) }
+func Error(format string, args ...any) Outcome { return outcome(StatusError, format, args) }
+
+func outcome(s Status, format string, args []any) Outcome {
+	return Outcome{Status: s, Detail: fmt.Sprintf(format, args...)}
+}
+
+// NewCheck makes a Check from run, for checks that keep no state of their own
+func NewCheck(id, title string, severity Severity, remediation string, run func(ctx context.Context, cfg *config.Config) Outcome) Check {
+	return funcCheck{id: id, title: title, severity: severity, remediation: remediation, run: run}
+}
+
+type funcCheck struct {
+	id, title   string
+	severity    Severity
+	remediation string
+	run         func(context.Context, *config.Config) Outcome
+}
+
+func (c funcCheck) ID() string          { return c.id }
+func (c funcCheck) Title() string       { return c.title }
+func (c funcCheck) Severity() Severity  { return c.severity }
+func (c funcCheck) Remediation() string { return c.remediation }
+
+func (c funcCheck) Run(ctx context.Context, cfg *config.Config) Outcome { return c.run(ctx, cfg) }
+
+var (
+	mu       sync.RWMutex
+	registry []Check
+)
+
+// Register adds c to every later scan. IDs are unique; registering one twice panics.
+func Register(c Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, existing := range registry {
+		if existing.ID() == c.ID() {
+			panic("prowler: check " + c.ID() + " registered twice")
+		}
+	}
+	registry = append(registry, c)
+}
+
+// Checks returns the registered checks in registration order
+func Checks() []Check {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Check(nil), registry...)
+}
@@ -0,0 +1,236 @@
+package prowler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/auth/peitho"
+	"github.com/peithosecure/peitho-backend/internal/config"
+	"github.com/peithosecure/peitho-backend/internal/health"
+	"github.com/peithosecure/peitho-backend/internal/listener"
+	"github.com/peithosecure/peitho-backend/internal/middleware"
+	"github.com/peithosecure/peitho-backend/internal/passwordpolicy"
+)
+
+// certRenewalWindow is how long before expiry the certificate check starts failing
+const certRenewalWindow = 30 * 24 * time.Hour
+
+// defaultPasswords are shipped in examples and images and tried by every scanner
+var defaultPasswords = []string{"admin", "password", "changeme", "keycloak", "peitho", "secret", "123456"}
+
+func init() {
+	Register(NewCheck("tls-min-version", "Listener refuses TLS versions older than 1.2", SeverityHigh,
+		"Serve with PEITHO_LISTEN_MODE=tls or mtls, or set the minimum to TLS 1.2 on the proxy terminating TLS.",
+		checkTLSVersion))
+	Register(NewCheck("tls-cert-expiry", "TLS certificate is valid and not about to expire", SeverityHigh,
+		"Renew the certificate at TLS_CERT_FILE; the server picks up the new files without a restart.",
+		checkCertExpiry))
+	Register(NewCheck("cors-wildcard", "CORS does not allow every origin", SeverityMedium,
+		"List the frontend origins in CORS_ALLOWED_ORIGINS instead of *.",
+		checkCORSWildcard))
+	Register(NewCheck("default-admin-credentials", "Admin credentials are not defaults", SeverityCritical,
+		"Set a unique KEYCLOAK_ADMIN_PASSWORD and regenerate PEITHO_ADMIN_PASSWORD_HASH with peithoctl admin hash-password.",
+		checkDefaultCredentials))
+	Register(NewCheck("sqlite-permissions", "Database file is private to the server account", SeverityHigh,
+		"chmod 600 the file at PEITHO_SQLITE_PATH and its -wal and -shm companions.",
+		checkSQLitePermissions))
+	Register(NewCheck("license-valid", "License file is present and correctly signed", SeverityCritical,
+		"Install a valid unlock.lic at UNLOCK_PATH.",
+		checkLicense))
+	Register(NewCheck("smtp-tls", "Mail server offers STARTTLS", SeverityHigh,
+		"Point SMTP_HOST and SMTP_PORT (usually 587) at a server offering STARTTLS with a trusted certificate.",
+		checkSMTPTLS))
+	Register(NewCheck("jwks-reachable", "Token signing keys can be fetched", SeverityHigh,
+		"Make sure Keycloak is running and reachable from the server; without its keys no bearer token verifies.",
+		checkJWKS))
+}
+
+// checkTLSVersion inspects the TLS configuration the listener was actually built with, so it
+// only has something to look at inside the serving process
+func checkTLSVersion(_ context.Context, cfg *config.Config) Outcome {
+	if cfg.ListenMode == listener.ModeHTTP {
+		return Skip("PEITHO_LISTEN_MODE=http: TLS is terminated in front of the server; check the proxy")
+	}
+	conf := listener.Active()
+	if conf == nil {
+		return Skip("no TLS listener runs in this process; scan through /api/v1/security-scan on the server")
+	}
+	oldest := conf.MinVersion
+	if oldest == 0 {
+		oldest = tls.VersionTLS12 // crypto/tls's default for servers
+	}
+	if oldest < tls.VersionTLS12 {
+		return Fail("the %s listener accepts %s", cfg.ListenMode, tls.VersionName(oldest))
+	}
+	return Pass("the %s listener requires %s or newer", cfg.ListenMode, tls.VersionName(oldest))
+}
+
+func checkCertExpiry(_ context.Context, cfg *config.Config) Outcome {
+	if cfg.ListenMode == listener.ModeHTTP {
+		return Skip("PEITHO_LISTEN_MODE=http: the certificate lives on the proxy")
+	}
+	cert, err := leafCertificate(cfg.TLSCertFile)
+	if err != nil {
+		return Error("%v", err)
+	}
+	now := time.Now()
+	switch left := cert.NotAfter.Sub(now); {
+	case left <= 0:
+		return Fail("%s expired on %s", cert.Subject.CommonName, cert.NotAfter.Format(time.DateOnly))
+	case now.Before(cert.NotBefore):
+		return Fail("%s is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.DateOnly))
+	case left < certRenewalWindow:
+		return Fail("%s expires in %d days, on %s", cert.Subject.CommonName, int(left.Hours()/24), cert.NotAfter.Format(time.DateOnly))
+	default:
+		return Pass("%s is valid until %s", cert.Subject.CommonName, cert.NotAfter.Format(time.DateOnly))
+	}
+}
+
+// leafCertificate parses the first certificate in a PEM file
+func leafCertificate(path string) (*x509.Certificate, error) {
+	if path == "" {
+		return nil, errors.New("TLS_CERT_FILE is not set")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			return nil, fmt.Errorf("%s holds no certificate", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func checkCORSWildcard(_ context.Context, cfg *config.Config) Outcome {
+	if slices.Contains(cfg.CORSOrigins, "*") {
+		return Fail("CORS_ALLOWED_ORIGINS is *: any website can call the API from a visitor's browser")
+	}
+	return Pass("%d allowed origins: %s", len(cfg.CORSOrigins), strings.Join(cfg.CORSOrigins, ", "))
+}
+
+func checkDefaultCredentials(ctx context.Context, cfg *config.Config) Outcome {
+	var found []string
+	checked := false
+
+	if cfg.KeycloakAdminPassword != "" {
+		checked = true
+		if slices.Contains(defaultPasswords, strings.ToLower(cfg.KeycloakAdminPassword)) || cfg.KeycloakAdminPassword == cfg.KeycloakAdmin {
+			found = append(found, "KEYCLOAK_ADMIN_PASSWORD")
+		}
+	}
+	if slices.Contains(cfg.AdminAuth, middleware.AdminAuthBasic) && cfg.AdminPasswordHash != "" {
+		checked = true
+		for _, candidate := range append([]string{cfg.AdminUsername}, defaultPasswords...) {
+			if ctx.Err() != nil {
+				return Error("%v", ctx.Err())
+			}
+			if ok, _ := passwordpolicy.VerifyHash(cfg.AdminPasswordHash, candidate); ok {
+				found = append(found, "PEITHO_ADMIN_PASSWORD_HASH")
+				break
+			}
+		}
+	}
+
+	switch {
+	case len(found) > 0:
+		return Fail("%s is a default password or the username", strings.Join(found, " and "))
+	case !checked:
+		return Skip("no static admin credentials are configured")
+	default:
+		return Pass("no default passwords in use")
+	}
+}
+
+func checkSQLitePermissions(_ context.Context, cfg *config.Config) Outcome {
+	if runtime.GOOS == "windows" {
+		return Skip("file modes are not meaningful on Windows")
+	}
+	info, err := os.Stat(cfg.SQLitePath)
+	if err != nil {
+		return Error("%v", err)
+	}
+	var loose []string
+	for _, path := range []string{cfg.SQLitePath, cfg.SQLitePath + "-wal", cfg.SQLitePath + "-shm"} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue // the companions exist only while the database is open in WAL mode
+		}
+		if fi.Mode().Perm()&0o077 != 0 {
+			loose = append(loose, fmt.Sprintf("%s is %04o", path, fi.Mode().Perm()))
+		}
+	}
+	if len(loose) > 0 {
+		return Fail("readable or writable by other accounts: %s", strings.Join(loose, ", "))
+	}
+	return Pass("%s is %04o", cfg.SQLitePath, info.Mode().Perm())
+}
+
+func checkLicense(_ context.Context, cfg *config.Config) Outcome {
+	lic, err := peitho.CheckLicenseFile(cfg.UnlockPath)
+	if err != nil {
+		return Fail("%v", err)
+	}
+	return Pass("signed license issued %s", lic.IssuedAt)
+}
+
+// checkSMTPTLS talks to the mail server the way the mailer does: plain SMTP upgraded with
+// STARTTLS, which net/smtp uses whenever the server offers it
+func checkSMTPTLS(ctx context.Context, cfg *config.Config) Outcome {
+	if cfg.SMTPHost == "" || cfg.SMTPPort == "" {
+		return Skip("SMTP_HOST and SMTP_PORT are not set; no mail is sent")
+	}
+	if cfg.SMTPPort == "465" {
+		return Fail("port 465 expects implicit TLS, which the mailer does not speak; use 587 with STARTTLS")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort))
+	if err != nil {
+		return Error("%v", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		return Error("%v", err)
+	}
+	defer c.Close()
+	if err := c.Hello("localhost"); err != nil {
+		return Error("%v", err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return Fail("%s does not offer STARTTLS; credentials and mail travel in clear text", cfg.SMTPHost)
+	}
+	if err := c.StartTLS(&tls.Config{ServerName: cfg.SMTPHost, MinVersion: tls.VersionTLS12}); err != nil {
+		return Fail("STARTTLS handshake with %s failed: %v", cfg.SMTPHost, err)
+	}
+	state, _ := c.TLSConnectionState()
+	_ = c.Quit()
+	return Pass("%s offers STARTTLS (%s)", cfg.SMTPHost, tls.VersionName(state.Version))
+}
+
+func checkJWKS(ctx context.Context, _ *config.Config) Outcome {
+	if err := health.JWKS(middleware.JWKSURL())(ctx); err != nil {
+		return Fail("%v", err)
+	}
+	return Pass("%s serves signing keys", middleware.JWKSURL())
+}
@@ -0,0 +1,176 @@
+package prowler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/buildinfo"
+)
+
+// Formats
+const (
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// ErrInvalidFormat is returned by Write for formats other than json and sarif
+var ErrInvalidFormat = errors.New("format must be json or sarif")
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "peitho-prowler"
+)
+
+// ContentType returns the MIME type served for a format
+func ContentType(format string) string {
+	if format == FormatSARIF {
+		return "application/sarif+json"
+	}
+	return "application/json"
+}
+
+// Write renders r onto out in format ("json" or "sarif")
+func Write(out io.Writer, format string, r Report) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	switch format {
+	case FormatJSON:
+		return enc.Encode(r)
+	case FormatSARIF:
+		return enc.Encode(toSARIF(r))
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// The subset of SARIF 2.1.0 a scan needs: one run, one rule per check, one result per finding
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+	Properties  map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string          `json:"id"`
+	ShortDescription     sarifText       `json:"shortDescription"`
+	Help                 *sarifText      `json:"help,omitempty"`
+	DefaultConfiguration sarifRuleConfig `json:"defaultConfiguration"`
+	Properties           map[string]any  `json:"properties"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	StartTimeUTC        string `json:"startTimeUtc"`
+	EndTimeUTC          string `json:"endTimeUtc"`
+}
+
+type sarifResult struct {
+	RuleID    string    `json:"ruleId"`
+	RuleIndex int       `json:"ruleIndex"`
+	Kind      string    `json:"kind"`
+	Level     string    `json:"level"`
+	Message   sarifText `json:"message"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+func toSARIF(r Report) sarifLog {
+	remediation := map[string]string{}
+	for _, c := range Checks() {
+		remediation[c.ID()] = c.Remediation()
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: toolName, Version: buildinfo.Get().Version}},
+		Invocations: []sarifInvocation{{
+			ExecutionSuccessful: true,
+			StartTimeUTC:        r.StartedAt.Format(time.RFC3339),
+			EndTimeUTC:          r.StartedAt.Add(time.Duration(r.Duration) * time.Millisecond).Format(time.RFC3339),
+		}},
+		Results:    []sarifResult{},
+		Properties: map[string]any{"score": r.Score},
+	}
+	for i, f := range r.Findings {
+		rule := sarifRule{
+			ID:                   f.ID,
+			ShortDescription:     sarifText{Text: f.Title},
+			DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(f.Severity)},
+			Properties:           map[string]any{"security-severity": securitySeverity(f.Severity), "tags": []string{"security"}},
+		}
+		if text := remediation[f.ID]; text != "" {
+			rule.Help = &sarifText{Text: text}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		res := sarifResult{RuleID: f.ID, RuleIndex: i, Level: "none", Message: sarifText{Text: f.Detail}}
+		switch f.Status {
+		case StatusPass:
+			res.Kind = "pass"
+		case StatusSkip:
+			res.Kind = "notApplicable"
+		case StatusError:
+			// the check could not tell; someone has to look
+			res.Kind, res.Level = "review", "warning"
+		default:
+			res.Kind, res.Level = "fail", sarifLevel(f.Severity)
+		}
+		if res.Message.Text == "" {
+			res.Message.Text = f.Title
+		}
+		run.Results = append(run.Results, res)
+	}
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity is the CVSS-like score code-scanning tools sort findings by
+func securitySeverity(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "9.5"
+	case SeverityHigh:
+		return "8.0"
+	case SeverityMedium:
+		return "5.5"
+	case SeverityLow:
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
@@ -0,0 +1,103 @@
+package prowler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/peithosecure/peitho-backend/internal/config"
+)
+
+// checkTimeout bounds each check, so an unreachable dependency cannot stall a scan
+const checkTimeout = 5 * time.Second
+
+// Finding is the result of one check
+type Finding struct {
+	ID          string   `json:"id" example:"cors-wildcard"`
+	Title       string   `json:"title" example:"CORS does not allow every origin"`
+	Severity    Severity `json:"severity" example:"medium"`
+	Status      Status   `json:"status" example:"fail"`
+	Detail      string   `json:"detail,omitempty" example:"CORS_ALLOWED_ORIGINS is *"`
+	Remediation string   `json:"remediation,omitempty" example:"List the frontend origins in CORS_ALLOWED_ORIGINS"`
+	DurationMS  int64    `json:"duration_ms" example:"0"`
+}
+
+// Report is the outcome of a scan. Score is the severity-weighted share of applicable
+// checks that passed, from 0 to 100; skipped checks do not count either way.
+type Report struct {
+	Score     int            `json:"score" example:"82"`
+	Counts    map[Status]int `json:"counts"`
+	Findings  []Finding      `json:"findings"`
+	StartedAt time.Time      `json:"started_at" example:"2025-06-01T12:00:00Z"`
+	Duration  int64          `json:"duration_ms" example:"120"`
+}
+
+// Scan runs every registered check concurrently against cfg. Failed high and critical
+// checks also raise a security alert.
+func Scan(ctx context.Context, cfg *config.Config) Report {
+	log.Println("[Prowler] Starting security scan...")
+	checks := Checks()
+	report := Report{
+		Counts:    map[Status]int{},
+		Findings:  make([]Finding, len(checks)),
+		StartedAt: time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Findings[i] = run(ctx, c, cfg)
+		}()
+	}
+	wg.Wait()
+
+	passed, applicable := 0, 0
+	for _, f := range report.Findings {
+		report.Counts[f.Status]++
+		if f.Status == StatusSkip {
+			continue
+		}
+		applicable += f.Severity.weight()
+		if f.Status == StatusPass {
+			passed += f.Severity.weight()
+			continue
+		}
+		if f.Severity == SeverityHigh || f.Severity == SeverityCritical {
+			TriggerAlert(fmt.Sprintf("%s failed: %s", f.ID, f.Detail))
+		}
+	}
+	report.Score = 100
+	if applicable > 0 {
+		report.Score = int(math.Floor(100 * float64(passed) / float64(applicable)))
+	}
+	report.Duration = time.Since(report.StartedAt).Milliseconds()
+
+	log.Printf("[Prowler] Security scan completed: score %d, %d failed", report.Score, report.Counts[StatusFail]+report.Counts[StatusError])
+	return report
+}
+
+// run executes one check, turning a panic into an error finding
+func run(ctx context.Context, c Check, cfg *config.Config) (f Finding) {
+	f = Finding{ID: c.ID(), Title: c.Title(), Severity: c.Severity()}
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			f.Status, f.Detail = StatusError, fmt.Sprintf("check panicked: %v", p)
+		}
+		if f.Status != StatusPass && f.Status != StatusSkip {
+			f.Remediation = c.Remediation()
+		}
+		f.DurationMS = time.Since(start).Milliseconds()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	out := c.Run(ctx, cfg)
+	f.Status, f.Detail = out.Status, out.Detail
+	return f
+}